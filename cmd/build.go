@@ -28,7 +28,8 @@ var buildCmd = &cobra.Command{
 
 		execDir := goBuildExecDir(buildDir, projectPath)
 
-		execCmd := exec.Command("go", "build", ".")
+		release, _ := cmd.Flags().GetBool("release")
+		execCmd := exec.Command("go", goBuildArgs(release)...)
 		execCmd.Dir = execDir
 		execCmd.Stdout = os.Stdout
 		execCmd.Stderr = os.Stderr
@@ -43,6 +44,22 @@ var buildCmd = &cobra.Command{
 	},
 }
 
+// Теги сборки, с которыми не проверяются assert (runtime/assert)
+// и контракты requires/ensures (runtime/contract).
+const (
+	noAssertTag    = "godsl_noassert"
+	noContractsTag = "godsl_nocontracts"
+)
+
+// goBuildArgs возвращает аргументы go build. В режиме release
+// assert и контракты вырезаются тегами godsl_noassert и godsl_nocontracts.
+func goBuildArgs(release bool) []string {
+	if release {
+		return []string{"build", "-tags", noAssertTag + "," + noContractsTag, "."}
+	}
+	return []string{"build", "."}
+}
+
 // goBuildExecDir возвращает директорию внутри buildDir, соответствующую projectPath.
 // Например: buildDir=build, projectPath=./examples → build/examples
 func goBuildExecDir(buildDir, projectPath string) string {
@@ -67,4 +84,5 @@ func goBuildExecDir(buildDir, projectPath string) string {
 func init() {
 	rootCmd.AddCommand(buildCmd)
 	buildCmd.Flags().Bool("clean", false, "Полная пересборка build (без инкремента)")
+	buildCmd.Flags().Bool("release", false, "Сборка без проверок assert и контрактов (теги godsl_noassert, godsl_nocontracts)")
 }
@@ -26,13 +26,15 @@ var generateCmd = &cobra.Command{
 
 		clean, _ := cmd.Flags().GetBool("clean")
 		watch, _ := cmd.Flags().GetBool("watch")
+		traceErrors, _ := cmd.Flags().GetBool("trace-errors")
+		opts := GenerateOptions{Clean: clean, TraceErrors: traceErrors}
 
 		if watch {
-			watchGenerate(projectPath, clean)
+			watchGenerate(projectPath, opts)
 			return
 		}
 
-		if _, err := generateProject(projectPath, "", GenerateOptions{Clean: clean}); err != nil {
+		if _, err := generateProject(projectPath, "", opts); err != nil {
 			fmt.Printf("Ошибка: %v\n", err)
 			return
 		}
@@ -43,15 +45,21 @@ func init() {
 	rootCmd.AddCommand(generateCmd)
 	generateCmd.Flags().Bool("clean", false, "Полная пересборка build (без инкремента)")
 	generateCmd.Flags().BoolP("watch", "w", false, "Перегенерировать при изменении .godsl файлов")
+	generateCmd.Flags().Bool("trace-errors", false, "Оборачивать ошибки из ?, throw и must позицией в .godsl (errtrace)")
 }
 
 type FileTask struct {
 	SourcePath string
 	TargetPath string
+	SourceRel  string // путь к исходнику относительно корня проекта (для позиций в трассировке)
 }
 
 type GenerateOptions struct {
-	Clean bool
+	Clean       bool
+	TraceErrors bool // режим --trace-errors: ошибки из ?, throw и must получают позицию в .godsl
+
+	signatures projectSignatures // функции с параметрами по умолчанию, собранные по проекту
+	packages   projectPackages   // объявления файлов по пакетам для проверки типов
 }
 
 const cacheFileName = ".godslcache.json"
@@ -64,9 +72,12 @@ type cacheEntry struct {
 }
 
 type buildCache struct {
-	Version int                   `json:"version"`
-	Godsl   map[string]cacheEntry `json:"godsl"` // key: relPath (.godsl)
-	Files   map[string]cacheEntry `json:"files"` // key: relPath (non-.godsl)
+	Version     int                   `json:"version"`
+	TraceErrors bool                  `json:"traceErrors,omitempty"` // режим, в котором собраны .go файлы
+	Signatures  string                `json:"signatures,omitempty"`  // хеш сигнатур функций с параметрами по умолчанию
+	Packages    string                `json:"packages,omitempty"`    // хеш объявлений файлов проекта
+	Godsl       map[string]cacheEntry `json:"godsl"`                 // key: relPath (.godsl)
+	Files       map[string]cacheEntry `json:"files"`                 // key: relPath (non-.godsl)
 }
 
 // generateProject транспилирует проект (или файл) в buildDir и копирует все остальные файлы как есть.
@@ -128,9 +139,24 @@ func generateProject(projectPath string, buildDirOverride string, opts GenerateO
 	if err != nil {
 		return "", fmt.Errorf("ошибка чтения кэша: %w", err)
 	}
-	if opts.Clean {
+	opts.signatures, err = collectProjectSignatures(walkRoot)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сбора сигнатур функций: %w", err)
+	}
+	opts.packages, err = collectProjectPackages(walkRoot)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сбора объявлений пакетов: %w", err)
+	}
+	sigHash, pkgHash := opts.signatures.hash(), opts.packages.hash()
+	if opts.Clean || cache.TraceErrors != opts.TraceErrors || cache.Signatures != sigHash || cache.Packages != pkgHash {
+		// Смена режима трассировки меняет сгенерированный код всех файлов,
+		// смена параметров по умолчанию — код вызовов в любом файле,
+		// смена объявлений — выведенные типы в соседних файлах.
 		cache = newBuildCache()
 	}
+	cache.TraceErrors = opts.TraceErrors
+	cache.Signatures = sigHash
+	cache.Packages = pkgHash
 
 	tasks, copyTasks, deletions, cachedGodsl, cachedFiles, nextCache, err := planProjectTasks(walkRoot, relBase, buildDir, cache)
 	if err != nil {
@@ -181,7 +207,7 @@ func generateProject(projectPath string, buildDirOverride string, opts GenerateO
 
 	if len(tasks) > 0 {
 		fmt.Printf("Транспиляция: %d файлов (.godsl)\n", len(tasks))
-		if err := transpileFilesParallel(tasks); err != nil {
+		if err := transpileFilesParallel(tasks, opts); err != nil {
 			return "", fmt.Errorf("ошибка транспиляции: %w", err)
 		}
 	}
@@ -259,7 +285,7 @@ func planProjectTasks(walkRoot, relBase, buildDir string, cache buildCache) ([]F
 			}
 
 			nextCache.Godsl[relPath] = cacheEntry{TargetRel: targetRel, Size: size, ModTime: modTime, Hash: h}
-			tasks = append(tasks, FileTask{SourcePath: path, TargetPath: targetPath})
+			tasks = append(tasks, FileTask{SourcePath: path, TargetPath: targetPath, SourceRel: relPath})
 			return nil
 		}
 
@@ -304,14 +330,14 @@ func planProjectTasks(walkRoot, relBase, buildDir string, cache buildCache) ([]F
 	return tasks, copyTasks, deletions, cachedGodsl, cachedFiles, nextCache, nil
 }
 
-func transpileFilesParallel(tasks []FileTask) error {
+func transpileFilesParallel(tasks []FileTask, opts GenerateOptions) error {
 	g := &errgroup.Group{}
 	g.SetLimit(8)
 
 	for _, task := range tasks {
 		task := task
 		g.Go(func() error {
-			err := transpileFile(task)
+			err := transpileFile(task, opts)
 			if err == nil {
 				fmt.Printf("✓ %s -> %s\n", task.SourcePath, task.TargetPath)
 			}
@@ -322,14 +348,22 @@ func transpileFilesParallel(tasks []FileTask) error {
 	return g.Wait()
 }
 
-func transpileFile(task FileTask) error {
+func transpileFile(task FileTask, opts GenerateOptions) error {
 	content, err := os.ReadFile(task.SourcePath)
 	if err != nil {
 		return fmt.Errorf("ошибка чтения файла %s: %v", task.SourcePath, err)
 	}
 
 	source := string(content)
-	transpiledCode, err := transpiler.TranspileFile(source)
+	transpiledCode, err := transpiler.TranspileFileWithOptions(source, transpiler.Options{
+		Filename:    filepath.ToSlash(task.SourceRel),
+		TraceErrors: opts.TraceErrors,
+		Warnings:    os.Stderr,
+		Signatures:  opts.signatures.forDir(filepath.Dir(task.SourcePath)),
+		Package:     opts.packages.forFile(task.SourcePath),
+		Imports:     opts.packages.imports(filepath.Dir(task.SourcePath)),
+		Dir:         filepath.Dir(task.SourcePath),
+	})
 	if err != nil {
 		return fmt.Errorf("ошибка транспиляции файла %s: %v", task.SourcePath, err)
 	}
@@ -58,6 +58,15 @@ func TestGoBuildExecDir_ProjectPathOutsideCwd_FallsBackToBuildDir(t *testing.T)
 
 // ─── planProjectTasks ─────────────────────────────────────────────────────────
 
+func TestGoBuildArgs_ReleaseAddsStripTags(t *testing.T) {
+	if got := strings.Join(goBuildArgs(false), " "); got != "build ." {
+		t.Errorf("expected %q, got %q", "build .", got)
+	}
+	if got := strings.Join(goBuildArgs(true), " "); got != "build -tags godsl_noassert,godsl_nocontracts ." {
+		t.Errorf("expected %q, got %q", "build -tags godsl_noassert,godsl_nocontracts .", got)
+	}
+}
+
 func TestPlanProjectTasks_NewGodslFile(t *testing.T) {
 	srcDir := t.TempDir()
 	buildDir := t.TempDir()
@@ -290,6 +299,176 @@ func TestGenerateProject_CleanBuild_RegeneatesEverything(t *testing.T) {
 	}
 }
 
+func TestGenerateProject_TraceErrors_RegeneratesOnModeChange(t *testing.T) {
+	srcDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(srcDir, "go.mod"), "module testapp\ngo 1.22\n")
+	mustWriteFile(t, filepath.Join(srcDir, "main.godsl"), `package main
+
+func step() error { return nil }
+
+func run() error {
+	step()?
+	return nil
+}
+
+func main() { _ = run() }
+`)
+
+	buildDir := t.TempDir()
+	origWd := mustChdir(t, srcDir)
+	defer os.Chdir(origWd)
+
+	if _, err := generateProject("", buildDir, GenerateOptions{}); err != nil {
+		t.Fatalf("first run error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "errtrace") {
+		t.Errorf("output without --trace-errors must not use errtrace\n\nContent:\n%s", data)
+	}
+
+	// Исходник не менялся, но режим сменился — файл должен быть перегенерирован.
+	if _, err := generateProject("", buildDir, GenerateOptions{TraceErrors: true}); err != nil {
+		t.Fatalf("trace run error: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `errtrace.Wrap(err, "main.godsl:6", "run")`) {
+		t.Errorf("expected traced error propagation\n\nContent:\n%s", data)
+	}
+}
+
+func TestGenerateProject_DefaultArgs_ResolvedAcrossFiles(t *testing.T) {
+	srcDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(srcDir, "go.mod"), "module testapp\ngo 1.22\n")
+	mustWriteFile(t, filepath.Join(srcDir, "main.godsl"), "package main\n\nfunc main() { _ = greet() }\n")
+	mustWriteFile(t, filepath.Join(srcDir, "greet.godsl"), `package main
+
+func greet(name = "world") string { return "hi " + name }
+`)
+
+	buildDir := t.TempDir()
+	origWd := mustChdir(t, srcDir)
+	defer os.Chdir(origWd)
+
+	if _, err := generateProject("", buildDir, GenerateOptions{}); err != nil {
+		t.Fatalf("first run error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `greet("world")`) {
+		t.Errorf("expected default argument from another file\n\nContent:\n%s", data)
+	}
+
+	// main.godsl не менялся, но значение по умолчанию сменилось — вызов
+	// должен быть перегенерирован.
+	mustWriteFile(t, filepath.Join(srcDir, "greet.godsl"), `package main
+
+func greet(name = "there") string { return "hi " + name }
+`)
+	if _, err := generateProject("", buildDir, GenerateOptions{}); err != nil {
+		t.Fatalf("second run error: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `greet("there")`) {
+		t.Errorf("expected regenerated call after default change\n\nContent:\n%s", data)
+	}
+}
+
+func TestGenerateProject_TernaryTypes_ResolvedAcrossFiles(t *testing.T) {
+	srcDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(srcDir, "go.mod"), "module testapp\ngo 1.22\n")
+	mustWriteFile(t, filepath.Join(srcDir, "main.godsl"), `package main
+
+func main() {
+	u := len("a") > 0 ? admin() : guest
+	_ = u.Name
+}
+`)
+	mustWriteFile(t, filepath.Join(srcDir, "user.godsl"), `package main
+
+type User struct{ Name string }
+
+func admin() User { return User{Name: "admin"} }
+`)
+	mustWriteFile(t, filepath.Join(srcDir, "guest.go"), `package main
+
+var guest = User{Name: "guest"}
+`)
+
+	buildDir := t.TempDir()
+	origWd := mustChdir(t, srcDir)
+	defer os.Chdir(origWd)
+
+	if _, err := generateProject("", buildDir, GenerateOptions{}); err != nil {
+		t.Fatalf("generateProject error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "var u User") {
+		t.Errorf("expected ternary type from other files of the package\n\nContent:\n%s", data)
+	}
+}
+
+func TestGenerateProject_TernaryTypes_ResolvedAcrossPackages(t *testing.T) {
+	srcDir := t.TempDir()
+	mustWriteFile(t, filepath.Join(srcDir, "go.mod"), "module testapp\ngo 1.22\n")
+	mustWriteFile(t, filepath.Join(srcDir, "main.godsl"), `package main
+
+import (
+	"testapp/golib"
+	"testapp/lib"
+)
+
+func main() {
+	c := len("a") > 0
+	it := c ? lib.Get(1) : lib.Get(2)
+	g := c ? golib.Get(1) : golib.Get(2)
+	_, _ = it.N, g.N
+}
+`)
+	mustWriteFile(t, filepath.Join(srcDir, "lib", "lib.godsl"), `package lib
+
+type Item struct{ N int }
+
+func Get(n int) Item { return Item{N: n} }
+`)
+	mustWriteFile(t, filepath.Join(srcDir, "golib", "golib.go"), `package golib
+
+type Item struct{ N int }
+
+func Get(n int) Item { return Item{N: n} }
+`)
+
+	buildDir := t.TempDir()
+	origWd := mustChdir(t, srcDir)
+	defer os.Chdir(origWd)
+
+	if _, err := generateProject("", buildDir, GenerateOptions{}); err != nil {
+		t.Fatalf("generateProject error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(buildDir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"var it lib.Item", "var g golib.Item"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q from another package of the project\n\nContent:\n%s", want, data)
+		}
+	}
+}
+
 func TestGenerateProject_NonExistentPath_ReturnsError(t *testing.T) {
 	_, err := generateProject("/this/path/does/not/exist/at/all", "", GenerateOptions{})
 	if err == nil {
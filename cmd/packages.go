@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	goast "go/ast"
+	goformat "go/format"
+	goparser "go/parser"
+	gotoken "go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sviridovkonstantin42/godsl/internal/transpiler"
+)
+
+// projectPackages — объявления файлов проекта на Go по пакетам. Пробный
+// проход go/types транспилятора проверяет файл вместе с объявлениями
+// соседних файлов пакета, поэтому видит их типы и функции, а пакеты
+// проекта, которые импортирует файл, загружает из их объявлений.
+type projectPackages struct {
+	ByDir map[string]map[string]string // директория пакета → имя файла → объявления на Go
+	Paths map[string]string            // директория пакета → путь импорта
+}
+
+// collectProjectPackages собирает объявления всех .godsl и .go файлов
+// проекта. Файлы с ошибками разбора пропускаются: ошибку покажет их
+// транспиляция или сборка.
+func collectProjectPackages(walkRoot string) (projectPackages, error) {
+	pp := projectPackages{ByDir: map[string]map[string]string{}, Paths: map[string]string{}}
+	modDir, modPath := findModule(walkRoot)
+
+	err := filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "build" {
+			return filepath.SkipDir
+		}
+		ext := filepath.Ext(p)
+		if info.IsDir() || ext != ".godsl" && ext != ".go" {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения файла %s: %w", p, err)
+		}
+		var decls string
+		if ext == ".godsl" {
+			decls, err = transpiler.PackageDecls(string(content))
+		} else {
+			decls, err = goDecls(content)
+		}
+		if err != nil {
+			return nil
+		}
+
+		dir := filepath.Dir(p)
+		if pp.ByDir[dir] == nil {
+			pp.ByDir[dir] = map[string]string{}
+		}
+		pp.ByDir[dir][filepath.Base(p)] = decls
+		if modPath != "" {
+			if rel, err := filepath.Rel(modDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				pp.Paths[dir] = path.Join(modPath, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	return pp, err
+}
+
+// goDecls возвращает объявления .go файла без тел функций: изменения тел
+// не влияют на типы соседних файлов и не сбрасывают кэш сборки.
+func goDecls(content []byte) (string, error) {
+	fset := gotoken.NewFileSet()
+	f, err := goparser.ParseFile(fset, "", content, goparser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*goast.FuncDecl); ok {
+			fn.Body = nil
+		}
+	}
+	var buf bytes.Buffer
+	if err := goformat.Node(&buf, fset, f); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// forFile возвращает объявления других файлов пакета файла path. Тестовые
+// файлы видны только тестовым файлам.
+func (pp projectPackages) forFile(path string) map[string]string {
+	files := pp.ByDir[filepath.Dir(path)]
+	if len(files) == 0 {
+		return nil
+	}
+	own := filepath.Base(path)
+	test := isTestFile(own)
+	decls := make(map[string]string, len(files))
+	for name, code := range files {
+		if name == own || isTestFile(name) && !test {
+			continue
+		}
+		decls[name] = code
+	}
+	return decls
+}
+
+// imports возвращает объявления других пакетов проекта для файла пакета в
+// директории dir: путь импорта → имя файла → объявления. Тестовые файлы
+// в импортируемые пакеты не входят.
+func (pp projectPackages) imports(dir string) map[string]map[string]string {
+	imports := map[string]map[string]string{}
+	for d, files := range pp.ByDir {
+		p, ok := pp.Paths[d]
+		if !ok || d == dir {
+			continue
+		}
+		decls := make(map[string]string, len(files))
+		for name, code := range files {
+			if !isTestFile(name) {
+				decls[name] = code
+			}
+		}
+		imports[p] = decls
+	}
+	return imports
+}
+
+// isTestFile сообщает, является ли файл тестовым (_test.go или _test.godsl).
+func isTestFile(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), "_test")
+}
+
+// hash возвращает хеш объявлений для кэша сборки; "" — файлов нет.
+func (pp projectPackages) hash() string {
+	if len(pp.ByDir) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(pp)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sviridovkonstantin42/godsl/internal/transpiler"
+)
+
+// projectSignatures — функции с параметрами по умолчанию во всех пакетах
+// проекта. Вызов такой функции из любого файла разворачивается в вызов
+// со всеми аргументами, поэтому сигнатуры собираются до транспиляции.
+type projectSignatures struct {
+	ByDir map[string]map[string]*transpiler.FuncSignature // директория пакета → имя функции → сигнатура
+	Paths map[string]string                               // директория пакета → путь импорта
+}
+
+// collectProjectSignatures разбирает все .godsl файлы проекта и собирает
+// функции с параметрами по умолчанию. Файлы с ошибками разбора
+// пропускаются: ошибку покажет их транспиляция.
+func collectProjectSignatures(walkRoot string) (projectSignatures, error) {
+	ps := projectSignatures{
+		ByDir: map[string]map[string]*transpiler.FuncSignature{},
+		Paths: map[string]string{},
+	}
+	modDir, modPath := findModule(walkRoot)
+
+	err := filepath.Walk(walkRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "build" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || filepath.Ext(p) != ".godsl" {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения файла %s: %w", p, err)
+		}
+		sigs, err := transpiler.CollectSignatures(string(content))
+		if err != nil || len(sigs) == 0 {
+			return nil
+		}
+
+		dir := filepath.Dir(p)
+		if ps.ByDir[dir] == nil {
+			ps.ByDir[dir] = map[string]*transpiler.FuncSignature{}
+		}
+		for name, sig := range sigs {
+			ps.ByDir[dir][name] = sig
+		}
+		if modPath != "" {
+			if rel, err := filepath.Rel(modDir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				ps.Paths[dir] = path.Join(modPath, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	return ps, err
+}
+
+// forDir возвращает сигнатуры для файла пакета в директории dir: функции
+// самого пакета под ключом "", остальных — под путями импорта.
+func (ps projectSignatures) forDir(dir string) map[string]map[string]*transpiler.FuncSignature {
+	if len(ps.ByDir) == 0 {
+		return nil
+	}
+	sigs := map[string]map[string]*transpiler.FuncSignature{"": ps.ByDir[dir]}
+	for d, funcs := range ps.ByDir {
+		if p, ok := ps.Paths[d]; ok && d != dir {
+			sigs[p] = funcs
+		}
+	}
+	return sigs
+}
+
+// hash возвращает хеш сигнатур для кэша сборки; "" — сигнатур нет.
+func (ps projectSignatures) hash() string {
+	if len(ps.ByDir) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(ps)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// findModule ищет go.mod в директории start и выше. Возвращает директорию
+// модуля и путь модуля или пустые строки.
+func findModule(start string) (string, string) {
+	dir := start
+	if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				if mod, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "module "); ok {
+					return dir, strings.Trim(strings.TrimSpace(mod), `"`)
+				}
+			}
+			return "", ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
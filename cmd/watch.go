@@ -83,7 +83,7 @@ func (w *godslFileWatcher) hasChanges() bool {
 
 // watchGenerate запускает generate в режиме наблюдения:
 // автоматически перегенерирует при изменении .godsl файлов
-func watchGenerate(projectPath string, clean bool) {
+func watchGenerate(projectPath string, opts GenerateOptions) {
 	w, err := newGodslFileWatcher(projectPath)
 	if err != nil {
 		fmt.Printf("Ошибка инициализации watcher: %v\n", err)
@@ -91,7 +91,7 @@ func watchGenerate(projectPath string, clean bool) {
 	}
 
 	fmt.Println("[watch] Начальная генерация...")
-	if _, err := generateProject(projectPath, "", GenerateOptions{Clean: clean}); err != nil {
+	if _, err := generateProject(projectPath, "", opts); err != nil {
 		fmt.Printf("Ошибка генерации: %v\n", err)
 	}
 
@@ -110,7 +110,7 @@ func watchGenerate(projectPath string, clean bool) {
 		case <-ticker.C:
 			if w.hasChanges() {
 				fmt.Println("[watch] Изменения обнаружены, регенерация...")
-				if _, err := generateProject(projectPath, "", GenerateOptions{}); err != nil {
+				if _, err := generateProject(projectPath, "", GenerateOptions{TraceErrors: opts.TraceErrors}); err != nil {
 					fmt.Printf("Ошибка генерации: %v\n", err)
 				}
 			}
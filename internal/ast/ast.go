@@ -200,6 +200,7 @@ type Field struct {
 	Names   []*Ident      // field/method/(type) parameter names; or nil
 	Type    Expr          // field/method/parameter type; or nil
 	Tag     *BasicLit     // field tag; or nil
+	Default Expr          // parameter default value (name [T] = value); or nil
 	Comment *CommentGroup // line comments; or nil
 }
 
@@ -214,6 +215,9 @@ func (f *Field) Pos() token.Pos {
 }
 
 func (f *Field) End() token.Pos {
+	if f.Default != nil {
+		return f.Default.End()
+	}
 	if f.Tag != nil {
 		return f.Tag.End()
 	}
@@ -436,6 +440,144 @@ type (
 		Colon    token.Pos // position of ":"
 		Else     Expr      // value when condition is false
 	}
+
+	// A DurationLit node represents a duration literal such as 100ms or 1.5s.
+	// It is accepted only where godsl expects a delay (retry ... backoff).
+	DurationLit struct {
+		ValuePos token.Pos // literal position
+		Value    string    // literal string; e.g. 100ms, 1.5s, 2m
+	}
+
+	// A LambdaExpr node represents a short function literal:
+	// x => expr, (a, b) => expr or (a T) => { ... }.
+	LambdaExpr struct {
+		Params *FieldList // parameters; Opening is NoPos for x => ...; Type is nil if not annotated
+		Arrow  token.Pos  // position of "=>"
+		Body   Expr       // expression body; or nil
+		Block  *BlockStmt // block body; or nil
+	}
+
+	// An InterpolatedLit node represents an interpolated string literal
+	// such as $"user {u.Name} has {ratio:%.2f}".
+	InterpolatedLit struct {
+		ValuePos token.Pos // position of "$"
+		Value    string    // literal string as written in the source
+		Texts    []string  // text around expressions with escapes as in a Go string; len(Texts) == len(Exprs)+1
+		Exprs    []Expr    // embedded expressions
+		Formats  []string  // format verb of each expression; "" means %v
+	}
+
+	// A NamedArg node represents a named call argument: name: value.
+	NamedArg struct {
+		Name  *Ident    // parameter name
+		Colon token.Pos // position of ":"
+		Value Expr      // argument value
+	}
+
+	// A RangeExpr node represents a range in a for ... in clause:
+	// low..high (inclusive), low..<high (exclusive), optionally with step.
+	RangeExpr struct {
+		Low     Expr        // lower bound
+		OpPos   token.Pos   // position of Op
+		Op      token.Token // RANGE_INCL or RANGE_EXCL
+		High    Expr        // upper bound
+		StepPos token.Pos   // position of "step"; or token.NoPos
+		Step    Expr        // step; or nil
+	}
+
+	// A ComprehensionExpr node represents a slice or map comprehension:
+	// [Value for x in X if Cond] or {Key: Value for k, v in X if Cond}.
+	ComprehensionExpr struct {
+		Lbrack token.Pos // position of "[" or "{"
+		Key    Expr      // map key; or nil for a slice comprehension
+		Colon  token.Pos // position of ":"; or token.NoPos
+		Value  Expr      // element value
+		For    token.Pos // position of "for" keyword
+		In     *InClause // loop variables and collection or range
+		If     token.Pos // position of "if" keyword; or token.NoPos
+		Cond   Expr      // filter condition; or nil
+		Rbrack token.Pos // position of "]" or "}"
+	}
+
+	// A PipeExpr node represents a pipeline stage: X |> Stage or X |> Stage?.
+	// X is passed to Stage as the first argument or into the _ placeholder
+	// of a call; "?" propagates the error returned by the stage.
+	PipeExpr struct {
+		X        Expr      // value passed to the stage
+		OpPos    token.Pos // position of "|>"
+		Stage    Expr      // function or call
+		Question token.Pos // position of "?" after the stage; or token.NoPos
+	}
+
+	// A FallbackExpr node represents an error-or-default expression.
+	// Syntax: X ?: Fallback  or  try X else Fallback
+	// X must return (value, error); Fallback is evaluated only on error.
+	FallbackExpr struct {
+		Try      token.Pos // position of "try" keyword; or token.NoPos for the ?: form
+		X        Expr      // expression returning (value, error)
+		OpPos    token.Pos // position of "?:" or "else"
+		Fallback Expr      // value used when X returns a non-nil error
+	}
+
+	// An OptSelectorExpr node represents an optional chaining selector X?.Sel.
+	// If X is nil, the whole chain short-circuits to the zero value.
+	OptSelectorExpr struct {
+		X     Expr      // expression
+		OpPos token.Pos // position of "?."
+		Sel   *Ident    // field or method selector
+	}
+
+	// A CoalesceExpr node represents a null-coalescing expression X ?? Y.
+	// It yields X when X is non-nil/non-zero and evaluates Y otherwise;
+	// for X of the form *p it yields Y when p is nil.
+	CoalesceExpr struct {
+		X     Expr      // left operand
+		OpPos token.Pos // position of "??"
+		Y     Expr      // default, evaluated lazily
+	}
+
+	// An AsyncExpr node represents starting a call in a goroutine:
+	// async f(x). The expression yields a future of the call result.
+	AsyncExpr struct {
+		Async token.Pos // position of "async"
+		X     Expr      // call expression or function literal
+	}
+
+	// An AwaitExpr node represents waiting for a future or a slice of
+	// futures: await f. The expression yields (value, error).
+	AwaitExpr struct {
+		Await token.Pos // position of "await"
+		X     Expr      // future or slice of futures
+	}
+
+	// A MatchExpr node represents a match expression:
+	// match X { case P1, P2 [if Guard]: Value ... default: Value }.
+	// If any arm binds a variable (case int n: ...), the patterns are types
+	// and the match lowers to a type switch; otherwise they are values.
+	MatchExpr struct {
+		Match  token.Pos   // position of "match"
+		X      Expr        // matched value
+		Lbrace token.Pos   // position of "{"
+		Arms   []*MatchArm // list of arms
+		Rbrace token.Pos   // position of "}"
+	}
+
+	// A StmtExpr node represents an if or switch statement used as an
+	// expression: x := if a > b { a } else { b }. The value of each branch
+	// is the last expression statement of its block.
+	StmtExpr struct {
+		Stmt Stmt // *IfStmt, *SwitchStmt or *TypeSwitchStmt
+	}
+
+	// A MatchArm node represents a single arm of a match expression.
+	MatchArm struct {
+		Case     token.Pos // position of "case" or "default"
+		Patterns []Expr    // value or type patterns; nil means default arm
+		Binding  *Ident    // variable bound by a type pattern; or nil
+		Guard    Expr      // guard condition after "if"; or nil
+		Colon    token.Pos // position of ":"
+		Value    Expr      // value of the arm
+	}
 )
 
 // The direction of a channel type is indicated by a bit
@@ -511,20 +653,45 @@ func (x *CompositeLit) Pos() token.Pos {
 	}
 	return x.Lbrace
 }
-func (x *ParenExpr) Pos() token.Pos      { return x.Lparen }
-func (x *SelectorExpr) Pos() token.Pos   { return x.X.Pos() }
-func (x *IndexExpr) Pos() token.Pos      { return x.X.Pos() }
-func (x *IndexListExpr) Pos() token.Pos  { return x.X.Pos() }
-func (x *SliceExpr) Pos() token.Pos      { return x.X.Pos() }
-func (x *TypeAssertExpr) Pos() token.Pos { return x.X.Pos() }
-func (x *CallExpr) Pos() token.Pos       { return x.Fun.Pos() }
-func (x *StarExpr) Pos() token.Pos       { return x.Star }
-func (x *UnaryExpr) Pos() token.Pos      { return x.OpPos }
-func (x *BinaryExpr) Pos() token.Pos     { return x.X.Pos() }
-func (x *KeyValueExpr) Pos() token.Pos   { return x.Key.Pos() }
-func (x *TernaryExpr) Pos() token.Pos    { return x.Cond.Pos() }
-func (x *ArrayType) Pos() token.Pos      { return x.Lbrack }
-func (x *StructType) Pos() token.Pos     { return x.Struct }
+func (x *ParenExpr) Pos() token.Pos       { return x.Lparen }
+func (x *SelectorExpr) Pos() token.Pos    { return x.X.Pos() }
+func (x *IndexExpr) Pos() token.Pos       { return x.X.Pos() }
+func (x *IndexListExpr) Pos() token.Pos   { return x.X.Pos() }
+func (x *SliceExpr) Pos() token.Pos       { return x.X.Pos() }
+func (x *TypeAssertExpr) Pos() token.Pos  { return x.X.Pos() }
+func (x *CallExpr) Pos() token.Pos        { return x.Fun.Pos() }
+func (x *StarExpr) Pos() token.Pos        { return x.Star }
+func (x *UnaryExpr) Pos() token.Pos       { return x.OpPos }
+func (x *BinaryExpr) Pos() token.Pos      { return x.X.Pos() }
+func (x *KeyValueExpr) Pos() token.Pos    { return x.Key.Pos() }
+func (x *TernaryExpr) Pos() token.Pos     { return x.Cond.Pos() }
+func (x *DurationLit) Pos() token.Pos     { return x.ValuePos }
+func (x *InterpolatedLit) Pos() token.Pos { return x.ValuePos }
+func (x *RangeExpr) Pos() token.Pos       { return x.Low.Pos() }
+func (x *NamedArg) Pos() token.Pos        { return x.Name.Pos() }
+func (x *LambdaExpr) Pos() token.Pos {
+	if x.Params.Opening.IsValid() {
+		return x.Params.Opening
+	}
+	return x.Params.Pos()
+}
+func (x *ComprehensionExpr) Pos() token.Pos { return x.Lbrack }
+func (x *PipeExpr) Pos() token.Pos          { return x.X.Pos() }
+func (x *FallbackExpr) Pos() token.Pos {
+	if x.Try.IsValid() {
+		return x.Try
+	}
+	return x.X.Pos()
+}
+func (x *CoalesceExpr) Pos() token.Pos    { return x.X.Pos() }
+func (x *OptSelectorExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *AsyncExpr) Pos() token.Pos       { return x.Async }
+func (x *AwaitExpr) Pos() token.Pos       { return x.Await }
+func (x *MatchExpr) Pos() token.Pos       { return x.Match }
+func (x *MatchArm) Pos() token.Pos        { return x.Case }
+func (x *StmtExpr) Pos() token.Pos        { return x.Stmt.Pos() }
+func (x *ArrayType) Pos() token.Pos       { return x.Lbrack }
+func (x *StructType) Pos() token.Pos      { return x.Struct }
 func (x *FuncType) Pos() token.Pos {
 	if x.Func.IsValid() || x.Params == nil { // see issue 3870
 		return x.Func
@@ -543,23 +710,53 @@ func (x *Ellipsis) End() token.Pos {
 	}
 	return x.Ellipsis + 3 // len("...")
 }
-func (x *BasicLit) End() token.Pos       { return token.Pos(int(x.ValuePos) + len(x.Value)) }
-func (x *FuncLit) End() token.Pos        { return x.Body.End() }
-func (x *CompositeLit) End() token.Pos   { return x.Rbrace + 1 }
-func (x *ParenExpr) End() token.Pos      { return x.Rparen + 1 }
-func (x *SelectorExpr) End() token.Pos   { return x.Sel.End() }
-func (x *IndexExpr) End() token.Pos      { return x.Rbrack + 1 }
-func (x *IndexListExpr) End() token.Pos  { return x.Rbrack + 1 }
-func (x *SliceExpr) End() token.Pos      { return x.Rbrack + 1 }
-func (x *TypeAssertExpr) End() token.Pos { return x.Rparen + 1 }
-func (x *CallExpr) End() token.Pos       { return x.Rparen + 1 }
-func (x *StarExpr) End() token.Pos       { return x.X.End() }
-func (x *UnaryExpr) End() token.Pos      { return x.X.End() }
-func (x *BinaryExpr) End() token.Pos     { return x.Y.End() }
-func (x *KeyValueExpr) End() token.Pos   { return x.Value.End() }
-func (x *TernaryExpr) End() token.Pos    { return x.Else.End() }
-func (x *ArrayType) End() token.Pos      { return x.Elt.End() }
-func (x *StructType) End() token.Pos     { return x.Fields.End() }
+func (x *BasicLit) End() token.Pos        { return token.Pos(int(x.ValuePos) + len(x.Value)) }
+func (x *FuncLit) End() token.Pos         { return x.Body.End() }
+func (x *CompositeLit) End() token.Pos    { return x.Rbrace + 1 }
+func (x *ParenExpr) End() token.Pos       { return x.Rparen + 1 }
+func (x *SelectorExpr) End() token.Pos    { return x.Sel.End() }
+func (x *IndexExpr) End() token.Pos       { return x.Rbrack + 1 }
+func (x *IndexListExpr) End() token.Pos   { return x.Rbrack + 1 }
+func (x *SliceExpr) End() token.Pos       { return x.Rbrack + 1 }
+func (x *TypeAssertExpr) End() token.Pos  { return x.Rparen + 1 }
+func (x *CallExpr) End() token.Pos        { return x.Rparen + 1 }
+func (x *StarExpr) End() token.Pos        { return x.X.End() }
+func (x *UnaryExpr) End() token.Pos       { return x.X.End() }
+func (x *BinaryExpr) End() token.Pos      { return x.Y.End() }
+func (x *KeyValueExpr) End() token.Pos    { return x.Value.End() }
+func (x *TernaryExpr) End() token.Pos     { return x.Else.End() }
+func (x *FallbackExpr) End() token.Pos    { return x.Fallback.End() }
+func (x *DurationLit) End() token.Pos     { return token.Pos(int(x.ValuePos) + len(x.Value)) }
+func (x *InterpolatedLit) End() token.Pos { return token.Pos(int(x.ValuePos) + len(x.Value)) }
+func (x *NamedArg) End() token.Pos        { return x.Value.End() }
+func (x *RangeExpr) End() token.Pos {
+	if x.Step != nil {
+		return x.Step.End()
+	}
+	return x.High.End()
+}
+func (x *ComprehensionExpr) End() token.Pos { return x.Rbrack + 1 }
+func (x *PipeExpr) End() token.Pos {
+	if x.Question.IsValid() {
+		return x.Question + 1
+	}
+	return x.Stage.End()
+}
+func (x *LambdaExpr) End() token.Pos {
+	if x.Block != nil {
+		return x.Block.End()
+	}
+	return x.Body.End()
+}
+func (x *CoalesceExpr) End() token.Pos    { return x.Y.End() }
+func (x *OptSelectorExpr) End() token.Pos { return x.Sel.End() }
+func (x *AsyncExpr) End() token.Pos       { return x.X.End() }
+func (x *AwaitExpr) End() token.Pos       { return x.X.End() }
+func (x *MatchExpr) End() token.Pos       { return x.Rbrace + 1 }
+func (x *MatchArm) End() token.Pos        { return x.Value.End() }
+func (x *StmtExpr) End() token.Pos        { return x.Stmt.End() }
+func (x *ArrayType) End() token.Pos       { return x.Elt.End() }
+func (x *StructType) End() token.Pos      { return x.Fields.End() }
 func (x *FuncType) End() token.Pos {
 	if x.Results != nil {
 		return x.Results.End()
@@ -572,24 +769,38 @@ func (x *ChanType) End() token.Pos      { return x.Value.End() }
 
 // exprNode() ensures that only expression/type nodes can be
 // assigned to an Expr.
-func (*BadExpr) exprNode()        {}
-func (*Ident) exprNode()          {}
-func (*Ellipsis) exprNode()       {}
-func (*BasicLit) exprNode()       {}
-func (*FuncLit) exprNode()        {}
-func (*CompositeLit) exprNode()   {}
-func (*ParenExpr) exprNode()      {}
-func (*SelectorExpr) exprNode()   {}
-func (*IndexExpr) exprNode()      {}
-func (*IndexListExpr) exprNode()  {}
-func (*SliceExpr) exprNode()      {}
-func (*TypeAssertExpr) exprNode() {}
-func (*CallExpr) exprNode()       {}
-func (*StarExpr) exprNode()       {}
-func (*UnaryExpr) exprNode()      {}
-func (*BinaryExpr) exprNode()     {}
-func (*KeyValueExpr) exprNode()   {}
-func (*TernaryExpr) exprNode()    {}
+func (*BadExpr) exprNode()           {}
+func (*Ident) exprNode()             {}
+func (*Ellipsis) exprNode()          {}
+func (*BasicLit) exprNode()          {}
+func (*FuncLit) exprNode()           {}
+func (*CompositeLit) exprNode()      {}
+func (*ParenExpr) exprNode()         {}
+func (*SelectorExpr) exprNode()      {}
+func (*IndexExpr) exprNode()         {}
+func (*IndexListExpr) exprNode()     {}
+func (*SliceExpr) exprNode()         {}
+func (*TypeAssertExpr) exprNode()    {}
+func (*CallExpr) exprNode()          {}
+func (*StarExpr) exprNode()          {}
+func (*UnaryExpr) exprNode()         {}
+func (*BinaryExpr) exprNode()        {}
+func (*KeyValueExpr) exprNode()      {}
+func (*TernaryExpr) exprNode()       {}
+func (*FallbackExpr) exprNode()      {}
+func (*CoalesceExpr) exprNode()      {}
+func (*OptSelectorExpr) exprNode()   {}
+func (*AsyncExpr) exprNode()         {}
+func (*AwaitExpr) exprNode()         {}
+func (*MatchExpr) exprNode()         {}
+func (*StmtExpr) exprNode()          {}
+func (*DurationLit) exprNode()       {}
+func (*InterpolatedLit) exprNode()   {}
+func (*LambdaExpr) exprNode()        {}
+func (*RangeExpr) exprNode()         {}
+func (*NamedArg) exprNode()          {}
+func (*ComprehensionExpr) exprNode() {}
+func (*PipeExpr) exprNode()          {}
 
 func (*ArrayType) exprNode()     {}
 func (*StructType) exprNode()    {}
@@ -680,7 +891,7 @@ type (
 	AssignStmt struct {
 		Lhs    []Expr
 		TokPos token.Pos   // position of Tok
-		Tok    token.Token // assignment token, DEFINE
+		Tok    token.Token // assignment token, DEFINE, or VAL (val x = ..., immutable short declaration)
 		Rhs    []Expr
 	}
 
@@ -771,9 +982,19 @@ type (
 		Init Stmt      // initialization statement; or nil
 		Cond Expr      // condition; or nil
 		Post Stmt      // post iteration statement; or nil
+		In   *InClause // for ... in clause; or nil (Init, Cond and Post are nil then)
 		Body *BlockStmt
 	}
 
+	// An InClause node represents the head of a for ... in loop:
+	// i in 0..<n, i in 10..0 step -2 or i, v in items.
+	InClause struct {
+		Key   *Ident    // loop variable
+		Value *Ident    // second loop variable; or nil
+		In    token.Pos // position of "in"
+		X     Expr      // *RangeExpr or value to range over
+	}
+
 	// A RangeStmt represents a for statement with a range clause.
 	RangeStmt struct {
 		For        token.Pos   // position of "for" keyword
@@ -824,6 +1045,55 @@ type (
 		Stmt Stmt      // the statement to error-check
 	}
 
+	// A RetryStmt node represents a retry block:
+	// retry Count [backoff Backoff] { ... } [catch ...]
+	// Errors propagated with ? or throw inside the body re-run the block
+	// up to Count times; the catch clauses run after the last failed attempt.
+	RetryStmt struct {
+		Retry   token.Pos    // position of "retry"
+		Count   Expr         // number of attempts
+		Backoff Expr         // initial delay between attempts; or nil
+		Body    *BlockStmt   // retried block
+		Catches []*CatchStmt // catch clauses; may be empty
+	}
+
+	// A ParallelStmt node represents a structured concurrency block:
+	// parallel [all] { go ...; go ... } [catch ...]
+	// Every branch runs in its own goroutine; the first error (or all
+	// errors joined, with "all") is handed to the catch clauses.
+	ParallelStmt struct {
+		Parallel token.Pos    // position of "parallel"
+		Mode     *Ident       // "all"; or nil (first error)
+		Body     *BlockStmt   // list of *ParallelBranch
+		Catches  []*CatchStmt // catch clauses; may be empty
+	}
+
+	// A GuardStmt node represents a guard statement:
+	// guard v, ok := m[k] else { ... } or guard cond else { ... }.
+	// The else block runs when the condition fails and must leave the
+	// enclosing scope; variables bound by Stmt stay in scope after the guard.
+	GuardStmt struct {
+		Guard token.Pos  // position of "guard"
+		Stmt  Stmt       // *AssignStmt or *ExprStmt (condition)
+		Else  *BlockStmt // block run when the guard fails
+	}
+
+	// An AssertStmt node represents an assert statement:
+	// assert cond or assert cond, msg. A failed assertion panics with the
+	// source text of Cond; in test functions it calls t.Fatalf instead.
+	AssertStmt struct {
+		Assert token.Pos // position of "assert"
+		Cond   Expr      // asserted condition
+		Msg    Expr      // message; or nil
+	}
+
+	// A ParallelBranch node represents a single branch of a parallel block:
+	// go f()? or go { ... }.
+	ParallelBranch struct {
+		Go   token.Pos // position of "go" keyword
+		Stmt Stmt      // simple statement, *QuestionStmt or *BlockStmt
+	}
+
 	// A MustStmt wraps an assign or expression statement with the must keyword.
 	// x := must f()  → x, err := f(); if err != nil { panic(err) }
 	// must f()       → if err := f(); err != nil { panic(err) }
@@ -933,6 +1203,9 @@ func (s *TryStmt) End() token.Pos {
 func (s *CatchStmt) Pos() token.Pos { return s.Catch }
 func (s *CatchStmt) End() token.Pos { return s.Body.End() }
 
+func (c *InClause) Pos() token.Pos { return c.Key.Pos() }
+func (c *InClause) End() token.Pos { return c.X.End() }
+
 func (s *ThrowStmt) Pos() token.Pos { return s.Throw }
 func (s *ThrowStmt) End() token.Pos { return s.X.End() }
 
@@ -942,6 +1215,36 @@ func (s *QuestionStmt) End() token.Pos { return s.Question + 1 }
 func (s *ErrCheckStmt) Pos() token.Pos { return s.At }
 func (s *ErrCheckStmt) End() token.Pos { return s.Stmt.End() }
 
+func (s *RetryStmt) Pos() token.Pos { return s.Retry }
+func (s *RetryStmt) End() token.Pos {
+	if len(s.Catches) > 0 {
+		return s.Catches[len(s.Catches)-1].End()
+	}
+	return s.Body.End()
+}
+
+func (s *ParallelStmt) Pos() token.Pos { return s.Parallel }
+func (s *ParallelStmt) End() token.Pos {
+	if len(s.Catches) > 0 {
+		return s.Catches[len(s.Catches)-1].End()
+	}
+	return s.Body.End()
+}
+
+func (s *ParallelBranch) Pos() token.Pos { return s.Go }
+func (s *ParallelBranch) End() token.Pos { return s.Stmt.End() }
+
+func (s *GuardStmt) Pos() token.Pos { return s.Guard }
+func (s *GuardStmt) End() token.Pos { return s.Else.End() }
+
+func (s *AssertStmt) Pos() token.Pos { return s.Assert }
+func (s *AssertStmt) End() token.Pos {
+	if s.Msg != nil {
+		return s.Msg.End()
+	}
+	return s.Cond.End()
+}
+
 func (s *MustStmt) Pos() token.Pos { return s.Must }
 func (s *MustStmt) End() token.Pos { return s.Stmt.End() }
 
@@ -968,12 +1271,17 @@ func (*CommClause) stmtNode()     {}
 func (*SelectStmt) stmtNode()     {}
 func (*ForStmt) stmtNode()        {}
 func (*RangeStmt) stmtNode()      {}
-func (*TryStmt) stmtNode()       {}
-func (*CatchStmt) stmtNode()     {}
-func (*ThrowStmt) stmtNode()    {}
-func (*QuestionStmt) stmtNode() {}
-func (*ErrCheckStmt) stmtNode() {}
-func (*MustStmt) stmtNode()     {}
+func (*TryStmt) stmtNode()        {}
+func (*CatchStmt) stmtNode()      {}
+func (*ThrowStmt) stmtNode()      {}
+func (*QuestionStmt) stmtNode()   {}
+func (*ErrCheckStmt) stmtNode()   {}
+func (*MustStmt) stmtNode()       {}
+func (*RetryStmt) stmtNode()      {}
+func (*ParallelStmt) stmtNode()   {}
+func (*ParallelBranch) stmtNode() {}
+func (*GuardStmt) stmtNode()      {}
+func (*AssertStmt) stmtNode()     {}
 
 // ----------------------------------------------------------------------------
 // Declarations
@@ -1085,19 +1393,63 @@ type (
 
 	// A FuncDecl node represents a function declaration.
 	FuncDecl struct {
-		Doc  *CommentGroup // associated documentation; or nil
-		Recv *FieldList    // receiver (methods); or nil (functions)
-		Name *Ident        // function/method name
-		Type *FuncType     // function signature: type and value parameters, results, and position of "func" keyword
-		Body *BlockStmt    // function body; or nil for external (non-Go) function
+		Doc       *CommentGroup     // associated documentation; or nil
+		Recv      *FieldList        // receiver (methods); or nil (functions)
+		Name      *Ident            // function/method name
+		Type      *FuncType         // function signature: type and value parameters, results, and position of "func" keyword
+		Contracts []*ContractClause // requires/ensures clauses; or nil
+		Body      *BlockStmt        // function body; or nil for external (non-Go) function
+	}
+
+	// A ContractClause node represents a requires or ensures clause of a
+	// function declaration: requires amt > 0, ensures a.Balance >= old(a.Balance).
+	// Ensures conditions may refer to named results and old(expr), the
+	// value of expr at function entry.
+	ContractClause struct {
+		Keyword token.Pos // position of "requires" or "ensures"
+		Ensures bool      // postcondition (ensures); otherwise precondition (requires)
+		Cond    Expr      // condition
+	}
+
+	// An EnumDecl node represents an enum declaration:
+	// enum Name [: Type] { A, B = Value, ... }.
+	EnumDecl struct {
+		Doc     *CommentGroup // associated documentation; or nil
+		Enum    token.Pos     // position of "enum"
+		Name    *Ident        // enum type name
+		Type    Expr          // underlying type; or nil (int)
+		Lbrace  token.Pos     // position of "{"
+		Members []*EnumMember // enum members
+		Rbrace  token.Pos     // position of "}"
+	}
+
+	// An EnumMember node represents a single member of an enum: Name [= Value].
+	EnumMember struct {
+		Name  *Ident // member name
+		Value Expr   // explicit value; or nil
+	}
+
+	// A RecordDecl node represents a record declaration:
+	// record Name[TypeParams](Fields) [derive(A, B)].
+	RecordDecl struct {
+		Doc        *CommentGroup // associated documentation; or nil
+		Record     token.Pos     // position of "record"
+		Name       *Ident        // record type name
+		TypeParams *FieldList    // type parameters; or nil
+		Fields     *FieldList    // record fields
+		Derive     token.Pos     // position of "derive"; or token.NoPos
+		Derives    []*Ident      // derive list; or nil
+		Rparen     token.Pos     // position of ")" closing the derive list; or token.NoPos
 	}
 )
 
 // Pos and End implementations for declaration nodes.
 
-func (d *BadDecl) Pos() token.Pos  { return d.From }
-func (d *GenDecl) Pos() token.Pos  { return d.TokPos }
-func (d *FuncDecl) Pos() token.Pos { return d.Type.Pos() }
+func (d *BadDecl) Pos() token.Pos    { return d.From }
+func (d *GenDecl) Pos() token.Pos    { return d.TokPos }
+func (d *FuncDecl) Pos() token.Pos   { return d.Type.Pos() }
+func (d *EnumDecl) Pos() token.Pos   { return d.Enum }
+func (d *RecordDecl) Pos() token.Pos { return d.Record }
 
 func (d *BadDecl) End() token.Pos { return d.To }
 func (d *GenDecl) End() token.Pos {
@@ -1110,14 +1462,37 @@ func (d *FuncDecl) End() token.Pos {
 	if d.Body != nil {
 		return d.Body.End()
 	}
+	if n := len(d.Contracts); n > 0 {
+		return d.Contracts[n-1].End()
+	}
 	return d.Type.End()
 }
+func (d *EnumDecl) End() token.Pos { return d.Rbrace + 1 }
+func (d *RecordDecl) End() token.Pos {
+	if d.Rparen.IsValid() {
+		return d.Rparen + 1
+	}
+	return d.Fields.End()
+}
+
+func (m *EnumMember) Pos() token.Pos { return m.Name.Pos() }
+func (m *EnumMember) End() token.Pos {
+	if m.Value != nil {
+		return m.Value.End()
+	}
+	return m.Name.End()
+}
+
+func (c *ContractClause) Pos() token.Pos { return c.Keyword }
+func (c *ContractClause) End() token.Pos { return c.Cond.End() }
 
 // declNode() ensures that only declaration nodes can be
 // assigned to a Decl.
-func (*BadDecl) declNode()  {}
-func (*GenDecl) declNode()  {}
-func (*FuncDecl) declNode() {}
+func (*BadDecl) declNode()    {}
+func (*GenDecl) declNode()    {}
+func (*FuncDecl) declNode()   {}
+func (*EnumDecl) declNode()   {}
+func (*RecordDecl) declNode() {}
 
 // ----------------------------------------------------------------------------
 // Files and packages
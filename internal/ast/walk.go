@@ -80,6 +80,38 @@ func Walk(v Visitor, node Node) {
 	case *MustStmt:
 		Walk(v, n.Stmt)
 
+	case *RetryStmt:
+		Walk(v, n.Count)
+		if n.Backoff != nil {
+			Walk(v, n.Backoff)
+		}
+		Walk(v, n.Body)
+		for _, c := range n.Catches {
+			Walk(v, c)
+		}
+
+	case *ParallelStmt:
+		if n.Mode != nil {
+			Walk(v, n.Mode)
+		}
+		Walk(v, n.Body)
+		for _, c := range n.Catches {
+			Walk(v, c)
+		}
+
+	case *ParallelBranch:
+		Walk(v, n.Stmt)
+
+	case *GuardStmt:
+		Walk(v, n.Stmt)
+		Walk(v, n.Else)
+
+	case *AssertStmt:
+		Walk(v, n.Cond)
+		if n.Msg != nil {
+			Walk(v, n.Msg)
+		}
+
 	case *Field:
 		if n.Doc != nil {
 			Walk(v, n.Doc)
@@ -91,6 +123,9 @@ func Walk(v Visitor, node Node) {
 		if n.Tag != nil {
 			Walk(v, n.Tag)
 		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
 		if n.Comment != nil {
 			Walk(v, n.Comment)
 		}
@@ -99,9 +134,45 @@ func Walk(v Visitor, node Node) {
 		walkList(v, n.List)
 
 	// Expressions
-	case *BadExpr, *Ident, *BasicLit:
+	case *BadExpr, *Ident, *BasicLit, *DurationLit:
 		// nothing to do
 
+	case *InterpolatedLit:
+		walkList(v, n.Exprs)
+
+	case *LambdaExpr:
+		Walk(v, n.Params)
+		if n.Block != nil {
+			Walk(v, n.Block)
+		} else {
+			Walk(v, n.Body)
+		}
+
+	case *NamedArg:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+
+	case *PipeExpr:
+		Walk(v, n.X)
+		Walk(v, n.Stage)
+
+	case *ComprehensionExpr:
+		if n.Key != nil {
+			Walk(v, n.Key)
+		}
+		Walk(v, n.Value)
+		Walk(v, n.In)
+		if n.Cond != nil {
+			Walk(v, n.Cond)
+		}
+
+	case *RangeExpr:
+		Walk(v, n.Low)
+		Walk(v, n.High)
+		if n.Step != nil {
+			Walk(v, n.Step)
+		}
+
 	case *Ellipsis:
 		if n.Elt != nil {
 			Walk(v, n.Elt)
@@ -173,6 +244,43 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Then)
 		Walk(v, n.Else)
 
+	case *FallbackExpr:
+		Walk(v, n.X)
+		Walk(v, n.Fallback)
+
+	case *OptSelectorExpr:
+		Walk(v, n.X)
+		Walk(v, n.Sel)
+
+	case *CoalesceExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+
+	case *AsyncExpr:
+		Walk(v, n.X)
+
+	case *AwaitExpr:
+		Walk(v, n.X)
+
+	case *MatchExpr:
+		Walk(v, n.X)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+
+	case *StmtExpr:
+		Walk(v, n.Stmt)
+
+	case *MatchArm:
+		walkList(v, n.Patterns)
+		if n.Binding != nil {
+			Walk(v, n.Binding)
+		}
+		if n.Guard != nil {
+			Walk(v, n.Guard)
+		}
+		Walk(v, n.Value)
+
 	// Types
 	case *ArrayType:
 		if n.Len != nil {
@@ -298,8 +406,18 @@ func Walk(v Visitor, node Node) {
 		if n.Post != nil {
 			Walk(v, n.Post)
 		}
+		if n.In != nil {
+			Walk(v, n.In)
+		}
 		Walk(v, n.Body)
 
+	case *InClause:
+		Walk(v, n.Key)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+		Walk(v, n.X)
+
 	case *RangeStmt:
 		if n.Key != nil {
 			Walk(v, n.Key)
@@ -367,10 +485,45 @@ func Walk(v Visitor, node Node) {
 		}
 		Walk(v, n.Name)
 		Walk(v, n.Type)
+		for _, c := range n.Contracts {
+			Walk(v, c)
+		}
 		if n.Body != nil {
 			Walk(v, n.Body)
 		}
 
+	case *ContractClause:
+		Walk(v, n.Cond)
+
+	case *EnumDecl:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		for _, m := range n.Members {
+			Walk(v, m)
+		}
+
+	case *EnumMember:
+		Walk(v, n.Name)
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *RecordDecl:
+		if n.Doc != nil {
+			Walk(v, n.Doc)
+		}
+		Walk(v, n.Name)
+		if n.TypeParams != nil {
+			Walk(v, n.TypeParams)
+		}
+		Walk(v, n.Fields)
+		walkList(v, n.Derives)
+
 	// Files and packages
 	case *File:
 		if n.Doc != nil {
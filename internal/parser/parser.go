@@ -16,6 +16,7 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 	"go/build/constraint"
 	"strings"
@@ -64,8 +65,10 @@ type parser struct {
 	syncCnt int       // number of parser.advance calls without progress
 
 	// Non-syntactic parser control
-	exprLev int  // < 0: in control clause, >= 0: in expression
-	inRhs   bool // if set, the parser is parsing a rhs expression
+	exprLev     int  // < 0: in control clause, >= 0: in expression
+	inRhs       bool // if set, the parser is parsing a rhs expression
+	stmtExprLev int  // > 0: inside an if or switch used as an expression
+	asExpr      bool // the next if or switch is an expression: no trailing ';'
 
 	imports []*ast.ImportSpec // list of imports
 
@@ -370,6 +373,11 @@ func (p *parser) expectClosing(tok token.Token, context string) token.Pos {
 
 // expectSemi consumes a semicolon and returns the applicable line comment.
 func (p *parser) expectSemi() (comment *ast.CommentGroup) {
+	// в switch-выражении значение ветки можно не отделять от следующей
+	// ветки: switch code { case 200: "ok" case 404: "missing" }
+	if p.stmtExprLev > 0 && (p.tok == token.CASE || p.tok == token.DEFAULT) {
+		return nil
+	}
 	// semicolon is optional before a closing ')' or '}'
 	if p.tok != token.RPAREN && p.tok != token.RBRACE {
 		switch p.tok {
@@ -831,6 +839,7 @@ func (p *parser) parseDotsType() *ast.Ellipsis {
 type field struct {
 	name *ast.Ident
 	typ  ast.Expr
+	def  ast.Expr // godsl: значение по умолчанию (name [T] = value); или nil
 }
 
 func (p *parser) parseParamDecl(name *ast.Ident, typeSetsOK bool) (f field) {
@@ -845,7 +854,7 @@ func (p *parser) parseParamDecl(name *ast.Ident, typeSetsOK bool) (f field) {
 		p.tok = token.IDENT // force token.IDENT case in switch below
 	} else if typeSetsOK && p.tok == token.TILDE {
 		// "~" ...
-		return field{nil, p.embeddedElem(nil)}
+		return field{name: nil, typ: p.embeddedElem(nil)}
 	}
 
 	switch p.tok {
@@ -947,15 +956,23 @@ func (p *parser) parseParameterList(name0 *ast.Ident, typ0 ast.Expr, closing tok
 			if tparams {
 				typ0 = p.embeddedElem(typ0)
 			}
-			par = field{name0, typ0}
+			par = field{name: name0, typ: typ0}
 		} else {
 			par = p.parseParamDecl(name0, tparams)
 		}
 		name0 = nil // 1st name was consumed if present
 		typ0 = nil  // 1st typ was consumed if present
+		if !tparams && p.tok == token.ASSIGN {
+			// godsl: timeout = 5 * time.Second, retries int = 3
+			if par.name == nil {
+				p.error(p.pos, "parameter with a default value must be named")
+			}
+			p.next()
+			par.def = p.parseExpr()
+		}
 		if par.name != nil || par.typ != nil {
 			list = append(list, par)
-			if par.name != nil && par.typ != nil {
+			if par.name != nil && (par.typ != nil || par.def != nil) {
 				named++
 			}
 			if par.typ != nil {
@@ -1012,6 +1029,8 @@ func (p *parser) parseParameterList(name0 *ast.Ident, typ0 ast.Expr, closing tok
 					n.NamePos = errPos // correct position
 					par.name = n
 				}
+			} else if par.def != nil {
+				// тип параметра выводится из значения по умолчанию
 			} else if typ != nil {
 				par.typ = typ
 			} else {
@@ -1072,6 +1091,15 @@ func (p *parser) parseParameterList(name0 *ast.Ident, typ0 ast.Expr, closing tok
 		names = nil
 	}
 	for _, par := range list {
+		if par.def != nil {
+			// Параметр со значением по умолчанию — всегда отдельное поле.
+			if len(names) > 0 {
+				addParams()
+			}
+			params = append(params, &ast.Field{Names: []*ast.Ident{par.name}, Type: par.typ, Default: par.def})
+			typ = nil
+			continue
+		}
 		if par.typ != typ {
 			if len(names) > 0 {
 				addParams()
@@ -1504,6 +1532,9 @@ func (p *parser) parseOperand() ast.Expr {
 
 	switch p.tok {
 	case token.IDENT:
+		if p.peekNextToken() == token.LAMBDA {
+			return p.parseLambdaExpr()
+		}
 		x := p.parseIdent()
 		return x
 
@@ -1512,7 +1543,15 @@ func (p *parser) parseOperand() ast.Expr {
 		p.next()
 		return x
 
+	case token.INTERP:
+		x := p.parseInterpolatedLit()
+		p.next()
+		return x
+
 	case token.LPAREN:
+		if p.isLambdaStart() {
+			return p.parseLambdaExpr()
+		}
 		lparen := p.pos
 		p.next()
 		p.exprLev++
@@ -1523,6 +1562,11 @@ func (p *parser) parseOperand() ast.Expr {
 
 	case token.FUNC:
 		return p.parseFuncTypeOrLit()
+
+	case token.LBRACK, token.LBRACE:
+		if p.isComprehensionStart() {
+			return p.parseComprehensionExpr()
+		}
 	}
 
 	if typ := p.tryIdentOrType(); typ != nil { // do not consume trailing type parameters
@@ -1661,6 +1705,17 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) *ast.CallExpr {
 	var list []ast.Expr
 	var ellipsis token.Pos
 	for p.tok != token.RPAREN && p.tok != token.EOF && !ellipsis.IsValid() {
+		if p.tok == token.IDENT && p.peekNextToken() == token.COLON {
+			// godsl: именованный аргумент name: value
+			name := p.parseIdent()
+			colon := p.expect(token.COLON)
+			list = append(list, &ast.NamedArg{Name: name, Colon: colon, Value: p.parseRhs()})
+			if !p.atComma("argument list", token.RPAREN) {
+				break
+			}
+			p.next()
+			continue
+		}
 		list = append(list, p.parseRhs()) // builtins may expect a type: make(some type, ...)
 		if p.tok == token.ELLIPSIS {
 			ellipsis = p.pos
@@ -1756,6 +1811,12 @@ func (p *parser) parsePrimaryExpr(x ast.Expr) ast.Expr {
 	for n = 1; ; n++ {
 		incNestLev(p)
 		switch p.tok {
+		case token.OPTCHAIN:
+			// a?.b — опциональная цепочка
+			opPos := p.pos
+			p.next()
+			sel := p.parseIdent()
+			x = &ast.OptSelectorExpr{X: x, OpPos: opPos, Sel: sel}
 		case token.PERIOD:
 			p.next()
 			switch p.tok {
@@ -1820,6 +1881,22 @@ func (p *parser) parseUnaryExpr() ast.Expr {
 		defer un(trace(p, "UnaryExpr"))
 	}
 
+	if p.isAsyncExprStart() {
+		pos, lit := p.pos, p.lit
+		p.next()
+		x := p.parseUnaryExpr()
+		if lit == "async" {
+			return &ast.AsyncExpr{Async: pos, X: x}
+		}
+		return &ast.AwaitExpr{Await: pos, X: x}
+	}
+	if p.isMatchExprStart() {
+		return p.parseMatchExpr()
+	}
+	if p.tok == token.IF || p.tok == token.SWITCH {
+		return p.parseStmtExpr()
+	}
+
 	switch p.tok {
 	case token.ADD, token.SUB, token.NOT, token.XOR, token.AND, token.TILDE:
 		pos, op := p.pos, p.tok
@@ -1917,17 +1994,54 @@ func (p *parser) parseBinaryExpr(x ast.Expr, prec1 int) ast.Expr {
 		}
 		pos := p.expect(op)
 		y := p.parseBinaryExpr(nil, oprec+1)
+		if op == token.PIPE {
+			pipe := &ast.PipeExpr{X: x, OpPos: pos, Stage: y}
+			if p.tok == token.QUESTION && p.isPipeQuestion() {
+				pipe.Question = p.pos
+				p.next()
+			}
+			x = pipe
+			continue
+		}
 		x = &ast.BinaryExpr{X: x, OpPos: pos, Op: op, Y: y}
 	}
 }
 
+// isPipeQuestion сообщает, относится ли текущий ? к стадии конвейера
+// (x |> validate? |> save), а не начинает тернарный оператор: за ? стадии
+// идёт следующий |> или конец выражения.
+func (p *parser) isPipeQuestion() bool {
+	switch p.peekNextToken() {
+	case token.PIPE, token.SEMICOLON, token.EOF, token.RPAREN, token.RBRACE, token.RBRACK, token.COMMA:
+		return true
+	}
+	return false
+}
+
 // The result may be a type or even a raw type ([...]int).
 func (p *parser) parseExpr() ast.Expr {
 	if p.trace {
 		defer un(trace(p, "Expression"))
 	}
 
-	x := p.parseBinaryExpr(nil, token.LowestPrec+1)
+	// Error-or-default: try X else Fallback
+	if p.tok == token.TRY {
+		return p.parseTryElseExpr()
+	}
+
+	x := p.parseCoalesceExpr(p.parseBinaryExpr(nil, token.LowestPrec+1))
+
+	// Error-or-default: X ?: Fallback
+	if p.tok == token.ELVIS {
+		opPos := p.pos
+		p.next() // consume ?:
+		fallback := p.parseExpr()
+		return &ast.FallbackExpr{
+			X:        x,
+			OpPos:    opPos,
+			Fallback: fallback,
+		}
+	}
 
 	// Ternary operator: cond ? then : else
 	// Disambiguate from the statement-suffix ? (QuestionStmt / error propagation):
@@ -1956,6 +2070,27 @@ func (p *parser) parseExpr() ast.Expr {
 	return x
 }
 
+// parseTryElseExpr parses the keyword form of the error-or-default expression:
+// try X else Fallback. The statement form (try { ... } catch) is handled by
+// parseTryStmt; here try always appears in expression position.
+func (p *parser) parseTryElseExpr() *ast.FallbackExpr {
+	if p.trace {
+		defer un(trace(p, "TryElseExpr"))
+	}
+
+	tryPos := p.expect(token.TRY)
+	x := p.parseBinaryExpr(nil, token.LowestPrec+1)
+	elsePos := p.expect(token.ELSE)
+	fallback := p.parseExpr()
+
+	return &ast.FallbackExpr{
+		Try:      tryPos,
+		X:        x,
+		OpPos:    elsePos,
+		Fallback: fallback,
+	}
+}
+
 func (p *parser) parseRhs() ast.Expr {
 	old := p.inRhs
 	p.inRhs = true
@@ -1983,6 +2118,10 @@ func (p *parser) parseSimpleStmt(mode int) (ast.Stmt, bool) {
 		defer un(trace(p, "SimpleStmt"))
 	}
 
+	if p.isValStart() {
+		return p.parseValStmt(), false
+	}
+
 	x := p.parseList(false)
 
 	switch p.tok {
@@ -2058,6 +2197,39 @@ func (p *parser) parseSimpleStmt(mode int) (ast.Stmt, bool) {
 	return &ast.ExprStmt{X: x[0]}, false
 }
 
+// isValStart сообщает, начинается ли с текущего токена объявление
+// val x = .... val — контекстное ключевое слово: за ним сразу идёт имя,
+// что невозможно в обычном коде Go, поэтому переменные с именем val
+// продолжают работать.
+func (p *parser) isValStart() bool {
+	return p.tok == token.IDENT && p.lit == "val" && p.peekNextToken() == token.IDENT
+}
+
+// parseValStmt парсит val x, y = Rhs в AssignStmt с Tok == VAL.
+// Как и для :=, правая часть может начинаться с must.
+func (p *parser) parseValStmt() ast.Stmt {
+	if p.trace {
+		defer un(trace(p, "ValStmt"))
+	}
+
+	p.next() // consume "val"
+	x := p.parseList(false)
+	for _, e := range x {
+		if _, ok := e.(*ast.Ident); !ok {
+			p.errorExpected(e.Pos(), "identifier on left side of val")
+		}
+	}
+	pos := p.expect(token.ASSIGN)
+
+	if p.tok == token.MUST {
+		mustPos := p.pos
+		p.next() // consume 'must'
+		assign := &ast.AssignStmt{Lhs: x, TokPos: pos, Tok: token.VAL, Rhs: p.parseList(true)}
+		return &ast.MustStmt{Must: mustPos, Stmt: assign}
+	}
+	return &ast.AssignStmt{Lhs: x, TokPos: pos, Tok: token.VAL, Rhs: p.parseList(true)}
+}
+
 func (p *parser) parseCallExpr(callType string) *ast.CallExpr {
 	x := p.parseRhs() // could be a conversion: (some type)(x)
 	if t := ast.Unparen(x); t != x {
@@ -2220,6 +2392,8 @@ func (p *parser) parseIfStmt() *ast.IfStmt {
 		defer un(trace(p, "IfStmt"))
 	}
 
+	asExpr := p.asExpr
+	p.asExpr = false
 	pos := p.expect(token.IF)
 
 	init, cond := p.parseIfHeader()
@@ -2230,15 +2404,18 @@ func (p *parser) parseIfStmt() *ast.IfStmt {
 		p.next()
 		switch p.tok {
 		case token.IF:
+			p.asExpr = asExpr
 			else_ = p.parseIfStmt()
 		case token.LBRACE:
 			else_ = p.parseBlockStmt()
-			p.expectSemi()
+			if !asExpr {
+				p.expectSemi()
+			}
 		default:
 			p.errorExpected(p.pos, "if statement or block")
 			else_ = &ast.BadStmt{From: p.pos, To: p.pos}
 		}
-	} else {
+	} else if !asExpr {
 		p.expectSemi()
 	}
 
@@ -2296,6 +2473,8 @@ func (p *parser) parseSwitchStmt() ast.Stmt {
 		defer un(trace(p, "SwitchStmt"))
 	}
 
+	asExpr := p.asExpr
+	p.asExpr = false
 	pos := p.expect(token.SWITCH)
 
 	var s1, s2 ast.Stmt
@@ -2335,7 +2514,9 @@ func (p *parser) parseSwitchStmt() ast.Stmt {
 		list = append(list, p.parseCaseClause())
 	}
 	rbrace := p.expect(token.RBRACE)
-	p.expectSemi()
+	if !asExpr {
+		p.expectSemi()
+	}
 	body := &ast.BlockStmt{Lbrace: lbrace, List: list, Rbrace: rbrace}
 
 	if typeSwitch {
@@ -2422,6 +2603,16 @@ func (p *parser) parseForStmt() ast.Stmt {
 
 	pos := p.expect(token.FOR)
 
+	if p.isInClauseStart() {
+		prevLev := p.exprLev
+		p.exprLev = -1
+		in := p.parseInClause()
+		p.exprLev = prevLev
+		body := p.parseBlockStmt()
+		p.expectSemi()
+		return &ast.ForStmt{For: pos, In: in, Body: body}
+	}
+
 	var s1, s2, s3 ast.Stmt
 	var isRange bool
 	if p.tok != token.LBRACE {
@@ -2497,6 +2688,62 @@ func (p *parser) parseForStmt() ast.Stmt {
 	}
 }
 
+// isInClauseStart сообщает, начинается ли после for заголовок for ... in:
+// i in или i, v in. Слово in — контекстное.
+func (p *parser) isInClauseStart() bool {
+	if p.tok != token.IDENT {
+		return false
+	}
+	saved := *p
+	defer func() { *p = saved }()
+
+	p.next()
+	if p.tok == token.COMMA {
+		p.next()
+		if p.tok != token.IDENT {
+			return false
+		}
+		p.next()
+	}
+	return p.tok == token.IDENT && p.lit == "in"
+}
+
+// parseInClause парсит заголовок for ... in: i in 0..<n, i in 10..0 step -2
+// или i, v in items.
+func (p *parser) parseInClause() *ast.InClause {
+	if p.trace {
+		defer un(trace(p, "InClause"))
+	}
+
+	clause := &ast.InClause{Key: p.parseIdent()}
+	if p.tok == token.COMMA {
+		p.next()
+		clause.Value = p.parseIdent()
+	}
+	clause.In = p.pos
+	p.next() // in
+
+	x := p.parseRhs()
+	if p.tok == token.RANGE_INCL || p.tok == token.RANGE_EXCL {
+		r := &ast.RangeExpr{Low: x, OpPos: p.pos, Op: p.tok}
+		p.next()
+		r.High = p.parseRhs()
+		if p.tok == token.IDENT && p.lit == "step" {
+			r.StepPos = p.pos
+			p.next()
+			r.Step = p.parseRhs()
+		}
+		x = r
+	} else if p.tok == token.IDENT && p.lit == "step" {
+		p.error(p.pos, "step is only allowed with a range (low..high or low..<high)")
+		p.next()
+		p.parseRhs()
+	}
+
+	clause.X = x
+	return clause
+}
+
 func (p *parser) parseStmt() (s ast.Stmt) {
 	defer decNestLev(incNestLev(p))
 
@@ -2504,6 +2751,27 @@ func (p *parser) parseStmt() (s ast.Stmt) {
 		defer un(trace(p, "Statement"))
 	}
 
+	if p.isParallelStmtStart() {
+		s = p.parseParallelStmt()
+		p.expectSemi()
+		return
+	}
+	if p.isRetryStmtStart() {
+		s = p.parseRetryStmt()
+		p.expectSemi()
+		return
+	}
+	if p.isGuardStmtStart() {
+		s = p.parseGuardStmt()
+		p.expectSemi()
+		return
+	}
+	if p.isAssertStmtStart() {
+		s = p.parseAssertStmt()
+		p.expectSemi()
+		return
+	}
+
 	switch p.tok {
 	case token.CONST, token.TYPE, token.VAR:
 		s = &ast.DeclStmt{Decl: p.parseDecl(stmtStart)}
@@ -2877,7 +3145,11 @@ func (p *parser) parseFuncDecl() *ast.FuncDecl {
 		p.error(tparams.Opening, "method must have no type parameters")
 		tparams = nil
 	}
-	results := p.parseResult()
+	var results *ast.FieldList
+	if !p.isContractStart() {
+		results = p.parseResult()
+	}
+	contracts := p.parseContracts()
 
 	var body *ast.BlockStmt
 	switch p.tok {
@@ -2906,11 +3178,49 @@ func (p *parser) parseFuncDecl() *ast.FuncDecl {
 			Params:     params,
 			Results:    results,
 		},
-		Body: body,
+		Contracts: contracts,
+		Body:      body,
 	}
 	return decl
 }
 
+// isContractStart сообщает, начинается ли с текущего токена клауза
+// requires или ensures. Это контекстные ключевые слова: за ними сразу идёт
+// условие, тогда как за типом результата с таким именем — {, ., [ или ;.
+func (p *parser) isContractStart() bool {
+	if p.tok != token.IDENT || p.lit != "requires" && p.lit != "ensures" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.IDENT, token.NOT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING,
+		token.LPAREN, token.SUB, token.MUL:
+		return true
+	}
+	return false
+}
+
+// parseContracts парсит клаузы requires Cond и ensures Cond после
+// сигнатуры функции.
+func (p *parser) parseContracts() []*ast.ContractClause {
+	if p.trace {
+		defer un(trace(p, "Contracts"))
+	}
+
+	var list []*ast.ContractClause
+	for p.isContractStart() {
+		c := &ast.ContractClause{Keyword: p.pos, Ensures: p.lit == "ensures"}
+		p.next() // consume "requires" or "ensures"
+
+		prevLev := p.exprLev
+		p.exprLev = -1
+		c.Cond = p.parseRhs()
+		p.exprLev = prevLev
+
+		list = append(list, c)
+	}
+	return list
+}
+
 func (p *parser) parseDecl(sync map[token.Token]bool) ast.Decl {
 	if p.trace {
 		defer un(trace(p, "Declaration"))
@@ -2931,6 +3241,12 @@ func (p *parser) parseDecl(sync map[token.Token]bool) ast.Decl {
 		return p.parseFuncDecl()
 
 	default:
+		if p.isEnumDeclStart() {
+			return p.parseEnumDecl()
+		}
+		if p.isRecordDeclStart() {
+			return p.parseRecordDecl()
+		}
 		pos := p.pos
 		p.errorExpected(pos, "declaration")
 		p.advance(sync)
@@ -3104,7 +3420,24 @@ func (p *parser) parseTryStmt() *ast.TryStmt {
 
 	pos := p.expect(token.TRY)
 	body := p.parseBlockStmt()
+	catches := p.parseCatchClauses()
+
+	var finally *ast.BlockStmt
+	if p.tok == token.FINALLY {
+		p.expect(token.FINALLY)
+		finally = p.parseBlockStmt()
+	}
+
+	return &ast.TryStmt{
+		Try:     pos,
+		Body:    body,
+		Catches: catches,
+		Finally: finally,
+	}
+}
 
+// parseCatchClauses парсит последовательность catch-блоков после try или retry.
+func (p *parser) parseCatchClauses() []*ast.CatchStmt {
 	var catches []*ast.CatchStmt
 	for p.tok == token.CATCH {
 		catchPos := p.expect(token.CATCH)
@@ -3130,21 +3463,756 @@ func (p *parser) parseTryStmt() *ast.TryStmt {
 			Body:       catchBody,
 		})
 	}
+	return catches
+}
 
-	var finally *ast.BlockStmt
-	if p.tok == token.FINALLY {
-		p.expect(token.FINALLY)
-		finally = p.parseBlockStmt()
+// isRetryStmtStart сообщает, начинается ли с текущего токена блок retry.
+// retry — контекстное ключевое слово: идентификатор retry, за которым сразу
+// идёт число или имя (retry 3 {...}, retry n {...}), не может начинать
+// обычный оператор Go, поэтому переменные и функции с именем retry
+// продолжают работать.
+func (p *parser) isRetryStmtStart() bool {
+	if p.tok != token.IDENT || p.lit != "retry" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.INT, token.IDENT:
+		return true
 	}
+	return false
+}
 
-	return &ast.TryStmt{
-		Try:     pos,
+// parseRetryStmt парсит retry Count [backoff Delay] { ... } [catch ...].
+func (p *parser) parseRetryStmt() *ast.RetryStmt {
+	defer decNestLev(incNestLev(p))
+	if p.trace {
+		defer un(trace(p, "RetryStmt"))
+	}
+
+	pos := p.pos
+	p.next() // consume "retry"
+
+	prevLev := p.exprLev
+	p.exprLev = -1
+	count := p.parseBinaryExpr(nil, token.LowestPrec+1)
+	var backoff ast.Expr
+	if p.tok == token.IDENT && p.lit == "backoff" {
+		p.next()
+		backoff = p.parseDelay()
+	}
+	p.exprLev = prevLev
+
+	body := p.parseBlockStmt()
+	catches := p.parseCatchClauses()
+
+	return &ast.RetryStmt{
+		Retry:   pos,
+		Count:   count,
+		Backoff: backoff,
 		Body:    body,
 		Catches: catches,
-		Finally: finally,
 	}
 }
 
+// isGuardStmtStart сообщает, начинается ли с текущего токена оператор guard.
+// guard — контекстное ключевое слово: за ним должно сразу идти имя или !
+// (guard v, ok := ..., guard !done), что невозможно в обычном коде Go.
+func (p *parser) isGuardStmtStart() bool {
+	if p.tok != token.IDENT || p.lit != "guard" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.IDENT, token.NOT:
+		return true
+	}
+	return false
+}
+
+// parseGuardStmt парсит guard Stmt else { ... }, где Stmt — присваивание
+// (guard v, ok := m[k]) или условие (guard n > 0).
+func (p *parser) parseGuardStmt() *ast.GuardStmt {
+	defer decNestLev(incNestLev(p))
+	if p.trace {
+		defer un(trace(p, "GuardStmt"))
+	}
+
+	pos := p.pos
+	p.next() // consume "guard"
+
+	prevLev := p.exprLev
+	p.exprLev = -1
+	stmt, _ := p.parseSimpleStmt(basic)
+	p.exprLev = prevLev
+
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+	case *ast.AssignStmt:
+		if s.Tok != token.DEFINE && s.Tok != token.ASSIGN {
+			p.error(s.TokPos, "guard requires := or = assignment")
+		}
+	default:
+		p.error(stmt.Pos(), "expected condition or assignment after guard")
+	}
+
+	p.expect(token.ELSE)
+	body := p.parseBlockStmt()
+
+	return &ast.GuardStmt{
+		Guard: pos,
+		Stmt:  stmt,
+		Else:  body,
+	}
+}
+
+// isAssertStmtStart сообщает, начинается ли с текущего токена оператор
+// assert. assert — контекстное ключевое слово: за ним должен сразу идти
+// операнд (assert n > 0, assert !done, assert ok), что невозможно в обычном
+// коде Go. Вызов assert(x) и выражения вида assert - 1 остаются обычным кодом.
+func (p *parser) isAssertStmtStart() bool {
+	if p.tok != token.IDENT || p.lit != "assert" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.IDENT, token.NOT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING:
+		return true
+	}
+	return false
+}
+
+// parseAssertStmt парсит assert Cond [, Msg].
+func (p *parser) parseAssertStmt() *ast.AssertStmt {
+	if p.trace {
+		defer un(trace(p, "AssertStmt"))
+	}
+
+	pos := p.pos
+	p.next() // consume "assert"
+
+	cond := p.parseRhs()
+	var msg ast.Expr
+	if p.tok == token.COMMA {
+		p.next()
+		msg = p.parseRhs()
+	}
+
+	return &ast.AssertStmt{
+		Assert: pos,
+		Cond:   cond,
+		Msg:    msg,
+	}
+}
+
+// isEnumDeclStart сообщает, начинается ли с текущей позиции объявление
+// enum Name { ... }. enum — контекстное ключевое слово: идентификатор
+// enum вне объявлений остаётся обычным именем.
+func (p *parser) isEnumDeclStart() bool {
+	return p.tok == token.IDENT && p.lit == "enum" && p.peekNextToken() == token.IDENT
+}
+
+// parseEnumDecl парсит enum Name [: Type] { A, B = Value, ... }.
+// Члены разделяются запятыми или переводами строк.
+func (p *parser) parseEnumDecl() *ast.EnumDecl {
+	if p.trace {
+		defer un(trace(p, "EnumDecl"))
+	}
+
+	doc := p.leadComment
+	pos := p.pos
+	p.next() // consume "enum"
+	name := p.parseIdent()
+
+	var typ ast.Expr
+	if p.tok == token.COLON {
+		p.next()
+		typ = p.parseType()
+	}
+
+	lbrace := p.expect(token.LBRACE)
+	var members []*ast.EnumMember
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		m := &ast.EnumMember{Name: p.parseIdent()}
+		if p.tok == token.ASSIGN {
+			p.next()
+			m.Value = p.parseRhs()
+		}
+		members = append(members, m)
+		if p.tok != token.COMMA && p.tok != token.SEMICOLON {
+			break
+		}
+		p.next()
+	}
+	rbrace := p.expect(token.RBRACE)
+	p.expectSemi()
+
+	if len(members) == 0 {
+		p.error(lbrace, "enum must declare at least one member")
+	}
+
+	return &ast.EnumDecl{
+		Doc:     doc,
+		Enum:    pos,
+		Name:    name,
+		Type:    typ,
+		Lbrace:  lbrace,
+		Members: members,
+		Rbrace:  rbrace,
+	}
+}
+
+// isRecordDeclStart сообщает, начинается ли с текущей позиции объявление
+// record Name(...). Как и enum, record — контекстное ключевое слово.
+func (p *parser) isRecordDeclStart() bool {
+	return p.tok == token.IDENT && p.lit == "record" && p.peekNextToken() == token.IDENT
+}
+
+// parseRecordDecl парсит record Name[TypeParams](Fields) [derive(A, B)].
+// Поля записываются как параметры функции: (X, Y int, Name string).
+func (p *parser) parseRecordDecl() *ast.RecordDecl {
+	if p.trace {
+		defer un(trace(p, "RecordDecl"))
+	}
+
+	doc := p.leadComment
+	pos := p.pos
+	p.next() // consume "record"
+	name := p.parseIdent()
+	tparams, fields := p.parseParameters(true)
+
+	for _, f := range fields.List {
+		if len(f.Names) == 0 {
+			p.error(f.Pos(), "record fields must be named")
+		}
+		if _, ok := f.Type.(*ast.Ellipsis); ok {
+			p.error(f.Type.Pos(), "record fields cannot be variadic")
+		}
+	}
+	if len(fields.List) == 0 {
+		p.error(fields.Opening, "record must declare at least one field")
+	}
+
+	decl := &ast.RecordDecl{
+		Doc:        doc,
+		Record:     pos,
+		Name:       name,
+		TypeParams: tparams,
+		Fields:     fields,
+	}
+	if p.tok == token.IDENT && p.lit == "derive" {
+		decl.Derive = p.pos
+		p.next()
+		p.expect(token.LPAREN)
+		for p.tok != token.RPAREN && p.tok != token.EOF {
+			decl.Derives = append(decl.Derives, p.parseIdent())
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+		decl.Rparen = p.expect(token.RPAREN)
+	}
+	p.expectSemi()
+
+	return decl
+}
+
+// isLambdaStart сообщает, начинается ли с текущей ( лямбда (a, b) => ...:
+// просматривает токены до парной ) и проверяет, что за ней идёт =>.
+// Состояние парсера после просмотра восстанавливается.
+func (p *parser) isLambdaStart() bool {
+	saved := *p
+	defer func() { *p = saved }()
+
+	depth := 0
+	for {
+		switch p.tok {
+		case token.LPAREN:
+			depth++
+		case token.RPAREN:
+			depth--
+			if depth == 0 {
+				p.next()
+				return p.tok == token.LAMBDA
+			}
+		case token.EOF:
+			return false
+		}
+		p.next()
+	}
+}
+
+// isComprehensionStart сообщает, начинается ли с текущей [ или { генератор
+// [v for x in xs] или {k: v for x in xs}: просматривает токены до парной
+// скобки и ищет for на верхнем уровне. Состояние парсера после просмотра
+// восстанавливается.
+func (p *parser) isComprehensionStart() bool {
+	saved := *p
+	defer func() { *p = saved }()
+
+	depth := 0
+	for {
+		switch p.tok {
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			depth--
+			if depth == 0 {
+				return false
+			}
+		case token.FOR:
+			if depth == 1 {
+				return true
+			}
+		case token.EOF:
+			return false
+		}
+		p.next()
+	}
+}
+
+// parseComprehensionExpr парсит генератор среза [v for x in xs if cond]
+// или мапы {k: v for k, v in m if cond}. Заголовок после for — тот же,
+// что у цикла for ... in, включая диапазоны.
+func (p *parser) parseComprehensionExpr() *ast.ComprehensionExpr {
+	if p.trace {
+		defer un(trace(p, "ComprehensionExpr"))
+	}
+
+	x := &ast.ComprehensionExpr{Lbrack: p.pos}
+	closing := token.RBRACK
+	if p.tok == token.LBRACE {
+		closing = token.RBRACE
+	}
+	p.next()
+	p.exprLev++
+	x.Value = p.parseRhs()
+	if closing == token.RBRACE {
+		x.Key = x.Value
+		x.Colon = p.expect(token.COLON)
+		x.Value = p.parseRhs()
+	}
+	x.For = p.expect(token.FOR)
+	if p.isInClauseStart() {
+		x.In = p.parseInClause()
+	} else {
+		p.errorExpected(p.pos, "x in collection")
+		x.In = &ast.InClause{Key: &ast.Ident{NamePos: p.pos, Name: "_"}, X: &ast.BadExpr{From: p.pos, To: p.pos}}
+	}
+	if p.tok == token.IF {
+		x.If = p.pos
+		p.next()
+		x.Cond = p.parseRhs()
+	}
+	p.exprLev--
+	x.Rbrack = p.expect(closing)
+	return x
+}
+
+// parseLambdaExpr парсит x => expr, (a, b) => expr, (a, b T) => expr
+// и лямбды с телом-блоком (a T) => { ... }. Типы параметров, как в Go,
+// задаются для группы имён; либо у всех параметров, либо ни у одного.
+func (p *parser) parseLambdaExpr() *ast.LambdaExpr {
+	if p.trace {
+		defer un(trace(p, "LambdaExpr"))
+	}
+
+	params := &ast.FieldList{}
+	if p.tok == token.IDENT {
+		params.List = []*ast.Field{{Names: []*ast.Ident{p.parseIdent()}}}
+	} else {
+		params.Opening = p.expect(token.LPAREN)
+		var names []*ast.Ident
+		for p.tok != token.RPAREN && p.tok != token.EOF {
+			names = append(names, p.parseIdent())
+			if p.tok != token.COMMA && p.tok != token.RPAREN {
+				params.List = append(params.List, &ast.Field{Names: names, Type: p.parseType()})
+				names = nil
+			}
+			if p.tok != token.COMMA {
+				break
+			}
+			p.next()
+		}
+		if len(names) > 0 {
+			if len(params.List) > 0 {
+				p.error(names[0].Pos(), "lambda parameters must be either all typed or all untyped")
+			}
+			for _, n := range names {
+				params.List = append(params.List, &ast.Field{Names: []*ast.Ident{n}})
+			}
+		}
+		params.Closing = p.expect(token.RPAREN)
+	}
+
+	lambda := &ast.LambdaExpr{Params: params, Arrow: p.expect(token.LAMBDA)}
+	if p.tok == token.LBRACE {
+		p.exprLev++
+		lambda.Block = p.parseBody()
+		p.exprLev--
+	} else {
+		lambda.Body = p.parseRhs()
+	}
+	return lambda
+}
+
+// parseInterpolatedLit разбирает текущий литерал $"text {expr:%spec}" на
+// текст и встроенные выражения. Выражения парсятся отдельным парсером
+// как полноценные выражения godsl (тернарный оператор, ?? и т.д.) с
+// позициями в исходном файле.
+func (p *parser) parseInterpolatedLit() *ast.InterpolatedLit {
+	if p.trace {
+		defer un(trace(p, "InterpolatedLit"))
+	}
+
+	lit := &ast.InterpolatedLit{ValuePos: p.pos, Value: p.lit}
+	if len(p.lit) < 3 || !strings.HasSuffix(p.lit, `"`) {
+		return lit // ошибка уже выдана сканером
+	}
+	base := p.file.Offset(p.pos)
+	src := p.lit[2 : len(p.lit)-1] // без $" и "
+
+	var text strings.Builder
+	for i := 0; i < len(src); i++ {
+		switch ch := src[i]; {
+		case ch == '\\' && i+1 < len(src):
+			text.WriteString(src[i : i+2])
+			i++
+		case ch == '{' && i+1 < len(src) && src[i+1] == '{', ch == '}' && i+1 < len(src) && src[i+1] == '}':
+			text.WriteByte(ch)
+			i++
+		case ch == '{':
+			end := interpExprEnd(src, i+1)
+			expr, format := src[i+1:end], ""
+			if colon := interpFormatColon(expr); colon >= 0 {
+				expr, format = expr[:colon], strings.TrimSpace(expr[colon+1:])
+			}
+			offs := base + 2 + i + 1
+			if strings.TrimSpace(expr) == "" {
+				p.error(p.file.Pos(offs), "empty expression in interpolated string")
+				expr = "_"
+			}
+			lit.Texts = append(lit.Texts, text.String())
+			lit.Exprs = append(lit.Exprs, p.parseEmbeddedExpr(offs, expr))
+			lit.Formats = append(lit.Formats, format)
+			text.Reset()
+			i = end
+		default:
+			text.WriteByte(ch)
+		}
+	}
+	lit.Texts = append(lit.Texts, text.String())
+	return lit
+}
+
+// parseEmbeddedExpr парсит выражение src, расположенное в файле по смещению
+// offs. Парсер получает исходник размером с файл, где всё, кроме выражения,
+// заменено пробелами, поэтому позиции узлов совпадают с позициями в файле.
+func (p *parser) parseEmbeddedExpr(offs int, src string) ast.Expr {
+	padded := bytes.Repeat([]byte{' '}, p.file.Size())
+	copy(padded[offs:], src)
+
+	var sub parser
+	sub.init(p.file, padded, p.mode&^ParseComments)
+	x := sub.parseRhs()
+	if sub.tok == token.SEMICOLON && sub.lit == "\n" {
+		sub.next()
+	}
+	sub.expect(token.EOF)
+	p.errors = append(p.errors, sub.errors...)
+	return x
+}
+
+// interpExprEnd возвращает индекс }, закрывающей выражение, которое
+// начинается в src с индекса start. Учитывает вложенные скобки и строки.
+func interpExprEnd(src string, start int) int {
+	depth := 0
+	for i := start; i < len(src); i++ {
+		switch src[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case '"', '\'', '`':
+			i = interpQuoteEnd(src, i)
+		}
+	}
+	return len(src)
+}
+
+// interpQuoteEnd возвращает индекс кавычки, закрывающей строку или руну,
+// которая открывается в src[i].
+func interpQuoteEnd(src string, i int) int {
+	quote := src[i]
+	for i++; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return len(src)
+}
+
+// interpFormatColon возвращает индекс двоеточия, отделяющего формат
+// ({ratio:%.2f}), или -1. Формат начинается с %, поэтому двоеточия
+// тернарного оператора, срезов и составных литералов не подходят.
+func interpFormatColon(expr string) int {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '"', '\'', '`':
+			i = interpQuoteEnd(expr, i)
+		case ':':
+			if depth == 0 && strings.HasPrefix(strings.TrimSpace(expr[i+1:]), "%") {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseCoalesceExpr парсит цепочку x ?? y ?? z с уже разобранным левым
+// операндом. Оператор ?? правоассоциативен и связывает слабее бинарных.
+func (p *parser) parseCoalesceExpr(x ast.Expr) ast.Expr {
+	if p.tok != token.COALESCE {
+		return x
+	}
+	opPos := p.pos
+	p.next() // consume ??
+	y := p.parseCoalesceExpr(p.parseBinaryExpr(nil, token.LowestPrec+1))
+	return &ast.CoalesceExpr{X: x, OpPos: opPos, Y: y}
+}
+
+// isAsyncExprStart сообщает, начинается ли с текущего токена async- или
+// await-выражение. Оба слова контекстные: за ними должен сразу идти
+// идентификатор или func (async fetch(url), await f), что невозможно
+// в обычном коде Go.
+func (p *parser) isAsyncExprStart() bool {
+	if p.tok != token.IDENT || (p.lit != "async" && p.lit != "await") {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.IDENT, token.FUNC:
+		return true
+	}
+	return false
+}
+
+// isMatchExprStart сообщает, начинается ли с текущего токена match-выражение.
+// match — контекстное ключевое слово: за ним должно сразу идти имя или
+// литерал (match v {...}); вызов match(x) остаётся обычным вызовом функции.
+func (p *parser) isMatchExprStart() bool {
+	if p.tok != token.IDENT || p.lit != "match" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING:
+		return true
+	}
+	return false
+}
+
+// parseMatchExpr парсит match X { case P1, P2 [if Guard]: Value ... default: Value }.
+func (p *parser) parseMatchExpr() *ast.MatchExpr {
+	defer decNestLev(incNestLev(p))
+	if p.trace {
+		defer un(trace(p, "MatchExpr"))
+	}
+
+	pos := p.pos
+	p.next() // consume "match"
+
+	prevLev := p.exprLev
+	p.exprLev = -1
+	x := p.parseExpr()
+	p.exprLev = prevLev
+
+	lbrace := p.expect(token.LBRACE)
+	var arms []*ast.MatchArm
+	for p.tok == token.CASE || p.tok == token.DEFAULT {
+		arms = append(arms, p.parseMatchArm())
+	}
+	rbrace := p.expect2(token.RBRACE)
+
+	return &ast.MatchExpr{
+		Match:  pos,
+		X:      x,
+		Lbrace: lbrace,
+		Arms:   arms,
+		Rbrace: rbrace,
+	}
+}
+
+// parseMatchArm парсит одну ветку match: case P1, P2 [if Guard]: Value,
+// case T v [if Guard]: Value или default: Value.
+func (p *parser) parseMatchArm() *ast.MatchArm {
+	if p.trace {
+		defer un(trace(p, "MatchArm"))
+	}
+
+	arm := &ast.MatchArm{Case: p.pos}
+	if p.tok == token.CASE {
+		p.next()
+		arm.Patterns = p.parseList(false)
+		if p.tok == token.IDENT {
+			// case int n — образец типа с привязкой переменной
+			arm.Binding = p.parseIdent()
+			if len(arm.Patterns) > 1 {
+				p.error(arm.Binding.Pos(), "cannot bind a variable in a match arm with several patterns")
+			}
+		}
+		if p.tok == token.IF {
+			p.next()
+			arm.Guard = p.parseExpr()
+		}
+	} else {
+		p.expect(token.DEFAULT)
+	}
+	arm.Colon = p.expect(token.COLON)
+	arm.Value = p.parseExpr()
+	if p.tok == token.SEMICOLON {
+		p.next()
+	}
+	return arm
+}
+
+// parseStmtExpr парсит if или switch в позиции выражения:
+// x := if a > b { a } else { b }. Значение ветки — её последнее выражение.
+func (p *parser) parseStmtExpr() *ast.StmtExpr {
+	if p.trace {
+		defer un(trace(p, "StmtExpr"))
+	}
+
+	prevLev, prevRhs := p.exprLev, p.inRhs
+	p.stmtExprLev++
+	p.asExpr = true
+	var s ast.Stmt
+	if p.tok == token.IF {
+		s = p.parseIfStmt()
+	} else {
+		s = p.parseSwitchStmt()
+	}
+	p.stmtExprLev--
+	p.exprLev, p.inRhs = prevLev, prevRhs
+
+	return &ast.StmtExpr{Stmt: s}
+}
+
+// isParallelStmtStart сообщает, начинается ли с текущего токена блок parallel.
+// Как и retry, parallel — контекстное ключевое слово: блоком считается
+// parallel { и parallel <режим>. Два имени подряд в Go недопустимы, поэтому
+// неизвестный режим (опечатка вроде parallel al) разбирается как блок и
+// parseParallelStmt сообщает о нём.
+func (p *parser) isParallelStmtStart() bool {
+	if p.tok != token.IDENT || p.lit != "parallel" {
+		return false
+	}
+	switch p.peekNextToken() {
+	case token.LBRACE:
+		return true
+	case token.IDENT:
+		return true
+	}
+	return false
+}
+
+// parseParallelStmt парсит parallel [all|first] { go ...; ... } [catch ...].
+func (p *parser) parseParallelStmt() *ast.ParallelStmt {
+	defer decNestLev(incNestLev(p))
+	if p.trace {
+		defer un(trace(p, "ParallelStmt"))
+	}
+
+	pos := p.pos
+	p.next() // consume "parallel"
+
+	var mode *ast.Ident
+	if p.tok == token.IDENT {
+		mode = p.parseIdent()
+		switch mode.Name {
+		case "first":
+			mode = nil // режим по умолчанию
+		case "all":
+		default:
+			p.error(mode.Pos(), fmt.Sprintf("unknown parallel mode %s: expected first or all", mode.Name))
+			mode = nil
+		}
+	}
+
+	lbrace := p.expect(token.LBRACE)
+	var branches []ast.Stmt
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		if p.tok != token.GO {
+			p.errorExpected(p.pos, "'go' branch")
+			p.parseStmt() // пропускаем оператор целиком
+			continue
+		}
+		branches = append(branches, p.parseParallelBranch())
+	}
+	rbrace := p.expect2(token.RBRACE)
+
+	catches := p.parseCatchClauses()
+
+	return &ast.ParallelStmt{
+		Parallel: pos,
+		Mode:     mode,
+		Body:     &ast.BlockStmt{Lbrace: lbrace, List: branches, Rbrace: rbrace},
+		Catches:  catches,
+	}
+}
+
+// parseParallelBranch парсит ветку parallel-блока: go { ... } или
+// go <простой оператор>[?].
+func (p *parser) parseParallelBranch() *ast.ParallelBranch {
+	pos := p.expect(token.GO)
+
+	var s ast.Stmt
+	if p.tok == token.LBRACE {
+		s = p.parseBlockStmt()
+	} else {
+		s, _ = p.parseSimpleStmt(basic)
+		if p.tok == token.QUESTION {
+			questionPos := p.pos
+			p.next()
+			s = &ast.QuestionStmt{Stmt: s, Question: questionPos}
+		}
+	}
+	p.expectSemi()
+
+	return &ast.ParallelBranch{Go: pos, Stmt: s}
+}
+
+// durationUnits — суффиксы литералов длительности, как в time.ParseDuration.
+var durationUnits = map[string]bool{
+	"ns": true, "us": true, "µs": true, "ms": true, "s": true, "m": true, "h": true,
+}
+
+// parseDelay парсит задержку: литерал длительности (100ms, 1.5s) или
+// обычное выражение (100 * time.Millisecond).
+func (p *parser) parseDelay() ast.Expr {
+	if p.tok == token.INT || p.tok == token.FLOAT {
+		pos, lit := p.pos, p.lit
+		next := p.peekNextToken()
+		// Единица измерения должна идти вплотную к числу: 100ms, а не 100 ms.
+		if next == token.IDENT && durationUnits[p.peekLit] && p.peekPos == pos+token.Pos(len(lit)) {
+			p.next() // число
+			unit := p.lit
+			p.next() // единица
+			return &ast.DurationLit{ValuePos: pos, Value: lit + unit}
+		}
+	}
+	return p.parseBinaryExpr(nil, token.LowestPrec+1)
+}
+
 func (p *parser) parseErrCheckStmt() *ast.ErrCheckStmt {
 	if p.trace {
 		defer un(trace(p, "ErrCheckStmt"))
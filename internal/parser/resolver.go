@@ -285,6 +285,39 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		r.walkFuncType(n.Type)
 		r.walkBody(n.Body)
 
+	case *ast.LambdaExpr:
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		r.resolveList(n.Params)
+		r.declareList(n.Params, ast.Var)
+		if n.Block != nil {
+			r.walkBody(n.Block)
+		} else {
+			ast.Walk(r, n.Body)
+		}
+
+	case *ast.ComprehensionExpr:
+		// Коллекция вычисляется во внешней области, переменные цикла
+		// видны в элементе и условии.
+		ast.Walk(r, n.In.X)
+		r.openScope(n.Pos())
+		defer r.closeScope()
+		r.declare(n.In, nil, r.topScope, ast.Var, n.In.Key)
+		if n.In.Value != nil {
+			r.declare(n.In, nil, r.topScope, ast.Var, n.In.Value)
+		}
+		if n.Key != nil {
+			ast.Walk(r, n.Key)
+		}
+		ast.Walk(r, n.Value)
+		if n.Cond != nil {
+			ast.Walk(r, n.Cond)
+		}
+
+	case *ast.NamedArg:
+		// Имя аргумента — имя параметра вызываемой функции, не ссылка.
+		ast.Walk(r, n.Value)
+
 	case *ast.SelectorExpr:
 		ast.Walk(r, n.X)
 		// Note: don't try to resolve n.Sel, as we don't support qualified
@@ -332,7 +365,7 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 
 	case *ast.AssignStmt:
 		r.walkExprs(n.Rhs)
-		if n.Tok == token.DEFINE {
+		if n.Tok == token.DEFINE || n.Tok == token.VAL {
 			r.shortVarDecl(n)
 		} else {
 			r.walkExprs(n.Lhs)
@@ -431,6 +464,13 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		if n.Post != nil {
 			ast.Walk(r, n.Post)
 		}
+		if n.In != nil {
+			ast.Walk(r, n.In.X)
+			r.declare(n.In, nil, r.topScope, ast.Var, n.In.Key)
+			if n.In.Value != nil {
+				r.declare(n.In, nil, r.topScope, ast.Var, n.In.Value)
+			}
+		}
 		ast.Walk(r, n.Body)
 
 	case *ast.RangeStmt:
@@ -468,6 +508,27 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		ast.Walk(r, n.Body)
 
 	// Declarations
+	case *ast.EnumDecl:
+		r.declare(n, nil, r.topScope, ast.Typ, n.Name)
+		if n.Type != nil {
+			ast.Walk(r, n.Type)
+		}
+		for i, m := range n.Members {
+			if m.Value != nil {
+				ast.Walk(r, m.Value)
+			}
+			r.declare(m, i, r.topScope, ast.Con, m.Name)
+		}
+
+	case *ast.RecordDecl:
+		r.declare(n, nil, r.topScope, ast.Typ, n.Name)
+		if n.TypeParams != nil {
+			r.openScope(n.Pos())
+			defer r.closeScope()
+			r.walkTParams(n.TypeParams)
+		}
+		r.resolveList(n.Fields)
+
 	case *ast.GenDecl:
 		switch n.Tok {
 		case token.CONST, token.VAR:
@@ -521,6 +582,9 @@ func (r *resolver) Visit(node ast.Node) ast.Visitor {
 		r.declareList(n.Type.Params, ast.Var)
 		r.declareList(n.Type.Results, ast.Var)
 
+		for _, c := range n.Contracts {
+			ast.Walk(r, c.Cond)
+		}
 		r.walkBody(n.Body)
 		if n.Recv == nil && n.Name.Name != "init" {
 			r.declare(n, nil, r.pkgScope, ast.Fun, n.Name)
@@ -549,6 +613,9 @@ func (r *resolver) resolveList(list *ast.FieldList) {
 		if f.Type != nil {
 			ast.Walk(r, f.Type)
 		}
+		if f.Default != nil {
+			ast.Walk(r, f.Default)
+		}
 	}
 }
 
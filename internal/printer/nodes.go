@@ -369,10 +369,19 @@ func (p *printer) parameters(fields *ast.FieldList, mode paramMode) {
 				// by a linebreak call after a type, or in the next multi-line identList
 				// will do the right thing.
 				p.identList(par.Names, ws == indent)
-				p.print(blank)
+				if par.Type != nil {
+					p.print(blank)
+				}
 			}
 			// parameter type
-			p.expr(stripParensAlways(par.Type))
+			if par.Type != nil {
+				p.expr(stripParensAlways(par.Type))
+			}
+			// godsl: default value
+			if par.Default != nil {
+				p.print(blank, token.ASSIGN, blank)
+				p.expr(par.Default)
+			}
 			prevLine = parLineEnd
 		}
 
@@ -845,6 +854,159 @@ func (p *printer) expr1(expr ast.Expr, prec1, depth int) {
 		p.print(token.COLON, blank)
 		p.expr1(x.Else, token.LowestPrec+1, depth)
 
+	case *ast.DurationLit:
+		p.setPos(x.ValuePos)
+		p.print(x.Value)
+
+	case *ast.InterpolatedLit:
+		p.setPos(x.ValuePos)
+		p.print(x.Value)
+
+	case *ast.LambdaExpr:
+		startCol := p.out.Column
+		p.lambdaParams(x.Params)
+		p.print(blank)
+		p.setPos(x.Arrow)
+		p.print(token.LAMBDA)
+		if x.Block != nil {
+			p.funcBody(p.distanceFrom(x.Pos(), startCol), blank, x.Block)
+		} else {
+			p.print(blank)
+			p.expr(x.Body)
+		}
+
+	case *ast.NamedArg:
+		p.expr(x.Name)
+		p.setPos(x.Colon)
+		p.print(token.COLON, blank)
+		p.expr(x.Value)
+
+	case *ast.RangeExpr:
+		p.expr1(x.Low, token.LowestPrec+1, depth)
+		p.setPos(x.OpPos)
+		p.print(x.Op)
+		p.expr1(x.High, token.LowestPrec+1, depth)
+		if x.Step != nil {
+			p.print(blank)
+			p.setPos(x.StepPos)
+			p.print("step", blank)
+			p.expr1(x.Step, token.LowestPrec+1, depth)
+		}
+
+	case *ast.ComprehensionExpr:
+		lbrack, rbrack := token.LBRACK, token.RBRACK
+		if x.Key != nil {
+			lbrack, rbrack = token.LBRACE, token.RBRACE
+		}
+		p.setPos(x.Lbrack)
+		p.print(lbrack)
+		if x.Key != nil {
+			p.expr(x.Key)
+			p.setPos(x.Colon)
+			p.print(token.COLON, blank)
+		}
+		p.expr(x.Value)
+		p.print(blank)
+		p.setPos(x.For)
+		p.print(token.FOR, blank)
+		p.expr(x.In.Key)
+		if x.In.Value != nil {
+			p.print(token.COMMA, blank)
+			p.expr(x.In.Value)
+		}
+		p.print(blank)
+		p.setPos(x.In.In)
+		p.print("in", blank)
+		p.expr(x.In.X)
+		if x.Cond != nil {
+			p.print(blank)
+			p.setPos(x.If)
+			p.print(token.IF, blank)
+			p.expr(x.Cond)
+		}
+		p.setPos(x.Rbrack)
+		p.print(rbrack)
+
+	case *ast.OptSelectorExpr:
+		p.expr1(x.X, token.HighestPrec, depth)
+		p.setPos(x.OpPos)
+		p.print(token.OPTCHAIN)
+		p.setPos(x.Sel.Pos())
+		p.print(x.Sel)
+
+	case *ast.PipeExpr:
+		ws := indent
+		p.expr1(x.X, token.LowestPrec+1, depth)
+		p.print(blank)
+		xline := p.pos.Line
+		yline := p.lineFor(x.Stage.Pos())
+		p.setPos(x.OpPos)
+		p.print(token.PIPE)
+		if xline != yline && xline > 0 && yline > 0 && p.linebreak(yline, 1, ws, true) > 0 {
+			ws = ignore
+		} else {
+			p.print(blank)
+		}
+		p.expr1(x.Stage, token.LowestPrec+1, depth)
+		if x.Question.IsValid() {
+			p.setPos(x.Question)
+			p.print(token.QUESTION)
+		}
+		if ws == ignore {
+			p.print(unindent)
+		}
+
+	case *ast.CoalesceExpr:
+		p.expr1(x.X, token.LowestPrec+1, depth)
+		p.print(blank)
+		p.setPos(x.OpPos)
+		p.print(token.COALESCE, blank)
+		p.expr1(x.Y, token.LowestPrec+1, depth)
+
+	case *ast.AsyncExpr:
+		p.setPos(x.Async)
+		p.print("async", blank)
+		p.expr1(x.X, token.UnaryPrec, depth)
+
+	case *ast.AwaitExpr:
+		p.setPos(x.Await)
+		p.print("await", blank)
+		p.expr1(x.X, token.UnaryPrec, depth)
+
+	case *ast.StmtExpr:
+		p.stmt(x.Stmt, false)
+
+	case *ast.MatchExpr:
+		p.setPos(x.Match)
+		p.print("match", blank)
+		p.expr(x.X)
+		p.print(blank)
+		p.setPos(x.Lbrace)
+		p.print(token.LBRACE)
+		for _, arm := range x.Arms {
+			p.linebreak(p.lineFor(arm.Case), 1, ignore, false)
+			p.matchArm(arm)
+		}
+		p.linebreak(p.lineFor(x.Rbrace), 1, ignore, false)
+		p.setPos(x.Rbrace)
+		p.print(token.RBRACE)
+
+	case *ast.FallbackExpr:
+		if x.Try.IsValid() {
+			p.setPos(x.Try)
+			p.print(token.TRY, blank)
+			p.expr1(x.X, token.LowestPrec+1, depth)
+			p.print(blank)
+			p.setPos(x.OpPos)
+			p.print(token.ELSE, blank)
+		} else {
+			p.expr1(x.X, token.LowestPrec+1, depth)
+			p.print(blank)
+			p.setPos(x.OpPos)
+			p.print(token.ELVIS, blank)
+		}
+		p.expr1(x.Fallback, token.LowestPrec+1, depth)
+
 	case *ast.StarExpr:
 		const prec = token.UnaryPrec
 		if prec < prec1 {
@@ -1235,6 +1397,34 @@ func (p *printer) stmtList(list []ast.Stmt, nindent int, nextIsRBrace bool) {
 	}
 }
 
+// matchArm prints a single arm of a match expression on one line:
+// case P1, P2 if Guard: Value.
+func (p *printer) matchArm(arm *ast.MatchArm) {
+	p.setPos(arm.Case)
+	if arm.Patterns != nil {
+		p.print(token.CASE, blank)
+		for i, pat := range arm.Patterns {
+			if i > 0 {
+				p.print(token.COMMA, blank)
+			}
+			p.expr(pat)
+		}
+		if arm.Binding != nil {
+			p.print(blank)
+			p.expr(arm.Binding)
+		}
+		if arm.Guard != nil {
+			p.print(blank, token.IF, blank)
+			p.expr(arm.Guard)
+		}
+	} else {
+		p.print(token.DEFAULT)
+	}
+	p.setPos(arm.Colon)
+	p.print(token.COLON, blank)
+	p.expr(arm.Value)
+}
+
 // block prints an *ast.BlockStmt; it always spans at least two lines.
 func (p *printer) block(b *ast.BlockStmt, nindent int) {
 	p.setPos(b.Lbrace)
@@ -1356,6 +1546,28 @@ func (p *printer) indentList(list []ast.Expr) bool {
 	return false
 }
 
+// assignStmt печатает присваивание; val x = ... печатается с ключевым
+// словом val, а must — перед правой частью (x := must f()).
+func (p *printer) assignStmt(s *ast.AssignStmt, must bool) {
+	var depth = 1
+	if len(s.Lhs) > 1 && len(s.Rhs) > 1 {
+		depth++
+	}
+	tok := s.Tok
+	if tok == token.VAL {
+		p.print(token.VAL, blank)
+		tok = token.ASSIGN
+	}
+	p.exprList(s.Pos(), s.Lhs, depth, 0, s.TokPos, false)
+	p.print(blank)
+	p.setPos(s.TokPos)
+	p.print(tok, blank)
+	if must {
+		p.print("must", blank)
+	}
+	p.exprList(s.TokPos, s.Rhs, depth, 0, token.NoPos, false)
+}
+
 func (p *printer) stmt(stmt ast.Stmt, nextIsRBrace bool) {
 	p.setPos(stmt.Pos())
 
@@ -1408,15 +1620,7 @@ func (p *printer) stmt(stmt ast.Stmt, nextIsRBrace bool) {
 		p.print(s.Tok)
 
 	case *ast.AssignStmt:
-		var depth = 1
-		if len(s.Lhs) > 1 && len(s.Rhs) > 1 {
-			depth++
-		}
-		p.exprList(s.Pos(), s.Lhs, depth, 0, s.TokPos, false)
-		p.print(blank)
-		p.setPos(s.TokPos)
-		p.print(s.Tok, blank)
-		p.exprList(s.TokPos, s.Rhs, depth, 0, token.NoPos, false)
+		p.assignStmt(s, false)
 
 	case *ast.GoStmt:
 		p.print(token.GO, blank)
@@ -1529,7 +1733,21 @@ func (p *printer) stmt(stmt ast.Stmt, nextIsRBrace bool) {
 
 	case *ast.ForStmt:
 		p.print(token.FOR)
-		p.controlClause(true, s.Init, s.Cond, s.Post)
+		if s.In != nil {
+			p.print(blank)
+			p.expr(s.In.Key)
+			if s.In.Value != nil {
+				p.print(token.COMMA, blank)
+				p.expr(s.In.Value)
+			}
+			p.print(blank)
+			p.setPos(s.In.In)
+			p.print("in", blank)
+			p.expr(s.In.X)
+			p.print(blank)
+		} else {
+			p.controlClause(true, s.Init, s.Cond, s.Post)
+		}
 		p.block(s.Body, 1)
 
 	case *ast.RangeStmt:
@@ -1595,9 +1813,56 @@ func (p *printer) stmt(stmt ast.Stmt, nextIsRBrace bool) {
 		p.stmt(s.Stmt, nextIsRBrace)
 
 	case *ast.MustStmt:
+		if a, ok := s.Stmt.(*ast.AssignStmt); ok {
+			// x := must f(): must стоит перед правой частью.
+			p.assignStmt(a, true)
+			break
+		}
 		p.print("must", blank)
 		p.stmt(s.Stmt, nextIsRBrace)
 
+	case *ast.RetryStmt:
+		p.print("retry", blank)
+		p.expr(s.Count)
+		if s.Backoff != nil {
+			p.print(blank, "backoff", blank)
+			p.expr(s.Backoff)
+		}
+		p.print(blank)
+		p.block(s.Body, 1)
+		for _, c := range s.Catches {
+			p.stmt(c, nextIsRBrace)
+		}
+
+	case *ast.ParallelStmt:
+		p.print("parallel", blank)
+		if s.Mode != nil {
+			p.expr(s.Mode)
+			p.print(blank)
+		}
+		p.block(s.Body, 1)
+		for _, c := range s.Catches {
+			p.stmt(c, nextIsRBrace)
+		}
+
+	case *ast.ParallelBranch:
+		p.print(token.GO, blank)
+		p.stmt(s.Stmt, nextIsRBrace)
+
+	case *ast.GuardStmt:
+		p.print("guard", blank)
+		p.stmt(s.Stmt, false)
+		p.print(blank, token.ELSE, blank)
+		p.block(s.Else, 1)
+
+	case *ast.AssertStmt:
+		p.print("assert", blank)
+		p.expr(s.Cond)
+		if s.Msg != nil {
+			p.print(token.COMMA, blank)
+			p.expr(s.Msg)
+		}
+
 	default:
 		panic("unreachable")
 	}
@@ -1989,6 +2254,16 @@ func (p *printer) funcDecl(d *ast.FuncDecl) {
 	}
 	p.expr(d.Name)
 	p.signature(d.Type)
+	for _, c := range d.Contracts {
+		p.print(blank)
+		p.setPos(c.Keyword)
+		if c.Ensures {
+			p.print("ensures", blank)
+		} else {
+			p.print("requires", blank)
+		}
+		p.expr(c.Cond)
+	}
 	p.funcBody(p.distanceFrom(d.Pos(), startCol), vtab, d.Body)
 }
 
@@ -2001,11 +2276,109 @@ func (p *printer) decl(decl ast.Decl) {
 		p.genDecl(d)
 	case *ast.FuncDecl:
 		p.funcDecl(d)
+	case *ast.EnumDecl:
+		p.enumDecl(d)
+	case *ast.RecordDecl:
+		p.recordDecl(d)
 	default:
 		panic("unreachable")
 	}
 }
 
+// enumDecl prints an enum declaration. Members written on one line stay
+// on one line (enum Color { Red, Green }); otherwise each member gets its
+// own line.
+func (p *printer) enumDecl(d *ast.EnumDecl) {
+	p.setComment(d.Doc)
+	p.setPos(d.Enum)
+	p.print("enum", blank)
+	p.expr(d.Name)
+	if d.Type != nil {
+		p.print(token.COLON, blank)
+		p.expr(d.Type)
+	}
+	p.print(blank)
+	p.setPos(d.Lbrace)
+	p.print(token.LBRACE)
+	oneLine := p.lineFor(d.Lbrace) == p.lineFor(d.Rbrace)
+	if oneLine {
+		for i, m := range d.Members {
+			if i > 0 {
+				p.print(token.COMMA)
+			}
+			p.print(blank)
+			p.enumMember(m)
+		}
+		p.print(blank)
+	} else {
+		p.print(indent)
+		for _, m := range d.Members {
+			p.linebreak(p.lineFor(m.Pos()), 1, ignore, false)
+			p.enumMember(m)
+		}
+		p.print(unindent)
+		p.linebreak(p.lineFor(d.Rbrace), 1, ignore, false)
+	}
+	p.setPos(d.Rbrace)
+	p.print(token.RBRACE)
+}
+
+// lambdaParams prints lambda parameters: x, (a, b) or (a, b T).
+func (p *printer) lambdaParams(params *ast.FieldList) {
+	if !params.Opening.IsValid() && len(params.List) == 1 && params.List[0].Type == nil {
+		p.expr(params.List[0].Names[0])
+		return
+	}
+	p.setPos(params.Opening)
+	p.print(token.LPAREN)
+	for i, f := range params.List {
+		if i > 0 {
+			p.print(token.COMMA, blank)
+		}
+		p.identList(f.Names, false)
+		if f.Type != nil {
+			p.print(blank)
+			p.expr(f.Type)
+		}
+	}
+	p.setPos(params.Closing)
+	p.print(token.RPAREN)
+}
+
+// recordDecl prints a record declaration:
+// record Name[T any](A, B int) derive(json).
+func (p *printer) recordDecl(d *ast.RecordDecl) {
+	p.setComment(d.Doc)
+	p.setPos(d.Record)
+	p.print("record", blank)
+	p.expr(d.Name)
+	if d.TypeParams != nil {
+		p.parameters(d.TypeParams, typeTParam)
+	}
+	p.parameters(d.Fields, funcParam)
+	if d.Derive.IsValid() {
+		p.print(blank)
+		p.setPos(d.Derive)
+		p.print("derive", token.LPAREN)
+		for i, x := range d.Derives {
+			if i > 0 {
+				p.print(token.COMMA, blank)
+			}
+			p.expr(x)
+		}
+		p.setPos(d.Rparen)
+		p.print(token.RPAREN)
+	}
+}
+
+func (p *printer) enumMember(m *ast.EnumMember) {
+	p.expr(m.Name)
+	if m.Value != nil {
+		p.print(blank, token.ASSIGN, blank)
+		p.expr(m.Value)
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Files
 
@@ -964,7 +964,9 @@ func (p *printer) print(args ...any) {
 
 		case token.Token:
 			s := x.String()
-			if mayCombine(p.lastTok, s[0]) {
+			// 0..<n сканируется как диапазон, пробел после числа не нужен.
+			isRange := x == token.RANGE_INCL || x == token.RANGE_EXCL
+			if !isRange && mayCombine(p.lastTok, s[0]) {
 				// the previous and the current token must be
 				// separated by a blank otherwise they combine
 				// into a different incorrect token sequence
@@ -1062,6 +1064,10 @@ func getDoc(n ast.Node) *ast.CommentGroup {
 		return n.Doc
 	case *ast.FuncDecl:
 		return n.Doc
+	case *ast.EnumDecl:
+		return n.Doc
+	case *ast.RecordDecl:
+		return n.Doc
 	case *ast.File:
 		return n.Doc
 	}
@@ -443,8 +443,8 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 		digsep |= s.digits(base, &invalid)
 	}
 
-	// fractional part
-	if s.ch == '.' {
+	// fractional part; ".." after a number starts a range (0..<n)
+	if s.ch == '.' && s.peek() != '.' {
 		tok = token.FLOAT
 		if prefix == 'o' || prefix == 'b' {
 			s.error(s.offset, "invalid radix point in "+litname(prefix))
@@ -665,6 +665,60 @@ func (s *Scanner) scanString() string {
 	return string(s.src[offs:s.offset])
 }
 
+// scanInterpString сканирует интерполированную строку $"text {expr}".
+// Внутри {...} могут быть любые выражения, в том числе строки с кавычками
+// и фигурные скобки; {{ и }} вне выражений — экранированные скобки.
+func (s *Scanner) scanInterpString() string {
+	// '$"' opening already consumed
+	offs := s.offset - 2
+
+	depth := 0
+	for {
+		ch := s.ch
+		if ch == '\n' || ch < 0 {
+			s.error(offs, "interpolated string literal not terminated")
+			break
+		}
+		s.next()
+		if depth == 0 {
+			if ch == '"' {
+				break
+			}
+			switch ch {
+			case '\\':
+				s.scanEscape('"')
+			case '{':
+				if s.ch == '{' {
+					s.next()
+				} else {
+					depth++
+				}
+			case '}':
+				if s.ch == '}' {
+					s.next()
+				} else {
+					s.error(s.offset-1, "single '}' in interpolated string literal (use '}}')")
+				}
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			s.scanString()
+		case '`':
+			s.scanRawString()
+		case '\'':
+			s.scanRune()
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+	}
+
+	return string(s.src[offs:s.offset])
+}
+
 func stripCR(b []byte, comment bool) []byte {
 	c := make([]byte, len(b))
 	i := 0
@@ -822,6 +876,12 @@ scanAgain:
 	case isDecimal(ch) || ch == '.' && isDecimal(rune(s.peek())):
 		insertSemi = true
 		tok, lit = s.scanNumber()
+	case ch == '$' && s.peek() == '"':
+		s.next()
+		s.next() // consume '$"'
+		insertSemi = true
+		tok = token.INTERP
+		lit = s.scanInterpString()
 	default:
 		s.next() // always make progress
 		switch ch {
@@ -849,6 +909,7 @@ scanAgain:
 			insertSemi = true
 			tok = token.STRING
 			lit = s.scanRawString()
+
 		case ':':
 			tok = s.switch2(token.COLON, token.DEFINE)
 		case '.':
@@ -858,6 +919,13 @@ scanAgain:
 				s.next()
 				s.next() // consume last '.'
 				tok = token.ELLIPSIS
+			} else if s.ch == '.' {
+				s.next()
+				tok = token.RANGE_INCL
+				if s.ch == '<' {
+					s.next()
+					tok = token.RANGE_EXCL
+				}
 			}
 		case ',':
 			tok = token.COMMA
@@ -927,6 +995,11 @@ scanAgain:
 		case '>':
 			tok = s.switch4(token.GTR, token.GEQ, '>', token.SHR, token.SHR_ASSIGN)
 		case '=':
+			if s.ch == '>' {
+				s.next()
+				tok = token.LAMBDA
+				break
+			}
 			tok = s.switch2(token.ASSIGN, token.EQL)
 		case '!':
 			tok = s.switch2(token.NOT, token.NEQ)
@@ -938,12 +1011,32 @@ scanAgain:
 				tok = s.switch3(token.AND, token.AND_ASSIGN, '&', token.LAND)
 			}
 		case '|':
+			if s.ch == '>' {
+				// x |> f — конвейер
+				s.next()
+				tok = token.PIPE
+				break
+			}
 			tok = s.switch3(token.OR, token.OR_ASSIGN, '|', token.LOR)
 		case '~':
 			tok = token.TILDE
 		case '?':
-			insertSemi = true
-			tok = token.QUESTION
+			if s.ch == ':' {
+				// x ?: fallback — значение по умолчанию при ошибке
+				s.next()
+				tok = token.ELVIS
+			} else if s.ch == '.' && !isDecimal(rune(s.peek())) {
+				// a?.b — опциональная цепочка; ?.5 остаётся тернарным оператором
+				s.next()
+				tok = token.OPTCHAIN
+			} else if s.ch == '?' {
+				// x ?? def — значение по умолчанию для nil/нулевого значения
+				s.next()
+				tok = token.COALESCE
+			} else {
+				insertSemi = true
+				tok = token.QUESTION
+			}
 		case '@':
 			// Ожидаем @errcheck — единственная поддерживаемая аннотация
 			if isLetter(s.ch) {
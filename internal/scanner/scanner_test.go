@@ -1,6 +1,7 @@
 package scanner_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/sviridovkonstantin42/godsl/internal/scanner"
@@ -269,6 +270,73 @@ func TestScanner_QuestionInExpression(t *testing.T) {
 	}
 }
 
+func TestScanner_ElvisOperator(t *testing.T) {
+	src := `port := strconv.Atoi(s) ?: 8080`
+	tokens := scanAll(t, src)
+
+	var hasElvis, hasQuestion bool
+	for _, tok := range tokens {
+		switch tok.tok {
+		case token.ELVIS:
+			hasElvis = true
+		case token.QUESTION:
+			hasQuestion = true
+		}
+	}
+	if !hasElvis {
+		t.Errorf("expected ELVIS token in %q, tokens: %v", src, tokens)
+	}
+	if hasQuestion {
+		t.Errorf("?: must not be scanned as QUESTION in %q, tokens: %v", src, tokens)
+	}
+}
+
+func TestScanner_CoalesceOperator(t *testing.T) {
+	src := `name := user.Nickname ?? user.Login`
+	tokens := scanAll(t, src)
+
+	var hasCoalesce, hasQuestion bool
+	for _, tok := range tokens {
+		switch tok.tok {
+		case token.COALESCE:
+			hasCoalesce = true
+		case token.QUESTION:
+			hasQuestion = true
+		}
+	}
+	if !hasCoalesce {
+		t.Errorf("expected COALESCE token in %q, tokens: %v", src, tokens)
+	}
+	if hasQuestion {
+		t.Errorf("?? must not be scanned as QUESTION in %q, tokens: %v", src, tokens)
+	}
+}
+
+func TestScanner_OptionalChain(t *testing.T) {
+	src := `city := order?.Customer?.City`
+	tokens := scanAll(t, src)
+
+	count := 0
+	for _, tok := range tokens {
+		if tok.tok == token.OPTCHAIN {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Errorf("expected 2 OPTCHAIN tokens in %q, tokens: %v", src, tokens)
+	}
+}
+
+func TestScanner_TernaryWithFloat_NotOptionalChain(t *testing.T) {
+	src := `x := ok ?.5 : 1.0`
+	tokens := scanAll(t, src)
+	for _, tok := range tokens {
+		if tok.tok == token.OPTCHAIN {
+			t.Errorf("?.5 must not be scanned as OPTCHAIN in %q, tokens: %v", src, tokens)
+		}
+	}
+}
+
 func TestScanner_ErrCheckWithinTryBody(t *testing.T) {
 	src := `@errcheck a, err := f()`
 	tokens := scanAll(t, src)
@@ -308,6 +376,65 @@ func TestScanner_StringLiteral_Unaffected(t *testing.T) {
 	}
 }
 
+func TestScanner_LambdaArrow(t *testing.T) {
+	src := `(a, b) => a == b`
+	tokens := scanAll(t, src)
+	found := false
+	for _, tok := range tokens {
+		if tok.tok == token.LAMBDA {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected LAMBDA token in %q, tokens: %v", src, tokens)
+	}
+}
+
+func TestScanner_PipeOperator(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token.Token
+	}{
+		{"x |> f", []token.Token{token.IDENT, token.PIPE, token.IDENT}},
+		{"a || b", []token.Token{token.IDENT, token.LOR, token.IDENT}},
+		{"a |= b", []token.Token{token.IDENT, token.OR_ASSIGN, token.IDENT}},
+	}
+	for _, tt := range tests {
+		var got []token.Token
+		for _, tok := range scanAll(t, tt.src) {
+			if tok.tok != token.SEMICOLON && tok.tok != token.EOF {
+				got = append(got, tok.tok)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("scan %q: got %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestScanner_RangeOperators(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []token.Token
+	}{
+		{"0..<n", []token.Token{token.INT, token.RANGE_EXCL, token.IDENT}},
+		{"10..0", []token.Token{token.INT, token.RANGE_INCL, token.INT}},
+		{"a...", []token.Token{token.IDENT, token.ELLIPSIS}},
+		{"1.5", []token.Token{token.FLOAT}},
+	}
+	for _, tt := range tests {
+		var got []token.Token
+		for _, tok := range scanAll(t, tt.src) {
+			if tok.tok != token.SEMICOLON && tok.tok != token.EOF {
+				got = append(got, tok.tok)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("scan %q: got %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
 // ─── numeric literals ─────────────────────────────────────────────────────────
 
 func TestScanner_FloatLiteral(t *testing.T) {
@@ -388,6 +515,41 @@ func TestScanner_StringWithUnicode(t *testing.T) {
 	}
 }
 
+func TestScanner_InterpolatedString(t *testing.T) {
+	src := `$"user {u.Name} has {len(m["k"])} items ({ratio:%.2f}) {{x}}"`
+	tokens := scanAll(t, src)
+	if len(tokens) != 1 {
+		t.Fatalf("expected a single token, got %v", tokens)
+	}
+	if tokens[0].tok != token.INTERP {
+		t.Errorf("expected INTERP, got %s", tokens[0].tok)
+	}
+	if tokens[0].lit != src {
+		t.Errorf("unexpected lit: %q", tokens[0].lit)
+	}
+}
+
+func TestScanner_InterpolatedString_Unterminated(t *testing.T) {
+	src := `$"a {x"`
+	fset := token.NewFileSet()
+	file := fset.AddFile("test.godsl", fset.Base(), len(src))
+
+	var errCalled bool
+	var s scanner.Scanner
+	s.Init(file, []byte(src), func(pos token.Position, msg string) {
+		errCalled = true
+	}, 0)
+	for {
+		_, tok, _ := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+	}
+	if !errCalled {
+		t.Error("expected error handler to be called for unterminated interpolated string")
+	}
+}
+
 // ─── operators and delimiters ─────────────────────────────────────────────────
 
 func TestScanner_Operators(t *testing.T) {
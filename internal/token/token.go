@@ -31,6 +31,7 @@ const (
 	IMAG   // 123.45i
 	CHAR   // 'a'
 	STRING // "abc"
+	INTERP // $"abc {x}"
 	literal_end
 
 	operator_beg
@@ -91,6 +92,14 @@ const (
 	SEMICOLON // ;
 	COLON     // :
 	QUESTION  // ?
+	ELVIS     // ?:
+	COALESCE  // ??
+	OPTCHAIN  // ?.
+	LAMBDA    // =>
+	PIPE      // |>
+
+	RANGE_INCL // ..
+	RANGE_EXCL // ..<
 	operator_end
 
 	keyword_beg
@@ -136,6 +145,7 @@ const (
 	// additional tokens, handled in an ad-hoc manner
 	TILDE
 	ERRCHECK // @errcheck
+	VAL      // val (контекстное ключевое слово: val x = ...)
 	additional_end
 )
 
@@ -157,6 +167,7 @@ var tokens = [...]string{
 	IMAG:   "IMAG",
 	CHAR:   "CHAR",
 	STRING: "STRING",
+	INTERP: "INTERP",
 
 	ADD: "+",
 	SUB: "-",
@@ -214,6 +225,14 @@ var tokens = [...]string{
 	SEMICOLON: ";",
 	COLON:     ":",
 	QUESTION:  "?",
+	ELVIS:     "?:",
+	COALESCE:  "??",
+	OPTCHAIN:  "?.",
+	LAMBDA:    "=>",
+	PIPE:      "|>",
+
+	RANGE_INCL: "..",
+	RANGE_EXCL: "..<",
 
 	BREAK:    "break",
 	CASE:     "case",
@@ -247,6 +266,7 @@ var tokens = [...]string{
 
 	TILDE:    "~",
 	ERRCHECK: "@errcheck",
+	VAL:      "val",
 }
 
 // String returns the string corresponding to the token tok.
@@ -281,7 +301,7 @@ const (
 // is LowestPrecedence.
 func (op Token) Precedence() int {
 	switch op {
-	case LOR:
+	case PIPE, LOR:
 		return 1
 	case LAND:
 		return 2
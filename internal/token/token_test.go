@@ -281,6 +281,7 @@ func TestToken_Precedence_BinaryOps(t *testing.T) {
 		tok  token.Token
 		want int
 	}{
+		{token.PIPE, 1},
 		{token.LOR, 1},
 		{token.LAND, 2},
 		{token.EQL, 3},
@@ -0,0 +1,161 @@
+package transpiler
+
+import (
+	"bytes"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/format"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// assertImportPath — рантайм-пакет godsl для оператора assert.
+const assertImportPath = "github.com/sviridovkonstantin42/godsl/runtime/assert"
+
+// transpileAssertStmt разворачивает assert в проверку, которая паникует
+// с исходным текстом условия и позицией в .godsl:
+//
+//	assert len(buf) <= cap, "buffer overflow"
+//
+// →
+//
+//	if assert.Enabled && len(buf) > cap {
+//	    assert.Fail("main.godsl:12", "len(buf) <= cap", "buffer overflow")
+//	}
+//
+// assert.Enabled ложно при сборке с тегом godsl_noassert, и компилятор
+// выбрасывает проверку целиком. В тестовых функциях assert вызывает
+// t.Fatalf и проверяется всегда:
+//
+//	if len(buf) > cap {
+//	    t.Fatalf("main_test.godsl:12: assertion failed: len(buf) <= cap: %v", "buffer overflow")
+//	}
+func (t *Transpiler) transpileAssertStmt(s *ast.AssertStmt) []ast.Stmt {
+	pos := t.sourcePos(s.Assert)
+	text := t.sourceText(s.Cond)
+	cond := t.negateCond(s.Cond)
+	var msg ast.Expr
+	if s.Msg != nil {
+		msg = t.transpileExpr(s.Msg)
+	}
+
+	var fail ast.Expr
+	if t.testName != "" {
+		format := pos + ": assertion failed: " + strings.ReplaceAll(text, "%", "%%")
+		args := []ast.Expr{nil}
+		if msg != nil {
+			format += ": %v"
+			args = append(args, msg)
+		}
+		args[0] = &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(format)}
+		fail = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.Ident{NamePos: token.NoPos, Name: t.testName},
+				Sel: &ast.Ident{NamePos: token.NoPos, Name: "Fatalf"},
+			},
+			Args: args,
+		}
+	} else {
+		t.checkRuntimeImport(s.Assert, "assert outside test functions", assertImportPath)
+		pkg := t.requireImport(assertImportPath)
+		args := []ast.Expr{
+			&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(pos)},
+			&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(text)},
+		}
+		if msg != nil {
+			args = append(args, msg)
+		}
+		fail = &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.Ident{NamePos: token.NoPos, Name: pkg},
+				Sel: &ast.Ident{NamePos: token.NoPos, Name: "Fail"},
+			},
+			Args: args,
+		}
+		// Позиция assert держит assert.Enabled && cond на одной строке.
+		cond = &ast.BinaryExpr{
+			X: &ast.SelectorExpr{
+				X:   &ast.Ident{NamePos: s.Assert, Name: pkg},
+				Sel: &ast.Ident{NamePos: token.NoPos, Name: "Enabled"},
+			},
+			Op: token.LAND,
+			Y:  cond,
+		}
+	}
+
+	return []ast.Stmt{&ast.IfStmt{
+		If:   token.NoPos,
+		Cond: cond,
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: fail}}},
+	}}
+}
+
+// checkRuntimeImport сообщает об ошибке, если имя рантайм-пакета godsl
+// importPath уже занято в файле другим импортом (например, testify/assert).
+func (t *Transpiler) checkRuntimeImport(pos token.Pos, what, importPath string) {
+	own := path.Base(importPath)
+	for p, name := range t.importNames {
+		if p == importPath {
+			continue
+		}
+		if name == own || name == "" && path.Base(p) == own {
+			t.errorf(pos, "%s conflicts with imported package %s; import it under another name", what, p)
+			return
+		}
+	}
+}
+
+// sourcePos возвращает позицию в .godsl для сообщений рантайма: "main.godsl:12".
+func (t *Transpiler) sourcePos(pos token.Pos) string {
+	position := t.fset.Position(pos)
+	filename := position.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+	return filename + ":" + strconv.Itoa(position.Line)
+}
+
+// sourceText возвращает текст выражения исходного файла в каноническом
+// виде godsl, восстановленный по FileSet транспилятора.
+func (t *Transpiler) sourceText(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, t.fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// testingParamName возвращает имя параметра *testing.T, *testing.B,
+// *testing.F или testing.TB, если он есть у функции. pkg — имя, под
+// которым файл импортирует testing.
+func testingParamName(funcType *ast.FuncType, pkg string) string {
+	if funcType == nil || funcType.Params == nil {
+		return ""
+	}
+	for _, field := range funcType.Params.List {
+		typ := field.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		sel, ok := typ.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != pkg {
+			continue
+		}
+		switch sel.Sel.Name {
+		case "T", "B", "F", "TB":
+		default:
+			continue
+		}
+		for _, name := range field.Names {
+			if name.Name != "_" {
+				return name.Name
+			}
+		}
+	}
+	return ""
+}
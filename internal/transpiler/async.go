@@ -0,0 +1,347 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+const asyncImportPath = "github.com/sviridovkonstantin42/godsl/runtime/async"
+
+// maxAsyncArgs — наибольшее число аргументов вызова, для которого есть
+// адаптеры async.CallN/async.DoN; вызовы с большим числом аргументов
+// оборачиваются в замыкание.
+const maxAsyncArgs = 3
+
+// asyncArgPrefix — префикс параметров замыкания, фиксирующего аргументы
+// вызова в async.
+const asyncArgPrefix = "_godslArg"
+
+// transpileAsyncExpr транспилирует async-выражение в запуск фьючерса:
+//
+//	async fetch(url)             → async.Call1(fetch, url)
+//	async save(x)                → async.Do1(save, x)   // save возвращает только error
+//	async func() (T, error) {…}  → async.Go(func() (T, error) {…})
+//
+// Аргументы вычисляются в момент async, как у оператора go. Функции,
+// возвращающие только error, распознаются по сигнатуре из go/types, а если
+// она неизвестна — по объявлению в этом же файле; остальные считаются
+// возвращающими (T, error).
+func (t *Transpiler) transpileAsyncExpr(x *ast.AsyncExpr) ast.Expr {
+	switch fn := ast.Unparen(x.X).(type) {
+	case *ast.FuncLit:
+		if fn.Type.Params != nil && fn.Type.Params.NumFields() > 0 {
+			t.errorf(x.Async, "async function literal must not have parameters")
+			return x.X
+		}
+		name := "Go"
+		if isErrorOnlyFunc(fn.Type) {
+			name = "Do0"
+		}
+		return t.asyncCall(name, t.transpileFuncLit(fn))
+
+	case *ast.CallExpr:
+		if fn.Ellipsis.IsValid() {
+			t.errorf(x.Async, "async does not support variadic calls with ...")
+			return x.X
+		}
+		fun := t.probe(fn.Fun, t.transpileExpr(fn.Fun))
+		args := t.transpileExprs(fn.Args)
+		errorOnly := t.returnsOnlyError(fn.Fun)
+		if len(args) > maxAsyncArgs {
+			return t.asyncClosure(x, fn, fun, args, errorOnly)
+		}
+		prefix := "Call"
+		if errorOnly {
+			prefix = "Do"
+		}
+		return t.asyncCall(prefix+strconv.Itoa(len(args)), append([]ast.Expr{fun}, args...)...)
+	}
+
+	t.errorf(x.Async, "async requires a function call or function literal")
+	return x.X
+}
+
+// asyncClosure запускает вызов с числом аргументов больше maxAsyncArgs.
+// Аргументы (и выражение функции, если это не имя) передаются параметрами
+// немедленно вызываемого замыкания, поэтому вычисляются в момент async:
+//
+//	async f(a, b, c, d)
+//	→ func(_godslArg0 A, …, _godslArg3 D) *async.Future[T] {
+//		return async.Go(func() (T, error) { return f(_godslArg0, …, _godslArg3) })
+//	}(a, b, c, d)
+func (t *Transpiler) asyncClosure(x *ast.AsyncExpr, call *ast.CallExpr, fun ast.Expr, args []ast.Expr, errorOnly bool) ast.Expr {
+	if t.probing {
+		// типы параметров ещё неизвестны; пробному проходу достаточно
+		// типа результата
+		inner := &ast.CallExpr{Fun: fun, Args: args}
+		if errorOnly {
+			return t.asyncCall("Do0", asyncFuncLit(nil, inner))
+		}
+		return t.asyncCall("Call0", &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: probeAsyncName},
+			Args: []ast.Expr{inner},
+		})
+	}
+
+	sig, _ := t.typeOf(call.Fun).(*types.Signature)
+	if sig == nil || (!errorOnly && sig.Results().Len() != 2) {
+		t.errorf(x.Async, "cannot determine the signature of %s for async with %d arguments", t.sourceText(call.Fun), len(args))
+		return x.X
+	}
+	var result ast.Expr
+	if !errorOnly {
+		if result = t.typeExpr(sig.Results().At(0).Type()); result == nil {
+			t.errorf(x.Async, "cannot determine the result type of %s for async", t.sourceText(call.Fun))
+			return x.X
+		}
+	}
+
+	params := &ast.FieldList{}
+	var bound []ast.Expr
+	bind := func(typ types.Type, value ast.Expr) ast.Expr {
+		name := asyncArgPrefix + strconv.Itoa(len(bound))
+		params.List = append(params.List, &ast.Field{
+			Names: []*ast.Ident{{NamePos: token.NoPos, Name: name}},
+			Type:  t.typeExpr(typ),
+		})
+		bound = append(bound, value)
+		return &ast.Ident{NamePos: token.NoPos, Name: name}
+	}
+	inner := &ast.CallExpr{Fun: fun}
+	if !isStableFunc(call.Fun) {
+		inner.Fun = bind(sig, fun)
+	}
+	for i, arg := range args {
+		typ := asyncParamType(sig, i)
+		if typ == nil {
+			t.errorf(x.Async, "too many arguments in async call of %s", t.sourceText(call.Fun))
+			return x.X
+		}
+		inner.Args = append(inner.Args, bind(typ, arg))
+	}
+	for _, field := range params.List {
+		if field.Type == nil {
+			t.errorf(x.Async, "cannot determine the parameter types of %s for async", t.sourceText(call.Fun))
+			return x.X
+		}
+	}
+
+	var start ast.Expr
+	if errorOnly {
+		start = t.asyncCall("Do0", asyncFuncLit(nil, inner))
+		// позиции скобок на одной строке: печатается struct{}
+		result = &ast.StructType{Fields: &ast.FieldList{Opening: x.Async, Closing: x.Async}}
+	} else {
+		start = t.asyncCall("Go", asyncFuncLit(result, inner))
+	}
+	future := &ast.StarExpr{X: &ast.IndexExpr{
+		X: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(asyncImportPath)},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: "Future"},
+		},
+		Index: result,
+	}}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{Params: params, Results: &ast.FieldList{List: []*ast.Field{{Type: future}}}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{start}}}},
+		},
+		Args: bound,
+	}
+}
+
+// asyncFuncLit строит func() (result, error) { return call } или,
+// если result == nil, func() error { return call }.
+func asyncFuncLit(result ast.Expr, call ast.Expr) *ast.FuncLit {
+	results := &ast.FieldList{}
+	if result != nil {
+		results.List = append(results.List, &ast.Field{Type: result})
+	}
+	results.List = append(results.List, &ast.Field{Type: &ast.Ident{NamePos: token.NoPos, Name: "error"}})
+	return &ast.FuncLit{
+		Type: &ast.FuncType{Params: &ast.FieldList{}, Results: results},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{call}}}},
+	}
+}
+
+// asyncParamType возвращает тип i-го аргумента вызова функции с
+// сигнатурой sig с учётом вариативного хвоста.
+func asyncParamType(sig *types.Signature, i int) types.Type {
+	params := sig.Params()
+	if sig.Variadic() && i >= params.Len()-1 {
+		return params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+	}
+	if i >= params.Len() {
+		return nil
+	}
+	return params.At(i).Type()
+}
+
+// isStableFunc сообщает, можно ли вычислить выражение функции внутри
+// горутины: имя функции или функция импортированного пакета (pkg.F).
+func isStableFunc(fun ast.Expr) bool {
+	switch fn := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		return ok && x.Obj == nil
+	}
+	return false
+}
+
+// transpileAwaitExpr транспилирует await-выражение:
+//
+//	await f   → f.Await()
+//	await fs  → async.All(fs)   // fs — срез фьючерсов
+func (t *Transpiler) transpileAwaitExpr(x *ast.AwaitExpr) ast.Expr {
+	futures := t.probe(x.X, t.transpileExpr(x.X))
+	if t.isFutureSlice(x.X) {
+		return t.asyncCall("All", futures)
+	}
+	return &ast.CallExpr{Fun: &ast.SelectorExpr{
+		X:   futures,
+		Sel: &ast.Ident{NamePos: token.NoPos, Name: "Await"},
+	}}
+}
+
+// transpileFuncLit транспилирует тело функционального литерала.
+// ? и throw в нём возвращают ошибку из самого литерала, assert в
+// подтесте (func(t *testing.T) { ... }) использует его параметр.
+func (t *Transpiler) transpileFuncLit(fn *ast.FuncLit) *ast.FuncLit {
+	prevTarget, prevHint, prevTest := t.errTarget, t.returnTypeHint, t.testName
+	t.errTarget, t.returnTypeHint = nil, extractFirstReturnType(fn.Type)
+	if name := testingParamName(fn.Type, t.importName("testing")); name != "" {
+		t.testName = name
+	}
+	defer func() { t.errTarget, t.returnTypeHint, t.testName = prevTarget, prevHint, prevTest }()
+
+	return &ast.FuncLit{
+		Type: fn.Type,
+		Body: &ast.BlockStmt{
+			Lbrace: fn.Body.Lbrace,
+			List:   t.transpileStmts(fn.Body.List),
+			Rbrace: fn.Body.Rbrace,
+		},
+	}
+}
+
+// asyncCall строит вызов async.<name>(args...) и добавляет импорт пакета.
+func (t *Transpiler) asyncCall(name string, args ...ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(asyncImportPath)},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: name},
+		},
+		Args: args,
+	}
+}
+
+// returnsOnlyError сообщает, известно ли, что fun возвращает только error:
+// по сигнатуре из go/types, а без неё — по объявлению в файле.
+func (t *Transpiler) returnsOnlyError(fun ast.Expr) bool {
+	if sig, ok := t.typeOf(fun).(*types.Signature); ok {
+		return sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), types.Universe.Lookup("error").Type())
+	}
+	switch fn := ast.Unparen(fun).(type) {
+	case *ast.Ident:
+		return t.errorOnlyFuncs[fn.Name]
+	case *ast.FuncLit:
+		return isErrorOnlyFunc(fn.Type)
+	}
+	return false
+}
+
+// isFutureSlice сообщает, является ли выражение срезом фьючерсов: по типу
+// из go/types, а без него — переменной-срезом, срезом среза, append или
+// литералом среза.
+func (t *Transpiler) isFutureSlice(expr ast.Expr) bool {
+	if typ := t.typeOf(expr); typ != nil {
+		_, ok := typ.Underlying().(*types.Slice)
+		return ok
+	}
+	switch x := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return t.futureSlices[x.Name]
+	case *ast.SliceExpr:
+		return true
+	case *ast.CompositeLit:
+		_, ok := x.Type.(*ast.ArrayType)
+		return ok
+	case *ast.CallExpr:
+		ident, ok := x.Fun.(*ast.Ident)
+		return ok && ident.Name == "append"
+	}
+	return false
+}
+
+// isErrorOnlyFunc сообщает, возвращает ли функция ровно один результат error.
+func isErrorOnlyFunc(funcType *ast.FuncType) bool {
+	if funcType.Results == nil || funcType.Results.NumFields() != 1 {
+		return false
+	}
+	ident, ok := funcType.Results.List[0].Type.(*ast.Ident)
+	return ok && ident.Name == "error"
+}
+
+// collectErrorOnlyFuncs возвращает функции верхнего уровня файла,
+// возвращающие только error.
+func collectErrorOnlyFuncs(file *ast.File) map[string]bool {
+	funcs := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && isErrorOnlyFunc(fn.Type) {
+			funcs[fn.Name.Name] = true
+		}
+	}
+	return funcs
+}
+
+// collectFutureSlices возвращает имена переменных функции, объявленных
+// срезами: параметры и var с типом []T, x := make([]T, …) и x := []T{…}.
+// await для таких переменных ждёт все фьючерсы среза.
+func collectFutureSlices(funcDecl *ast.FuncDecl) map[string]bool {
+	slices := make(map[string]bool)
+	isSlice := func(expr ast.Expr) bool {
+		arr, ok := expr.(*ast.ArrayType)
+		return ok && arr.Len == nil
+	}
+	ast.Inspect(funcDecl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			if isSlice(n.Type) {
+				for _, name := range n.Names {
+					slices[name.Name] = true
+				}
+			}
+		case *ast.ValueSpec:
+			if isSlice(n.Type) {
+				for _, name := range n.Names {
+					slices[name.Name] = true
+				}
+			}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE || len(n.Lhs) != len(n.Rhs) {
+				break
+			}
+			for i, rhs := range n.Rhs {
+				var typ ast.Expr
+				switch r := rhs.(type) {
+				case *ast.CompositeLit:
+					typ = r.Type
+				case *ast.CallExpr:
+					if fun, ok := r.Fun.(*ast.Ident); ok && fun.Name == "make" && len(r.Args) > 0 {
+						typ = r.Args[0]
+					}
+				}
+				if ident, ok := n.Lhs[i].(*ast.Ident); ok && typ != nil && isSlice(typ) {
+					slices[ident.Name] = true
+				}
+			}
+		}
+		return true
+	})
+	return slices
+}
@@ -0,0 +1,193 @@
+package transpiler
+
+import (
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// catchChain строит обработку ошибки цепочкой catch-блоков — операторы
+// внутри if err != nil { ... }. Блоки с типами становятся ветками одного
+// type switch, catch без типа — веткой default:
+//
+//	} catch(ErrTimeout | ErrNetwork) {
+//	    ...
+//	} catch(e ErrDisk) {
+//	    ...
+//	} catch {
+//	    ...
+//	}
+//
+// →
+//
+//	switch e := err.(type) {
+//	case ErrTimeout, ErrNetwork:
+//	    ...
+//	case ErrDisk:
+//	    ...
+//	default:
+//	    ...
+//	}
+//
+// В ветке с одним типом переменная catch имеет этот тип, с несколькими —
+// тип error. Ошибка, не подошедшая ни к
+// одному типу, без catch без типа не обрабатывается. body преобразует
+// тело блока (в IIFE finally return заменяется на return true).
+func (t *Transpiler) catchChain(catches []*ast.CatchStmt, body func([]ast.Stmt) []ast.Stmt) []ast.Stmt {
+	var typed []*ast.CatchStmt
+	var catchAll *ast.CatchStmt
+	for _, c := range catches {
+		if len(c.ErrorTypes) == 0 {
+			catchAll = c // catch без типа должен быть последним
+			break
+		}
+		typed = append(typed, c)
+	}
+	if len(typed) == 0 {
+		return body(catchAll.Body.List)
+	}
+	for _, c := range typed {
+		if breaksOut(c.Body.List) {
+			return []ast.Stmt{t.catchIfChain(typed, catchAll, body)}
+		}
+	}
+
+	binding := catchBinding(typed, catchAll)
+	var clauses []ast.Stmt
+	for _, c := range typed {
+		var list []ast.Stmt
+		if hasCatchVar(c) && c.ErrorVar.Name != binding {
+			list = append(list, coalesceAssign(
+				&ast.Ident{NamePos: token.NoPos, Name: c.ErrorVar.Name},
+				token.DEFINE,
+				&ast.Ident{NamePos: token.NoPos, Name: binding},
+			))
+		}
+		clauses = append(clauses, &ast.CaseClause{
+			Case:  c.Catch,
+			List:  c.ErrorTypes,
+			Colon: token.NoPos,
+			Body:  append(list, body(c.Body.List)...),
+		})
+	}
+	if catchAll != nil {
+		clauses = append(clauses, &ast.CaseClause{Case: catchAll.Catch, Colon: token.NoPos, Body: body(catchAll.Body.List)})
+	}
+
+	var assign ast.Stmt = &ast.ExprStmt{X: &ast.TypeAssertExpr{X: t.catchSubject()}}
+	if binding != "" {
+		assign = &ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: binding}},
+			TokPos: token.NoPos,
+			Tok:    token.DEFINE,
+			Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: t.catchSubject()}},
+		}
+	}
+	return []ast.Stmt{&ast.TypeSwitchStmt{
+		Switch: token.NoPos,
+		Assign: assign,
+		Body:   &ast.BlockStmt{Lbrace: token.NoPos, List: clauses, Rbrace: token.NoPos},
+	}}
+}
+
+// catchBinding возвращает имя переменной, которую объявляет type switch:
+// общее имя переменной блоков с типами. Если блоки называют переменную
+// по-разному или имя упоминается в блоке без переменной (switch скрыл бы
+// внешнюю переменную с тем же именем), switch объявляет _godslCatch, а
+// ветки — свои переменные из неё. Пустая строка — переменных нет.
+func catchBinding(typed []*ast.CatchStmt, catchAll *ast.CatchStmt) string {
+	name := ""
+	shared := true
+	var others []ast.Node
+	for _, c := range typed {
+		switch {
+		case !hasCatchVar(c):
+			others = append(others, c.Body)
+		case name == "":
+			name = c.ErrorVar.Name
+		case c.ErrorVar.Name != name:
+			shared = false
+		}
+	}
+	if catchAll != nil {
+		others = append(others, catchAll.Body)
+	}
+	if name != "" && (!shared || usesIdent(name, others...)) {
+		return "_godslCatch"
+	}
+	return name
+}
+
+// hasCatchVar сообщает, привязывает ли блок ошибку к переменной.
+func hasCatchVar(c *ast.CatchStmt) bool {
+	return c.ErrorVar != nil && c.ErrorVar.Name != "_"
+}
+
+// catchVar объявляет переменную блока в ветке if: e := err.(T) для одного
+// типа и e := err для нескольких.
+func (t *Transpiler) catchVar(c *ast.CatchStmt) ast.Stmt {
+	value := t.catchSubject()
+	if len(c.ErrorTypes) == 1 {
+		value = &ast.TypeAssertExpr{X: value, Type: c.ErrorTypes[0]}
+	}
+	return coalesceAssign(&ast.Ident{NamePos: token.NoPos, Name: c.ErrorVar.Name}, token.DEFINE, value)
+}
+
+// catchIfChain строит цепочку if/else if вместо type switch, когда блок
+// содержит break: внутри switch он выходил бы из switch, а не из цикла.
+// Блок с несколькими типами повторяется для каждого из них.
+func (t *Transpiler) catchIfChain(typed []*ast.CatchStmt, catchAll *ast.CatchStmt, body func([]ast.Stmt) []ast.Stmt) ast.Stmt {
+	var first, last *ast.IfStmt
+	for _, c := range typed {
+		for _, typ := range c.ErrorTypes {
+			lhs := ast.Expr(&ast.Ident{NamePos: token.NoPos, Name: "_"})
+			var list []ast.Stmt
+			switch {
+			case hasCatchVar(c) && len(c.ErrorTypes) == 1:
+				lhs = &ast.Ident{NamePos: token.NoPos, Name: c.ErrorVar.Name}
+			case hasCatchVar(c):
+				list = append(list, t.catchVar(c))
+			}
+			s := &ast.IfStmt{
+				If: token.NoPos,
+				Init: &ast.AssignStmt{
+					Lhs:    []ast.Expr{lhs, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
+					TokPos: token.NoPos,
+					Tok:    token.DEFINE,
+					Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: t.catchSubject(), Type: typ}},
+				},
+				Cond: &ast.Ident{NamePos: token.NoPos, Name: "ok"},
+				Body: &ast.BlockStmt{Lbrace: token.NoPos, List: append(list, body(c.Body.List)...), Rbrace: token.NoPos},
+			}
+			if first == nil {
+				first = s
+			} else {
+				last.Else = s
+			}
+			last = s
+		}
+	}
+	if catchAll != nil {
+		last.Else = &ast.BlockStmt{Lbrace: token.NoPos, List: body(catchAll.Body.List), Rbrace: token.NoPos}
+	}
+	return first
+}
+
+// breaksOut сообщает, есть ли в операторах break без метки, который
+// относится не к вложенному циклу, switch или select.
+func breaksOut(stmts []ast.Stmt) bool {
+	found := false
+	for _, s := range stmts {
+		ast.Inspect(s, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				return false
+			case *ast.BranchStmt:
+				if n.Tok == token.BREAK && n.Label == nil {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
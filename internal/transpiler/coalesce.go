@@ -0,0 +1,405 @@
+package transpiler
+
+import (
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+const zeroImportPath = "github.com/sviridovkonstantin42/godsl/runtime/zero"
+
+// zeroKind определяет, как проверить левый операнд ?? на нулевое значение.
+type zeroKind int
+
+const (
+	zeroUnknown zeroKind = iota // zero.Is(v)
+	zeroNil                     // v == nil
+	zeroString                  // v == ""
+	zeroNumber                  // v == 0
+	zeroBool                    // !v
+)
+
+// transpileCoalesceAssign разворачивает присваивание с ?? без IIFE:
+//
+//	name := user.Nickname ?? user.Login
+//
+// →
+//
+//	name := user.Nickname
+//	if name == "" {
+//	    name = user.Login
+//	}
+//
+// Правая часть вычисляется только если левая нулевая. Для *p ?? def
+// проверяется p == nil, а разыменование выполняется только для не-nil p.
+func (t *Transpiler) transpileCoalesceAssign(lhs ast.Expr, tok token.Token, c *ast.CoalesceExpr) []ast.Stmt {
+	lhs = t.transpileExpr(lhs)
+	if t.probing {
+		return []ast.Stmt{coalesceAssign(lhs, tok, t.probeCoalesce(c))}
+	}
+
+	if star, ok := ast.Unparen(c.X).(*ast.StarExpr); ok {
+		ptr := t.transpileExpr(star.X)
+		if !isSimpleRef(ptr) {
+			// Указатель с побочными эффектами нельзя вычислять дважды.
+			return []ast.Stmt{coalesceAssign(lhs, tok, t.zeroCall("DerefOr", ptr, t.transpileExpr(c.Y)))}
+		}
+		if tok == token.ASSIGN {
+			// if p != nil { lhs = *p } else { lhs = def }
+			check := &ast.IfStmt{
+				If:   token.NoPos,
+				Cond: t.zeroCheck(ptr, zeroNil, false),
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					coalesceAssign(lhs, token.ASSIGN, &ast.StarExpr{Star: token.NoPos, X: ptr}),
+				}},
+			}
+			// x = *p ?? x: ветка else присваивала бы x самой себе.
+			if !t.sameRef(lhs, c.Y) {
+				check.Else = &ast.BlockStmt{List: t.coalesceDefault(lhs, c.Y)}
+			}
+			return []ast.Stmt{check}
+		}
+		// lhs := zero.Deref(p); if p == nil { lhs = def }
+		return []ast.Stmt{
+			coalesceAssign(lhs, tok, t.zeroCall("Deref", ptr)),
+			&ast.IfStmt{
+				If:   token.NoPos,
+				Cond: t.zeroCheck(ptr, zeroNil, true),
+				Body: &ast.BlockStmt{List: t.coalesceDefault(lhs, c.Y)},
+			},
+		}
+	}
+
+	kind := t.coalesceKindOf(c)
+	if tok == token.ASSIGN && t.sameRef(lhs, c.Y) {
+		// x = v ?? x: x меняется, только если v не нулевое.
+		val := &ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}
+		return []ast.Stmt{&ast.IfStmt{
+			If:   token.NoPos,
+			Init: coalesceAssign(val, token.DEFINE, t.transpileExpr(c.X)),
+			Cond: t.zeroCheck(val, kind, false),
+			Body: &ast.BlockStmt{List: []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, val)}},
+		}}
+	}
+	if root := refRoot(lhs); tok == token.ASSIGN && root != nil && usesIdent(root.Name, c.Y) {
+		// Значение по умолчанию читает lhs, поэтому lhs нельзя перезаписать
+		// до проверки: левый операнд сохраняется во временной переменной.
+		val := &ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}
+		return []ast.Stmt{&ast.IfStmt{
+			If:   token.NoPos,
+			Init: coalesceAssign(val, token.DEFINE, t.transpileExpr(c.X)),
+			Cond: t.zeroCheck(val, kind, true),
+			Body: &ast.BlockStmt{List: t.coalesceDefault(lhs, c.Y)},
+			Else: &ast.BlockStmt{List: []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, val)}},
+		}}
+	}
+	check := &ast.IfStmt{
+		If:   token.NoPos,
+		Cond: t.zeroCheck(lhs, kind, true),
+		Body: &ast.BlockStmt{List: t.coalesceDefault(lhs, c.Y)},
+	}
+	if tok == token.ASSIGN && t.sameRef(lhs, c.X) {
+		// x = x ?? def: присваивание x самой себе не нужно.
+		return []ast.Stmt{check}
+	}
+	if hasOptChain(c.X) {
+		// a?.B ?? def: тип переменной при необходимости берётся из литерала def.
+		return append(t.transpileOptChainAssign(lhs, tok, c.X, inferLiteralType(c.Y)), check)
+	}
+	return []ast.Stmt{coalesceAssign(lhs, tok, t.transpileExpr(c.X)), check}
+}
+
+// coalesceDefault присваивает lhs значение по умолчанию; цепочка
+// a ?? b ?? c разворачивается во вложенные проверки.
+func (t *Transpiler) coalesceDefault(lhs ast.Expr, def ast.Expr) []ast.Stmt {
+	if c, ok := ast.Unparen(def).(*ast.CoalesceExpr); ok {
+		return t.transpileCoalesceAssign(lhs, token.ASSIGN, c)
+	}
+	return []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, t.transpileExpr(def))}
+}
+
+// transpileCoalesceReturn разворачивает return с ?? без IIFE:
+//
+//	return name ?? "anonymous"
+//
+// →
+//
+//	if _godslVal := name; _godslVal != "" {
+//	    return _godslVal
+//	}
+//	return "anonymous"
+func (t *Transpiler) transpileCoalesceReturn(c *ast.CoalesceExpr) []ast.Stmt {
+	if t.probing {
+		return []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{t.probeCoalesce(c)}}}
+	}
+	val := &ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}
+	var check *ast.IfStmt
+	if star, ok := ast.Unparen(c.X).(*ast.StarExpr); ok {
+		// if _godslPtr := p; _godslPtr != nil { return *_godslPtr }
+		ptr := &ast.Ident{NamePos: token.NoPos, Name: "_godslPtr"}
+		check = &ast.IfStmt{
+			If:   token.NoPos,
+			Init: coalesceAssign(ptr, token.DEFINE, t.transpileExpr(star.X)),
+			Cond: t.zeroCheck(ptr, zeroNil, false),
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{
+				Return:  token.NoPos,
+				Results: []ast.Expr{&ast.StarExpr{Star: token.NoPos, X: ptr}},
+			}}},
+		}
+	} else {
+		check = &ast.IfStmt{
+			If:   token.NoPos,
+			Init: coalesceAssign(val, token.DEFINE, t.transpileExpr(c.X)),
+			Cond: t.zeroCheck(val, t.coalesceKindOf(c), false),
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{
+				Return:  token.NoPos,
+				Results: []ast.Expr{val},
+			}}},
+		}
+	}
+
+	if next, ok := ast.Unparen(c.Y).(*ast.CoalesceExpr); ok {
+		return append([]ast.Stmt{check}, t.transpileCoalesceReturn(next)...)
+	}
+	return []ast.Stmt{check, &ast.ReturnStmt{
+		Return:  token.NoPos,
+		Results: []ast.Expr{t.transpileExpr(c.Y)},
+	}}
+}
+
+// transpileCoalesceExpr транспилирует ?? внутри произвольного выражения.
+// Если правая часть не имеет побочных эффектов, используется вызов
+// zero.Or(x, def) или zero.DerefOr(p, def); иначе — IIFE, чтобы
+// сохранить ленивое вычисление правой части:
+//
+//	func() T {
+//	    if _godslVal := x; _godslVal != nil {
+//	        return _godslVal
+//	    }
+//	    return def()
+//	}()
+//
+// T — общий тип операндов по данным go/types, без них — тип литералов
+// операндов или any.
+func (t *Transpiler) transpileCoalesceExpr(c *ast.CoalesceExpr) ast.Expr {
+	if t.probing {
+		return t.probeCoalesce(c)
+	}
+	if isPureExpr(c.Y) {
+		if star, ok := ast.Unparen(c.X).(*ast.StarExpr); ok {
+			return t.zeroCall("DerefOr", t.transpileExpr(star.X), t.transpileExpr(c.Y))
+		}
+		return t.zeroCall("Or", t.transpileExpr(c.X), t.transpileExpr(c.Y))
+	}
+
+	retType := t.typeExpr(t.typeOf(c))
+	if retType == nil {
+		retType = literalBranchType(c.X, c.Y)
+	}
+	if retType == nil {
+		t.requireResolved(c)
+		retType = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+	}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    token.NoPos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: retType}}},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   t.transpileCoalesceReturn(c),
+				Rbrace: token.NoPos,
+			},
+		},
+	}
+}
+
+// probeCoalesce строит форму x ?? y для пробного прохода:
+// _godslTernary(true, x, y) даёт общий тип операндов с типом констант по
+// умолчанию, а x отдельно обёрнут маркером для выбора проверки на ноль.
+func (t *Transpiler) probeCoalesce(c *ast.CoalesceExpr) ast.Expr {
+	return t.probe(c, &ast.CallExpr{
+		Fun: &ast.Ident{NamePos: token.NoPos, Name: probeTernaryName},
+		Args: []ast.Expr{
+			&ast.Ident{NamePos: token.NoPos, Name: "true"},
+			t.probe(c.X, t.transpileExpr(c.X)),
+			t.transpileExpr(c.Y),
+		},
+	})
+}
+
+// coalesceKindOf выбирает проверку левого операнда ?? по его типу из
+// go/types. Синтаксис операндов (coalesceKind) используется, только если
+// тип неизвестен.
+func (t *Transpiler) coalesceKindOf(c *ast.CoalesceExpr) zeroKind {
+	if typ := t.typeOf(c.X); typ != nil {
+		return zeroKindOf(typ)
+	}
+	return coalesceKind(c)
+}
+
+// zeroCheck строит проверку v на нулевое значение (или на ненулевое,
+// если isZero == false).
+func (t *Transpiler) zeroCheck(v ast.Expr, kind zeroKind, isZero bool) ast.Expr {
+	op := token.EQL
+	if !isZero {
+		op = token.NEQ
+	}
+	var y ast.Expr
+	switch kind {
+	case zeroNil:
+		y = &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	case zeroString:
+		y = &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: `""`}
+	case zeroNumber:
+		y = &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}
+	case zeroBool:
+		if isZero {
+			return &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: v}
+		}
+		return v
+	default:
+		call := t.zeroCall("Is", v)
+		if isZero {
+			return call
+		}
+		return &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: call}
+	}
+	return &ast.BinaryExpr{X: v, OpPos: token.NoPos, Op: op, Y: y}
+}
+
+// zeroCall строит вызов zero.<name>(args...) и добавляет импорт пакета.
+func (t *Transpiler) zeroCall(name string, args ...ast.Expr) ast.Expr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(zeroImportPath)},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: name},
+		},
+		Args: args,
+	}
+}
+
+// coalesceKind выводит вид нулевого значения по операндам цепочки ??:
+// литерал "" или строка → сравнение с "", число → с 0, nil, make, new,
+// &T{} и литералы срезов/карт → с nil. Если вывести не удалось, проверка
+// выполняется через zero.Is.
+func coalesceKind(c *ast.CoalesceExpr) zeroKind {
+	for _, operand := range []ast.Expr{c.X, c.Y} {
+		if next, ok := ast.Unparen(operand).(*ast.CoalesceExpr); ok {
+			if kind := coalesceKind(next); kind != zeroUnknown {
+				return kind
+			}
+			continue
+		}
+		if kind := operandZeroKind(operand); kind != zeroUnknown {
+			return kind
+		}
+	}
+	return zeroUnknown
+}
+
+// operandZeroKind выводит вид нулевого значения одного операнда по синтаксису.
+func operandZeroKind(expr ast.Expr) zeroKind {
+	if typ, ok := inferLiteralType(expr).(*ast.Ident); ok {
+		switch typ.Name {
+		case "string":
+			return zeroString
+		case "bool":
+			return zeroBool
+		default:
+			return zeroNumber
+		}
+	}
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		if e.Name == "nil" {
+			return zeroNil
+		}
+	case *ast.CompositeLit:
+		switch typ := e.Type.(type) {
+		case *ast.ArrayType:
+			if typ.Len == nil {
+				return zeroNil
+			}
+		case *ast.MapType:
+			return zeroNil
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return zeroNil
+		}
+	case *ast.FuncLit:
+		return zeroNil
+	case *ast.CallExpr:
+		if fun, ok := e.Fun.(*ast.Ident); ok && (fun.Name == "make" || fun.Name == "new") {
+			return zeroNil
+		}
+	}
+	return zeroUnknown
+}
+
+// isPureExpr сообщает, что выражение можно вычислить заранее: в нём нет
+// вызовов функций и чтения из каналов.
+func isPureExpr(expr ast.Expr) bool {
+	pure := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false // тело литерала не выполняется при вычислении
+		case *ast.CallExpr, *ast.AsyncExpr, *ast.AwaitExpr, *ast.TernaryExpr, *ast.FallbackExpr:
+			pure = false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				pure = false
+			}
+		}
+		return pure
+	})
+	return pure
+}
+
+// isSimpleRef сообщает, является ли выражение именем или цепочкой
+// селекторов (p, u.Profile), которые можно вычислять повторно.
+func isSimpleRef(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return isSimpleRef(e.X)
+	}
+	return false
+}
+
+// sameRef сообщает, обращаются ли lhs (уже транспилированный) и x к одной
+// и той же переменной или полю.
+func (t *Transpiler) sameRef(lhs, x ast.Expr) bool {
+	x = ast.Unparen(x)
+	return isSimpleRef(lhs) && isSimpleRef(x) && t.nodeString(lhs) == t.nodeString(x)
+}
+
+// refRoot возвращает переменную, с которой начинается ссылка x (a, a.b,
+// a[i], *a), или nil.
+func refRoot(x ast.Expr) *ast.Ident {
+	switch x := ast.Unparen(x).(type) {
+	case *ast.Ident:
+		return x
+	case *ast.SelectorExpr:
+		return refRoot(x.X)
+	case *ast.IndexExpr:
+		return refRoot(x.X)
+	case *ast.StarExpr:
+		return refRoot(x.X)
+	}
+	return nil
+}
+
+// coalesceAssign строит lhs <tok> rhs.
+func coalesceAssign(lhs ast.Expr, tok token.Token, rhs ast.Expr) *ast.AssignStmt {
+	return &ast.AssignStmt{
+		Lhs:    []ast.Expr{lhs},
+		TokPos: token.NoPos,
+		Tok:    tok,
+		Rhs:    []ast.Expr{rhs},
+	}
+}
@@ -0,0 +1,253 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// transpileComprehension разворачивает генератор среза или мапы в IIFE с
+// циклом for ... in:
+//
+//	names := append(prefix, [u.Name for u in users if u.Active]...)
+//
+// →
+//
+//	names := append(prefix, func() []string {
+//	    _godslOut := make([]string, 0, len(users))
+//	    for _, u := range users {
+//	        if u.Active {
+//	            _godslOut = append(_godslOut, u.Name)
+//	        }
+//	    }
+//	    return _godslOut
+//	}()...)
+//
+// Типы элемента и ключа выводятся пробным проходом из выражений генератора.
+// Если коллекция — имя или цепочка селекторов среза, массива, строки или
+// мапы, результат заранее выделяется под len(коллекции), а для
+// целочисленного диапазона без шага — под число его элементов.
+func (t *Transpiler) transpileComprehension(c *ast.ComprehensionExpr) ast.Expr {
+	out := &ast.Ident{NamePos: token.NoPos, Name: "_godslOut"}
+	resultType, stmts := t.comprehensionStmts(c, out, token.DEFINE)
+	if resultType == nil {
+		return c
+	}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    token.NoPos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: resultType}}},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   append(stmts, &ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{out}}),
+				Rbrace: token.NoPos,
+			},
+		},
+	}
+}
+
+// transpileComprehensionAssign разворачивает присваивание генератора в
+// операторы без IIFE: результат сразу собирается в переменную слева.
+//
+//	names := [u.Name for u in users if u.Active]
+//
+// →
+//
+//	names := make([]string, 0, len(users))
+//	for _, u := range users {
+//	    if u.Active {
+//	        names = append(names, u.Name)
+//	    }
+//	}
+//
+// Если слева не имя или генератор сам использует это имя, используется IIFE.
+func (t *Transpiler) transpileComprehensionAssign(lhs ast.Expr, tok token.Token, c *ast.ComprehensionExpr) []ast.Stmt {
+	name, ok := lhs.(*ast.Ident)
+	if !ok || name.Name == "_" || usesIdent(name.Name, c) {
+		return []ast.Stmt{coalesceAssign(t.transpileExpr(lhs), tok, t.transpileComprehension(c))}
+	}
+	if _, stmts := t.comprehensionStmts(c, name, tok); stmts != nil {
+		return stmts
+	}
+	return []ast.Stmt{coalesceAssign(lhs, tok, c)}
+}
+
+// comprehensionStmts строит операторы, собирающие результат генератора в
+// out: объявление (tok == DEFINE) или присваивание начального значения и
+// цикл. Возвращает тип результата или nil, если его не удалось вывести.
+func (t *Transpiler) comprehensionStmts(c *ast.ComprehensionExpr, out *ast.Ident, tok token.Token) (ast.Expr, []ast.Stmt) {
+	value := t.probe(c.Value, c.Value)
+
+	var resultType ast.Expr
+	var add ast.Stmt
+	if c.Key == nil {
+		elem := t.comprehensionType(c.Value)
+		if elem == nil {
+			t.errorf(c.Value.Pos(), "cannot infer element type of comprehension")
+			return nil, nil
+		}
+		resultType = &ast.ArrayType{Lbrack: token.NoPos, Elt: elem}
+		add = coalesceAssign(out, token.ASSIGN, &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: "append"},
+			Args: []ast.Expr{out, value},
+		})
+	} else {
+		key, elem := t.comprehensionType(c.Key), t.comprehensionType(c.Value)
+		switch {
+		case key == nil:
+			t.errorf(c.Key.Pos(), "cannot infer key type of comprehension")
+			return nil, nil
+		case elem == nil:
+			t.errorf(c.Value.Pos(), "cannot infer element type of comprehension")
+			return nil, nil
+		}
+		resultType = &ast.MapType{Map: token.NoPos, Key: key, Value: elem}
+		add = coalesceAssign(&ast.IndexExpr{X: out, Index: t.probe(c.Key, c.Key)}, token.ASSIGN, value)
+	}
+
+	if c.Cond != nil {
+		add = &ast.IfStmt{If: token.NoPos, Cond: c.Cond, Body: &ast.BlockStmt{List: []ast.Stmt{add}}}
+	}
+	loop := t.transpileForIn(&ast.ForStmt{
+		For:  token.NoPos,
+		In:   c.In,
+		Body: &ast.BlockStmt{Lbrace: token.NoPos, List: []ast.Stmt{add}, Rbrace: token.NoPos},
+	})
+
+	var init ast.Stmt
+	var size ast.Expr
+	if r, ok := c.In.X.(*ast.RangeExpr); ok {
+		size = t.rangeLen(r)
+	} else if t.hasCheapLen(c.In) {
+		size = &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: "len"},
+			Args: []ast.Expr{t.transpileExpr(c.In.X)},
+		}
+	}
+	switch {
+	case c.Key != nil:
+		args := []ast.Expr{resultType}
+		if size != nil {
+			args = append(args, size)
+		}
+		init = coalesceAssign(out, tok, &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: "make"},
+			Args: args,
+		})
+	case size != nil:
+		init = coalesceAssign(out, tok, &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: "make"},
+			Args: []ast.Expr{resultType, &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}, size},
+		})
+	case tok == token.DEFINE:
+		// Без известной длины срез начинается с nil и растёт через append.
+		init = &ast.DeclStmt{Decl: &ast.GenDecl{
+			TokPos: token.NoPos,
+			Tok:    token.VAR,
+			Specs:  []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{out}, Type: resultType}},
+		}}
+	default:
+		init = coalesceAssign(out, tok, &ast.Ident{NamePos: token.NoPos, Name: "nil"})
+	}
+	return resultType, []ast.Stmt{init, loop}
+}
+
+// comprehensionType возвращает тип выражения генератора. В пробном
+// проходе берётся тип из предыдущего прохода, а в первом — any.
+func (t *Transpiler) comprehensionType(x ast.Expr) ast.Expr {
+	if !t.probing {
+		return t.typeExpr(t.typeOf(x))
+	}
+	if typ := t.typeExpr(t.prevTypes[x]); typ != nil {
+		return typ
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: "any"}
+}
+
+// hasCheapLen сообщает, можно ли заранее вычислить число элементов
+// коллекции генератора: len от имени среза, массива, строки или мапы.
+// Диапазоны, каналы и вызовы функций не предвыделяются.
+func (t *Transpiler) hasCheapLen(in *ast.InClause) bool {
+	if !isSimpleRef(in.X) {
+		return false
+	}
+	typ := t.typeOf(in)
+	if typ == nil {
+		return false
+	}
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		_, isArray := ptr.Elem().Underlying().(*types.Array)
+		return isArray
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Slice, *types.Array, *types.Map:
+		return true
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	}
+	return false
+}
+
+// rangeLen возвращает число элементов целочисленного диапазона без шага
+// для предвыделения результата генератора или nil. Границы должны быть
+// литералами или именами, чтобы их можно было вычислить ещё раз; для
+// литералов число вычисляется сразу, иначе — max(high-low, 0).
+func (t *Transpiler) rangeLen(r *ast.RangeExpr) ast.Expr {
+	if r.Step != nil || !types.Identical(t.typeOf(r), types.Typ[types.Int]) {
+		return nil
+	}
+	for _, bound := range []ast.Expr{r.Low, r.High} {
+		if !isConstLit(bound) && !isSimpleRef(bound) {
+			return nil
+		}
+	}
+	extra := int64(0)
+	if r.Op == token.RANGE_INCL {
+		extra = 1
+	}
+	low, lowOK := intLit(r.Low)
+	high, highOK := intLit(r.High)
+	if lowOK && highOK {
+		if n := high - low + extra; n > 0 {
+			return &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: strconv.FormatInt(n, 10)}
+		}
+		return nil
+	}
+	var n ast.Expr = r.High
+	if !lowOK || low != 0 {
+		n = &ast.BinaryExpr{X: r.High, Op: token.SUB, Y: r.Low}
+	}
+	if extra > 0 {
+		n = &ast.BinaryExpr{X: n, Op: token.ADD, Y: &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "1"}}
+	}
+	return &ast.CallExpr{
+		Fun:  &ast.Ident{NamePos: token.NoPos, Name: "max"},
+		Args: []ast.Expr{n, &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}},
+	}
+}
+
+// intLit возвращает значение целочисленного литерала, возможно со знаком.
+func intLit(e ast.Expr) (int64, bool) {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.INT {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(x.Value, 0, 64)
+		return n, err == nil
+	case *ast.UnaryExpr:
+		n, ok := intLit(x.X)
+		switch x.Op {
+		case token.SUB:
+			return -n, ok
+		case token.ADD:
+			return n, ok
+		}
+	}
+	return 0, false
+}
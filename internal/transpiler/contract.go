@@ -0,0 +1,154 @@
+package transpiler
+
+import (
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// contractImportPath — рантайм-пакет godsl для клауз requires и ensures.
+const contractImportPath = "github.com/sviridovkonstantin42/godsl/runtime/contract"
+
+// transpileContracts разворачивает клаузы requires и ensures функции в
+// проверки в начале тела:
+//
+//	func Transfer(a, b *Acct, amt int) error requires amt > 0 ensures a.Balance >= 0 { ... }
+//
+// →
+//
+//	if contract.Enabled && amt <= 0 {
+//	    contract.Requires("bank.godsl:3", "Transfer", "amt > 0")
+//	}
+//	if contract.Enabled {
+//	    defer func() {
+//	        if a.Balance < 0 {
+//	            contract.Ensures("bank.godsl:3", "Transfer", "a.Balance >= 0")
+//	        }
+//	    }()
+//	}
+//
+// Постусловия проверяются в отложенном блоке, поэтому видят именованные
+// результаты. old(expr) вычисляется при входе в функцию и сохраняется во
+// временной переменной до defer. При сборке с тегом godsl_nocontracts
+// contract.Enabled ложно, и компилятор выбрасывает проверки целиком.
+func (t *Transpiler) transpileContracts(decl *ast.FuncDecl) []ast.Stmt {
+	t.checkRuntimeImport(decl.Contracts[0].Keyword, "requires and ensures", contractImportPath)
+	t.requireImport(contractImportPath)
+
+	var stmts, olds, ensures []ast.Stmt
+	t.oldValues = make(map[*ast.CallExpr]*ast.Ident)
+	defer func() { t.oldValues = nil }()
+
+	for _, c := range decl.Contracts {
+		calls := t.oldCalls(c.Cond)
+		if !c.Ensures {
+			for _, call := range calls {
+				t.errorf(call.Pos(), "old is only allowed in ensures clauses")
+			}
+			stmts = append(stmts, &ast.IfStmt{
+				If:   token.NoPos,
+				Cond: t.contractEnabled(c.Keyword, t.contractFailCond(c)),
+				Body: &ast.BlockStmt{List: []ast.Stmt{t.contractFail(c)}},
+			})
+			continue
+		}
+		for _, call := range calls {
+			name := "_godslOld"
+			if n := len(t.oldValues) + 1; n > 1 {
+				name += strconv.Itoa(n)
+			}
+			v := &ast.Ident{NamePos: token.NoPos, Name: name}
+			olds = append(olds, coalesceAssign(v, token.DEFINE, t.transpileExpr(call.Args[0])))
+			t.oldValues[call] = v
+		}
+		ensures = append(ensures, &ast.IfStmt{
+			If:   token.NoPos,
+			Cond: t.contractFailCond(c),
+			Body: &ast.BlockStmt{List: []ast.Stmt{t.contractFail(c)}},
+		})
+	}
+	if len(ensures) == 0 {
+		return stmts
+	}
+
+	check := &ast.DeferStmt{Defer: token.NoPos, Call: &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{Func: token.NoPos, Params: &ast.FieldList{}},
+			Body: &ast.BlockStmt{List: ensures},
+		},
+	}}
+	return append(stmts, &ast.IfStmt{
+		If:   token.NoPos,
+		Cond: t.contractEnabled(token.NoPos, nil),
+		Body: &ast.BlockStmt{List: append(olds, check)},
+	})
+}
+
+// oldCalls возвращает вызовы old(expr) в условии контракта. Функция или
+// переменная old, объявленная в файле, вызовом old не считается.
+func (t *Transpiler) oldCalls(cond ast.Expr) []*ast.CallExpr {
+	var calls []*ast.CallExpr
+	ast.Inspect(cond, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isOldCall(call) {
+			return true
+		}
+		if len(call.Args) != 1 || call.Ellipsis.IsValid() {
+			t.errorf(call.Pos(), "old takes exactly one argument")
+			return false
+		}
+		ast.Inspect(call.Args[0], func(n ast.Node) bool {
+			if inner, ok := n.(*ast.CallExpr); ok && isOldCall(inner) {
+				t.errorf(inner.Pos(), "old cannot be nested")
+			}
+			return true
+		})
+		calls = append(calls, call)
+		return false
+	})
+	return calls
+}
+
+// isOldCall сообщает, является ли вызов вызовом old(...) контракта.
+func isOldCall(call *ast.CallExpr) bool {
+	id, ok := call.Fun.(*ast.Ident)
+	return ok && id.Name == "old" && id.Obj == nil
+}
+
+// contractFailCond возвращает условие нарушения клаузы.
+func (t *Transpiler) contractFailCond(c *ast.ContractClause) ast.Expr {
+	return t.negateCond(c.Cond)
+}
+
+// contractFail строит вызов contract.Requires или contract.Ensures с
+// позицией клаузы, именем функции и исходным текстом условия.
+func (t *Transpiler) contractFail(c *ast.ContractClause) ast.Stmt {
+	fn := "Requires"
+	if c.Ensures {
+		fn = "Ensures"
+	}
+	str := func(s string) ast.Expr {
+		return &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(s)}
+	}
+	return &ast.ExprStmt{X: &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(contractImportPath)},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: fn},
+		},
+		Args: []ast.Expr{str(t.sourcePos(c.Keyword)), str(t.funcName), str(t.sourceText(c.Cond))},
+	}}
+}
+
+// contractEnabled возвращает contract.Enabled или contract.Enabled && cond.
+// pos держит оба операнда && на одной строке.
+func (t *Transpiler) contractEnabled(pos token.Pos, cond ast.Expr) ast.Expr {
+	enabled := &ast.SelectorExpr{
+		X:   &ast.Ident{NamePos: pos, Name: t.requireImport(contractImportPath)},
+		Sel: &ast.Ident{NamePos: token.NoPos, Name: "Enabled"},
+	}
+	if cond == nil {
+		return enabled
+	}
+	return &ast.BinaryExpr{X: enabled, Op: token.LAND, Y: cond}
+}
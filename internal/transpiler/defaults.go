@@ -0,0 +1,574 @@
+package transpiler
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/parser"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// FuncSignature — сигнатура функции с параметрами по умолчанию. По ней
+// транспилятор разрешает вызовы с именованными и пропущенными аргументами,
+// в том числе из других файлов и пакетов.
+type FuncSignature struct {
+	Params  []FuncParam // параметры по порядку
+	Options bool        // сгенерированы FOptions и DefaultFOptions (экспортируемая необобщённая функция)
+}
+
+// FuncParam — параметр функции в FuncSignature.
+type FuncParam struct {
+	Name    string
+	Default string // значение по умолчанию в синтаксисе godsl; "" — обязательный параметр
+}
+
+// paramIndex возвращает номер параметра по имени или -1.
+func (sig *FuncSignature) paramIndex(name string) int {
+	for i, p := range sig.Params {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CollectSignatures возвращает функции файла с параметрами по умолчанию
+// по именам. generate собирает их по всем файлам проекта и передаёт
+// в Options.Signatures.
+func CollectSignatures(source string) (map[string]*FuncSignature, error) {
+	t := NewTranspiler()
+	file, err := parser.ParseFile(t.fset, "", source, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %v", err)
+	}
+	return t.collectSignatures(file), nil
+}
+
+// collectSignatures возвращает функции верхнего уровня файла, у которых
+// есть параметры по умолчанию.
+func (t *Transpiler) collectSignatures(file *ast.File) map[string]*FuncSignature {
+	sigs := make(map[string]*FuncSignature)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !hasDefaults(fn.Type) {
+			continue
+		}
+		sig := &FuncSignature{
+			Options: token.IsExported(fn.Name.Name) && fn.Type.TypeParams == nil,
+		}
+		for _, f := range fn.Type.Params.List {
+			def := ""
+			if f.Default != nil {
+				def = t.nodeString(f.Default)
+			}
+			for _, n := range f.Names {
+				sig.Params = append(sig.Params, FuncParam{Name: n.Name, Default: def})
+			}
+		}
+		sigs[fn.Name.Name] = sig
+	}
+	return sigs
+}
+
+// packageSignatures дополняет Options.Signatures функциями самого файла:
+// они доступны под ключом "" вместе с функциями других файлов пакета.
+func (t *Transpiler) packageSignatures(file *ast.File) map[string]map[string]*FuncSignature {
+	sigs := make(map[string]map[string]*FuncSignature, len(t.opts.Signatures)+1)
+	for path, funcs := range t.opts.Signatures {
+		sigs[path] = funcs
+	}
+	own := make(map[string]*FuncSignature)
+	for name, sig := range t.opts.Signatures[""] {
+		own[name] = sig
+	}
+	for name, sig := range t.collectSignatures(file) {
+		own[name] = sig
+	}
+	sigs[""] = own
+	return sigs
+}
+
+// hasDefaults сообщает, есть ли у функции параметры по умолчанию.
+func hasDefaults(funcType *ast.FuncType) bool {
+	if funcType.Params == nil {
+		return false
+	}
+	for _, f := range funcType.Params.List {
+		if f.Default != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDefaultParams сообщает об ошибке для значений по умолчанию вне
+// функций верхнего уровня: в методах, литералах функций и типах функций,
+// а также для значений, ссылающихся на другие параметры функции: значение
+// вычисляется в области видимости пакета, где параметров нет.
+func (t *Transpiler) checkDefaultParams(file *ast.File) {
+	funcs := make(map[*ast.FuncType]bool)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			funcs[fn.Type] = true
+			t.checkDefaultRefs(fn.Type)
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ft, ok := n.(*ast.FuncType); ok && !funcs[ft] && hasDefaults(ft) {
+			for _, f := range ft.Params.List {
+				if f.Default != nil {
+					t.errorf(f.Default.Pos(), "default parameter values are only supported in top-level functions")
+					break
+				}
+			}
+		}
+		return true
+	})
+}
+
+// checkDefaultRefs сообщает об ошибке, если значение по умолчанию
+// ссылается на параметр функции.
+func (t *Transpiler) checkDefaultRefs(funcType *ast.FuncType) {
+	if !hasDefaults(funcType) {
+		return
+	}
+	for _, f := range funcType.Params.List {
+		if f.Default == nil {
+			continue
+		}
+		for _, p := range funcType.Params.List {
+			for _, n := range p.Names {
+				if n.Name != "_" && refersTo(n.Name, f.Default) {
+					t.errorf(f.Default.Pos(), "default value of parameter %s refers to parameter %s", f.Names[0].Name, n.Name)
+					return
+				}
+			}
+		}
+	}
+}
+
+// refersTo сообщает, ссылается ли выражение на имя name. В отличие от
+// usesIdent, не учитывает имена полей и методов после точки.
+func refersTo(name string, expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			found = found || refersTo(name, n.X)
+			return false
+		case *ast.Ident:
+			if n.Name == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// lowerDefaultParams убирает значения по умолчанию из параметров функции.
+// Тип параметра без аннотации выводится из значения по умолчанию через
+// go/types. Для экспортируемой необобщённой функции генерируются
+// FOptions, DefaultFOptions и FWithOptions для вызовов из Go-кода.
+//
+//	func Dial(addr string, timeout = 5 * time.Second, retries = 3)
+//
+// становится
+//
+//	func Dial(addr string, timeout time.Duration, retries int)
+func (t *Transpiler) lowerDefaultParams(fn *ast.FuncDecl) (*ast.FuncType, []ast.Stmt) {
+	// Выведенные типы не имеют позиций, поэтому и у ")" её нет: иначе
+	// printer поставит запятую после последнего параметра.
+	params := &ast.FieldList{Opening: fn.Type.Params.Opening, Closing: token.NoPos}
+	var prologue []ast.Stmt
+	seenDefault := false
+	for _, f := range fn.Type.Params.List {
+		if f.Default == nil {
+			if seenDefault {
+				t.errorf(f.Pos(), "parameter %s must have a default value because it follows parameters with defaults", f.Names[0].Name)
+			}
+			params.List = append(params.List, f)
+			continue
+		}
+		seenDefault = true
+		typ := f.Type
+		if typ == nil && t.probing {
+			// Тип ещё неизвестен: параметр становится _ any, а в теле
+			// объявляется переменная с типом значения по умолчанию.
+			prologue = append(prologue, &ast.AssignStmt{
+				Lhs: []ast.Expr{f.Names[0]},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{t.probe(f, t.transpileExpr(f.Default))},
+			})
+			anyType := &ast.Ident{NamePos: token.NoPos, Name: "any"}
+			params.List = append(params.List, &ast.Field{
+				Names: []*ast.Ident{{NamePos: token.NoPos, Name: "_"}},
+				Type:  anyType,
+			})
+			t.defaultFunc(fn, f, anyType)
+			continue
+		}
+		if typ == nil {
+			if typ = t.typeExpr(t.typeOf(f)); typ == nil {
+				name := f.Names[0].Name
+				t.errorf(f.Pos(), "cannot infer type of parameter %s from its default value; annotate it: %s T = ...", name, name)
+				typ = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+			}
+		}
+		params.List = append(params.List, &ast.Field{Names: f.Names, Type: typ})
+		t.defaultFunc(fn, f, typ)
+	}
+
+	funcType := &ast.FuncType{Func: fn.Type.Func, TypeParams: fn.Type.TypeParams, Params: params, Results: fn.Type.Results}
+	if !t.probing && token.IsExported(fn.Name.Name) && fn.Type.TypeParams == nil {
+		t.declCode = append(t.declCode, t.defaultOptions(fn, params))
+	}
+	return funcType, prologue
+}
+
+// defaultFunc генерирует функцию уровня пакета, которая вычисляет значение
+// по умолчанию параметра f, если его нельзя подставить в место вызова
+// как есть:
+//
+//	func fetch(n int, retries = limit)
+//
+// →
+//
+//	func _godslDefault_fetch_retries() int {
+//		return limit
+//	}
+func (t *Transpiler) defaultFunc(fn *ast.FuncDecl, f *ast.Field, typ ast.Expr) {
+	if isLiteralDefault(f.Default) {
+		return
+	}
+	param := f.Names[0].Name
+	if fn.Type.TypeParams != nil {
+		for _, tp := range fn.Type.TypeParams.List {
+			for _, n := range tp.Names {
+				if usesIdent(n.Name, typ, f.Default) {
+					t.errorf(f.Default.Pos(), "default value of parameter %s depends on type parameter %s; use a literal value", param, n.Name)
+					return
+				}
+			}
+		}
+	}
+	name := defaultFuncName(fn.Name.Name, param)
+	t.declCode = append(t.declCode, fmt.Sprintf(
+		"\n// %s возвращает значение по умолчанию параметра %s функции %s.\nfunc %s() %s {\n\treturn %s\n}\n",
+		name, param, fn.Name.Name, name, t.nodeString(typ), t.nodeString(t.transpileExpr(f.Default))))
+}
+
+// defaultOptions генерирует структуру необязательных параметров функции,
+// их значения по умолчанию и вызов функции со структурой в виде Go-кода.
+func (t *Transpiler) defaultOptions(fn *ast.FuncDecl, params *ast.FieldList) string {
+	name := fn.Name.Name
+	opts := name + "Options"
+	optsParam := "opts"
+	for _, f := range fn.Type.Params.List {
+		for _, n := range f.Names {
+			if n.Name == optsParam {
+				optsParam = "options"
+			}
+		}
+	}
+
+	type option struct{ param, field, typ, def string }
+	var required []string // объявления обязательных параметров
+	var args []string     // аргументы вызова fn из FWithOptions
+	var options []option
+	width := 0
+	for i, f := range fn.Type.Params.List {
+		typ := t.nodeString(params.List[i].Type)
+		if f.Default == nil {
+			for _, n := range f.Names {
+				param := n.Name
+				if param == "_" {
+					param = fmt.Sprintf("p%d", len(args))
+				}
+				required = append(required, param+" "+typ)
+				args = append(args, param)
+			}
+			continue
+		}
+		param := f.Names[0].Name
+		o := option{param: param, field: exportedName(param), typ: typ, def: t.nodeString(t.transpileExpr(f.Default))}
+		options = append(options, o)
+		args = append(args, optsParam+"."+o.field)
+		width = max(width, len(o.field))
+	}
+
+	results := ""
+	if fn.Type.Results != nil && len(fn.Type.Results.List) > 0 {
+		var list []string
+		named := false
+		for _, f := range fn.Type.Results.List {
+			typ := t.nodeString(f.Type)
+			if len(f.Names) == 0 {
+				list = append(list, typ)
+				continue
+			}
+			named = true
+			var names []string
+			for _, n := range f.Names {
+				names = append(names, n.Name)
+			}
+			list = append(list, strings.Join(names, ", ")+" "+typ)
+		}
+		results = " " + strings.Join(list, ", ")
+		if named || len(list) > 1 {
+			results = " (" + strings.Join(list, ", ") + ")"
+		}
+	}
+
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	w("\n// %s — необязательные параметры %s.\n", opts, name)
+	w("type %s struct {\n", opts)
+	for _, o := range options {
+		w("\t%-*s %s\n", width, o.field, o.typ)
+	}
+	w("}\n")
+
+	var inits []string
+	for _, o := range options {
+		inits = append(inits, o.field+": "+o.def)
+	}
+	w("\n// Default%s возвращает значения необязательных параметров %s по умолчанию.\n", opts, name)
+	w("func Default%s() %s {\n", opts, opts)
+	w("\treturn %s{%s}\n", opts, strings.Join(inits, ", "))
+	w("}\n")
+
+	for _, o := range options {
+		param := o.param
+		if param == "o" {
+			param = "v"
+		}
+		w("\n// With%s возвращает копию %s с новым значением %s.\n", o.field, opts, o.field)
+		w("func (o %s) With%s(%s %s) %s {\n", opts, o.field, param, o.typ, opts)
+		w("\to.%s = %s\n", o.field, param)
+		w("\treturn o\n")
+		w("}\n")
+	}
+
+	ret := ""
+	if results != "" {
+		ret = "return "
+	}
+	w("\n// %sWithOptions вызывает %s с необязательными параметрами из %s.\n", name, name, optsParam)
+	w("func %sWithOptions(%s)%s {\n", name, strings.Join(append(required, optsParam+" "+opts), ", "), results)
+	w("\t%s%s(%s)\n", ret, name, strings.Join(args, ", "))
+	w("}\n")
+
+	// Типы параметров и значения по умолчанию напечатаны из AST, поэтому
+	// код выравнивается gofmt целиком.
+	if src, err := format.Source([]byte(b.String())); err == nil {
+		return string(src)
+	}
+	return b.String()
+}
+
+// resolveCallArgs подставляет в вызов функции с параметрами по умолчанию
+// пропущенные аргументы и расставляет именованные по позициям:
+//
+//	Dial("x", retries: 5)  →  Dial("x", 5*time.Second, 5)
+//
+// Литеральное значение по умолчанию подставляется как есть, остальные
+// вычисляет функция уровня пакета (см. defaultFunc), а функция из другого
+// пакета вызывается через FWithOptions (см. optionsCall). Именованные
+// аргументы с вызовами нельзя переставлять: их порядок вычисления
+// изменился бы. Результат запоминается, чтобы пробный и основной проходы
+// работали с одними и теми же узлами.
+func (t *Transpiler) resolveCallArgs(call *ast.CallExpr) *ast.CallExpr {
+	if resolved, ok := t.resolvedCalls[call]; ok {
+		return resolved
+	}
+	sig, name, pkg := t.lookupSignature(call.Fun)
+	if sig == nil {
+		for _, arg := range call.Args {
+			if a, ok := arg.(*ast.NamedArg); ok {
+				t.errorf(a.Pos(), "named argument %s: named arguments are only supported for godsl functions with default parameters", a.Name.Name)
+				break
+			}
+		}
+		return call
+	}
+	if call.Ellipsis.IsValid() {
+		t.errorf(call.Ellipsis, "cannot use ... in call to %s with default parameters", name)
+		return call
+	}
+
+	args := make([]ast.Expr, len(sig.Params))
+	named := false
+	var calls []int // параметры именованных аргументов с вызовами в порядке записи
+	for i, arg := range call.Args {
+		a, ok := arg.(*ast.NamedArg)
+		if !ok {
+			switch {
+			case named:
+				t.errorf(arg.Pos(), "positional argument after named arguments in call to %s", name)
+				return call
+			case i >= len(args):
+				t.errorf(arg.Pos(), "too many arguments in call to %s", name)
+				return call
+			}
+			args[i] = arg
+			continue
+		}
+		named = true
+		j := sig.paramIndex(a.Name.Name)
+		switch {
+		case j < 0:
+			t.errorf(a.Name.Pos(), "%s has no parameter %s", name, a.Name.Name)
+			return call
+		case args[j] != nil:
+			t.errorf(a.Name.Pos(), "argument %s is given more than once in call to %s", a.Name.Name, name)
+			return call
+		}
+		args[j] = a.Value
+		if isPureExpr(a.Value) {
+			continue
+		}
+		// Аргументы вычисляются в порядке параметров, поэтому вызовы
+		// в именованных аргументах не должны меняться местами.
+		for _, k := range calls {
+			if k > j {
+				t.errorf(a.Name.Pos(), "argument %s would be evaluated before argument %s in call to %s; pass them in parameter order or assign them to variables first", a.Name.Name, sig.Params[k].Name, name)
+				return call
+			}
+		}
+		calls = append(calls, j)
+	}
+
+	omitted := false
+	for i, p := range sig.Params {
+		if args[i] != nil {
+			continue
+		}
+		if p.Default == "" {
+			t.errorf(call.Rparen, "missing argument %s in call to %s", p.Name, name)
+			return call
+		}
+		omitted = true
+		if pkg != nil {
+			continue
+		}
+		if args[i] = t.defaultArg(call, p, name); args[i] == nil {
+			return call
+		}
+	}
+
+	resolved := &ast.CallExpr{Fun: call.Fun, Lparen: call.Lparen, Args: args, Rparen: call.Rparen}
+	if pkg != nil && omitted {
+		if !sig.Options {
+			t.errorf(call.Rparen, "missing arguments in call to %s: default values of generic functions are not available from other packages", name)
+			return call
+		}
+		resolved = optionsCall(resolved, sig, pkg)
+	}
+	t.resolvedCalls[call] = resolved
+	return resolved
+}
+
+// optionsCall переводит вызов функции из другого пакета с пропущенными
+// аргументами в вызов FWithOptions: значения по умолчанию берутся из
+// одного вызова DefaultFOptions(), а переданные необязательные аргументы
+// задаются методами WithX.
+//
+//	net.Dial("x", retries: 5)  →  net.DialWithOptions("x", net.DefaultDialOptions().WithRetries(5))
+func optionsCall(call *ast.CallExpr, sig *FuncSignature, pkg *ast.Ident) *ast.CallExpr {
+	fun := call.Fun.(*ast.SelectorExpr)
+	selector := func(x ast.Expr, name string) *ast.SelectorExpr {
+		return &ast.SelectorExpr{X: x, Sel: &ast.Ident{NamePos: token.NoPos, Name: name}}
+	}
+	opts := ast.Expr(&ast.CallExpr{Fun: selector(&ast.Ident{NamePos: token.NoPos, Name: pkg.Name}, "Default"+fun.Sel.Name+"Options")})
+	var args []ast.Expr
+	for i, p := range sig.Params {
+		switch {
+		case p.Default == "":
+			args = append(args, call.Args[i])
+		case call.Args[i] != nil:
+			opts = &ast.CallExpr{Fun: selector(opts, "With"+exportedName(p.Name)), Args: []ast.Expr{call.Args[i]}}
+		}
+	}
+	return &ast.CallExpr{
+		Fun:    &ast.SelectorExpr{X: fun.X, Sel: &ast.Ident{NamePos: fun.Sel.NamePos, Name: fun.Sel.Name + "WithOptions"}},
+		Lparen: call.Lparen,
+		Args:   append(args, opts),
+		Rparen: call.Rparen,
+	}
+}
+
+// defaultArg возвращает значение по умолчанию параметра p для вызова
+// функции из того же пакета.
+func (t *Transpiler) defaultArg(call *ast.CallExpr, p FuncParam, name string) ast.Expr {
+	expr, err := parser.ParseExpr(p.Default)
+	if err != nil {
+		t.errorf(call.Rparen, "invalid default value of %s in %s: %v", p.Name, name, err)
+		return nil
+	}
+	if isLiteralDefault(expr) {
+		clearPositions(expr)
+		return expr
+	}
+	// Значение с именами вычисляется в области видимости пакета функции,
+	// а не в месте вызова, где имя может быть перекрыто.
+	return &ast.CallExpr{Fun: &ast.Ident{NamePos: token.NoPos, Name: defaultFuncName(name, p.Name)}}
+}
+
+// isLiteralDefault сообщает, что значение по умолчанию не содержит имён
+// (кроме nil, true и false) и его можно подставить в место вызова.
+func isLiteralDefault(expr ast.Expr) bool {
+	literal := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name != "nil" && id.Name != "true" && id.Name != "false" {
+			literal = false
+		}
+		return literal
+	})
+	return literal
+}
+
+// defaultFuncName возвращает имя функции уровня пакета, которая вычисляет
+// значение по умолчанию параметра param функции fn.
+func defaultFuncName(fn, param string) string {
+	return "_godslDefault_" + fn + "_" + param
+}
+
+// lookupSignature находит сигнатуру вызываемой функции с параметрами по
+// умолчанию: f(...) в пакете файла или pkg.F(...) из импортированного
+// пакета godsl. pkg — имя пакета в вызове или nil.
+func (t *Transpiler) lookupSignature(fun ast.Expr) (sig *FuncSignature, name string, pkg *ast.Ident) {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		// Локальная переменная с тем же именем перекрывает функцию.
+		if f.Obj != nil && f.Obj.Kind != ast.Fun {
+			return nil, "", nil
+		}
+		return t.signatures[""][f.Name], f.Name, nil
+	case *ast.SelectorExpr:
+		x, ok := f.X.(*ast.Ident)
+		if !ok || x.Obj != nil {
+			return nil, "", nil
+		}
+		for path, importName := range t.importNames {
+			if importName == "" {
+				importName = path[strings.LastIndex(path, "/")+1:]
+			}
+			if importName == x.Name {
+				return t.signatures[path][f.Sel.Name], x.Name + "." + f.Sel.Name, x
+			}
+		}
+	}
+	return nil, "", nil
+}
+
+// exportedName переводит имя параметра в имя поля: timeout → Timeout.
+func exportedName(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(r)) + name[size:]
+}
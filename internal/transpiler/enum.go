@@ -0,0 +1,280 @@
+package transpiler
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// enumBaseTypes — допустимые базовые типы enum.
+var enumBaseTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"byte": true, "rune": true, "string": true,
+}
+
+// collectEnums возвращает enum, объявленные в файле, по именам их членов.
+func collectEnums(file *ast.File) map[string]*ast.EnumDecl {
+	members := make(map[string]*ast.EnumDecl)
+	for _, decl := range file.Decls {
+		if enum, ok := decl.(*ast.EnumDecl); ok {
+			for _, m := range enum.Members {
+				members[m.Name.Name] = enum
+			}
+		}
+	}
+	return members
+}
+
+// transpileEnumDecl разворачивает enum в именованный тип и блок констант.
+// Методы String, IsValid, MarshalText, UnmarshalText и функции ParseX,
+// XValues генерируются текстом в t.declCode и дописываются в конец файла.
+//
+//	enum Color { Red, Green }
+//
+// становится
+//
+//	type Color int
+//
+//	const (
+//		Red Color = iota
+//		Green
+//	)
+func (t *Transpiler) transpileEnumDecl(enum *ast.EnumDecl) []ast.Decl {
+	name := enum.Name.Name
+	base := "int"
+	if enum.Type != nil {
+		ident, ok := enum.Type.(*ast.Ident)
+		if !ok || !enumBaseTypes[ident.Name] {
+			t.errorf(enum.Type.Pos(), "enum %s: underlying type must be an integer type or string", name)
+			return nil
+		}
+		base = ident.Name
+	}
+	isString := base == "string"
+
+	seen := make(map[string]bool)
+	explicit := 0
+	for _, m := range enum.Members {
+		if seen[m.Name.Name] {
+			t.errorf(m.Name.Pos(), "enum %s: duplicate member %s", name, m.Name.Name)
+		}
+		seen[m.Name.Name] = true
+		if m.Value != nil {
+			explicit++
+		}
+	}
+	if !isString && explicit > 0 && explicit < len(enum.Members) {
+		for _, m := range enum.Members {
+			if m.Value == nil {
+				t.errorf(m.Name.Pos(), "enum %s: member %s needs an explicit value because other members have one", name, m.Name.Name)
+				break
+			}
+		}
+	}
+
+	typeIdent := func() *ast.Ident { return &ast.Ident{NamePos: token.NoPos, Name: name} }
+
+	var baseExpr ast.Expr = &ast.Ident{NamePos: token.NoPos, Name: base}
+	if enum.Type != nil {
+		baseExpr = enum.Type
+	}
+	typeDecl := &ast.GenDecl{
+		Doc:    enum.Doc,
+		TokPos: enum.Enum,
+		Tok:    token.TYPE,
+		Specs:  []ast.Spec{&ast.TypeSpec{Name: enum.Name, Type: baseExpr}},
+	}
+
+	constDecl := &ast.GenDecl{
+		TokPos: enum.Lbrace,
+		Tok:    token.CONST,
+		Lparen: enum.Lbrace,
+		Rparen: enum.Rbrace,
+	}
+	for i, m := range enum.Members {
+		spec := &ast.ValueSpec{Names: []*ast.Ident{m.Name}}
+		switch {
+		case m.Value != nil:
+			spec.Type, spec.Values = typeIdent(), []ast.Expr{m.Value}
+		case isString:
+			spec.Type = typeIdent()
+			spec.Values = []ast.Expr{&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: fmt.Sprintf("%q", m.Name.Name)}}
+		case i == 0:
+			spec.Type, spec.Values = typeIdent(), []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "iota"}}
+		}
+		constDecl.Specs = append(constDecl.Specs, spec)
+	}
+
+	t.declCode = append(t.declCode, enumMethods(enum, isString, t.requireImport("fmt")))
+	return []ast.Decl{typeDecl, constDecl}
+}
+
+// enumMethods генерирует методы и функции enum в виде Go-кода. fmtName —
+// имя, под которым в файле доступен пакет fmt.
+func enumMethods(enum *ast.EnumDecl, isString bool, fmtName string) string {
+	name := enum.Name.Name
+	recv := receiverName(name)
+	names := make([]string, len(enum.Members))
+	for i, m := range enum.Members {
+		names[i] = m.Name.Name
+	}
+	list := strings.Join(names, ", ")
+
+	zero, verb := "0", "%d"
+	if isString {
+		zero, verb = `""`, "%q"
+	}
+
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	w("\n// String возвращает строковое представление %s.\n", name)
+	w("func (%s %s) String() string {\n", recv, name)
+	if isString {
+		w("\treturn string(%s)\n", recv)
+	} else {
+		w("\tswitch %s {\n", recv)
+		for _, n := range names {
+			w("\tcase %s:\n\t\treturn %q\n", n, n)
+		}
+		w("\t}\n")
+		w("\treturn %s.Sprintf(\"%s(%%d)\", %s)\n", fmtName, name, recv)
+	}
+	w("}\n")
+
+	w("\n// Parse%s возвращает значение %s по его строковому представлению.\n", name, name)
+	w("func Parse%s(s string) (%s, error) {\n", name, name)
+	if isString {
+		w("\tswitch v := %s(s); v {\n", name)
+		w("\tcase %s:\n\t\treturn v, nil\n", list)
+	} else {
+		w("\tswitch s {\n")
+		for _, n := range names {
+			w("\tcase %q:\n\t\treturn %s, nil\n", n, n)
+		}
+	}
+	w("\t}\n")
+	w("\treturn %s, %s.Errorf(\"invalid %s %%q\", s)\n", zero, fmtName, name)
+	w("}\n")
+
+	w("\n// %sValues возвращает все значения %s в порядке объявления.\n", name, name)
+	w("func %sValues() []%s {\n", name, name)
+	w("\treturn []%s{%s}\n", name, list)
+	w("}\n")
+
+	w("\n// IsValid сообщает, является ли значение одним из членов %s.\n", name)
+	w("func (%s %s) IsValid() bool {\n", recv, name)
+	w("\tswitch %s {\n", recv)
+	w("\tcase %s:\n\t\treturn true\n", list)
+	w("\t}\n")
+	w("\treturn false\n")
+	w("}\n")
+
+	w("\n// MarshalText реализует encoding.TextMarshaler (в том числе для encoding/json).\n")
+	w("func (%s %s) MarshalText() ([]byte, error) {\n", recv, name)
+	w("\tif !%s.IsValid() {\n", recv)
+	w("\t\treturn nil, %s.Errorf(\"invalid %s %s\", %s)\n", fmtName, name, verb, enumUnderlying(recv, isString))
+	w("\t}\n")
+	w("\treturn []byte(%s.String()), nil\n", recv)
+	w("}\n")
+
+	w("\n// UnmarshalText реализует encoding.TextUnmarshaler (в том числе для encoding/json).\n")
+	w("func (%s *%s) UnmarshalText(text []byte) error {\n", recv, name)
+	// локальные имена длиннее одной буквы и не совпадают с получателем
+	w("\tparsed, err := Parse%s(string(text))\n", name)
+	w("\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\t*%s = parsed\n", recv)
+	w("\treturn nil\n")
+	w("}\n")
+
+	return b.String()
+}
+
+// receiverName возвращает имя получателя сгенерированных методов: первая буква
+// имени типа в нижнем регистре.
+func receiverName(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r))
+}
+
+// enumUnderlying возвращает выражение для печати значения без вызова String.
+func enumUnderlying(recv string, isString bool) string {
+	if isString {
+		return "string(" + recv + ")"
+	}
+	return recv
+}
+
+// checkEnumSwitches предупреждает о switch и match по enum без default,
+// в которых перечислены не все члены enum.
+func (t *Transpiler) checkEnumSwitches(file *ast.File) {
+	if t.opts.Warnings == nil || len(t.enumMembers) == 0 {
+		return
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SwitchStmt:
+			if n.Tag == nil {
+				return true
+			}
+			var cases []ast.Expr
+			for _, stmt := range n.Body.List {
+				clause := stmt.(*ast.CaseClause)
+				if clause.List == nil {
+					return true
+				}
+				cases = append(cases, clause.List...)
+			}
+			t.warnMissingMembers(n.Switch, "switch", cases)
+		case *ast.MatchExpr:
+			var cases []ast.Expr
+			for _, arm := range n.Arms {
+				if arm.Patterns == nil {
+					return true
+				}
+				// Ветка с условием покрывает значения не полностью.
+				if arm.Guard == nil {
+					cases = append(cases, arm.Patterns...)
+				}
+			}
+			t.warnMissingMembers(n.Match, "match", cases)
+		}
+		return true
+	})
+}
+
+// warnMissingMembers выводит предупреждение, если все cases — члены одного
+// enum и часть членов не перечислена.
+func (t *Transpiler) warnMissingMembers(pos token.Pos, kind string, cases []ast.Expr) {
+	var enum *ast.EnumDecl
+	covered := make(map[string]bool)
+	for _, c := range cases {
+		ident, ok := c.(*ast.Ident)
+		if !ok {
+			return
+		}
+		e := t.enumMembers[ident.Name]
+		if e == nil || (enum != nil && e != enum) {
+			return
+		}
+		enum = e
+		covered[ident.Name] = true
+	}
+	if enum == nil {
+		return
+	}
+	var missing []string
+	for _, m := range enum.Members {
+		if !covered[m.Name.Name] {
+			missing = append(missing, m.Name.Name)
+		}
+	}
+	if len(missing) > 0 {
+		t.warnf(pos, "%s over %s is missing cases: %s", kind, enum.Name.Name, strings.Join(missing, ", "))
+	}
+}
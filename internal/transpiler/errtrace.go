@@ -0,0 +1,193 @@
+package transpiler
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// errtraceImportPath — рантайм-пакет godsl для режима --trace-errors.
+const errtraceImportPath = "github.com/sviridovkonstantin42/godsl/runtime/errtrace"
+
+// traceErr оборачивает выражение-ошибку позицией pos в .godsl и именем
+// текущей функции:
+//
+//	err → errtrace.Wrap(err, "main.godsl:12", "process")
+//
+// Без режима TraceErrors выражение возвращается как есть.
+func (t *Transpiler) traceErr(errExpr ast.Expr, pos token.Pos) ast.Expr {
+	if !t.opts.TraceErrors {
+		return errExpr
+	}
+	pkg := t.requireImport(errtraceImportPath)
+
+	position := t.fset.Position(pos)
+	filename := position.Filename
+	if filename == "" {
+		filename = "<input>"
+	}
+	funcName := t.funcName
+	if funcName == "" {
+		funcName = "<unknown>"
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: pkg},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: "Wrap"},
+		},
+		Args: []ast.Expr{
+			errExpr,
+			&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(fmt.Sprintf("%s:%d", filename, position.Line))},
+			&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(funcName)},
+		},
+	}
+}
+
+// catchSubject возвращает выражение, к которому применяется проверка типа в
+// catch. В режиме TraceErrors ошибка может быть обёрнута трассой, поэтому
+// проверяется errtrace.Cause(err), иначе — сама err.
+func (t *Transpiler) catchSubject() ast.Expr {
+	errIdent := &ast.Ident{NamePos: token.NoPos, Name: "err"}
+	if !t.opts.TraceErrors {
+		return errIdent
+	}
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(errtraceImportPath)},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: "Cause"},
+		},
+		Args: []ast.Expr{errIdent},
+	}
+}
+
+// funcDeclName возвращает имя функции для трассировки: Name, T.Name или (*T).Name.
+func funcDeclName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+	switch recv := funcDecl.Recv.List[0].Type.(type) {
+	case *ast.StarExpr:
+		if name := recvTypeName(recv.X); name != "" {
+			return "(*" + name + ")." + funcDecl.Name.Name
+		}
+	default:
+		if name := recvTypeName(recv); name != "" {
+			return name + "." + funcDecl.Name.Name
+		}
+	}
+	return funcDecl.Name.Name
+}
+
+// recvTypeName возвращает имя типа получателя без параметров типа.
+func recvTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.IndexExpr:
+		return recvTypeName(e.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(e.X)
+	}
+	return ""
+}
+
+// requireImport отмечает, что сгенерированному коду нужен пакет importPath,
+// и возвращает имя, под которым пакет доступен в файле. Если файл уже
+// импортирует пакет, используется его имя (в том числе псевдоним). Иначе
+// добавляется импорт под именем пакета, а если это имя занято другим
+// импортом или пакет импортирован как _ или . — под именем _godsl<Имя>.
+func (t *Transpiler) requireImport(importPath string) string {
+	if name, ok := t.imports[importPath]; ok {
+		return name
+	}
+	own := path.Base(importPath)
+	alias := "_godsl" + strings.ToUpper(own[:1]) + own[1:]
+	name, imported := t.importNames[importPath]
+	switch {
+	case !imported:
+		name = own
+		for p, n := range t.importNames {
+			if n == own || n == "" && path.Base(p) == own {
+				name = alias
+				break
+			}
+		}
+	case name == "":
+		name = own
+	case name == "_" || name == ".":
+		name = alias
+	}
+	t.imports[importPath] = name
+	return name
+}
+
+// importName возвращает имя, под которым файл импортирует importPath, или
+// пустую строку, если пакет не импортирован.
+func (t *Transpiler) importName(importPath string) string {
+	name, ok := t.importNames[importPath]
+	if ok && name == "" {
+		name = path.Base(importPath)
+	}
+	return name
+}
+
+// addImports добавляет в файл импорты, запрошенные через requireImport,
+// если их ещё нет под нужным именем.
+func (t *Transpiler) addImports(file *ast.File) {
+	if len(t.imports) == 0 {
+		return
+	}
+
+	existing := make(map[string]bool)
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		existing[p+" "+name] = true
+	}
+
+	var paths []string
+	for p, name := range t.imports {
+		if !existing[p+" "+name] {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	sort.Strings(paths)
+
+	var importDecl *ast.GenDecl
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			importDecl = gen
+			break
+		}
+	}
+	if importDecl == nil {
+		importDecl = &ast.GenDecl{TokPos: token.NoPos, Tok: token.IMPORT}
+		file.Decls = append([]ast.Decl{importDecl}, file.Decls...)
+	}
+
+	for _, p := range paths {
+		spec := &ast.ImportSpec{
+			Path: &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: strconv.Quote(p)},
+		}
+		if name := t.imports[p]; name != path.Base(p) {
+			spec.Name = &ast.Ident{NamePos: token.NoPos, Name: name}
+		}
+		importDecl.Specs = append(importDecl.Specs, spec)
+		file.Imports = append(file.Imports, spec)
+	}
+}
@@ -0,0 +1,288 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// transpileForIn разворачивает цикл for ... in. Диапазон становится
+// классическим циклом for, коллекция — циклом for range:
+//
+//	for i in 0..<len(xs) { ... }   → for i, _godslEnd := 0, len(xs); i < _godslEnd; i++ { ... }
+//	for i in 10..0 step -2 { ... } → for i := 10; i >= 0; i -= 2 { ... }
+//	for i, v in items { ... }      → for i, v := range items { ... }
+//
+// Границы и шаг диапазона вычисляются один раз, до первой итерации.
+func (t *Transpiler) transpileForIn(s *ast.ForStmt) ast.Stmt {
+	body := &ast.BlockStmt{
+		Lbrace: s.Body.Lbrace,
+		List:   t.transpileStmts(s.Body.List),
+		Rbrace: s.Body.Rbrace,
+	}
+	if r, ok := s.In.X.(*ast.RangeExpr); ok {
+		return t.transpileRangeLoop(s, r, body)
+	}
+
+	loop := &ast.RangeStmt{
+		For:    s.For,
+		Key:    s.In.Key,
+		TokPos: s.In.In,
+		Tok:    token.DEFINE,
+		Range:  s.In.In,
+		X:      t.probe(s.In, t.transpileExpr(s.In.X)),
+		Body:   body,
+	}
+	switch {
+	case s.In.Value != nil:
+		loop.Value = s.In.Value
+	case !t.rangesOverKeys(s.In):
+		// for v in items перебирает значения элементов.
+		loop.Key, loop.Value = &ast.Ident{NamePos: token.NoPos, Name: "_"}, s.In.Key
+	}
+	return loop
+}
+
+// rangesOverKeys сообщает, выдаёт ли range по коллекции единственное
+// значение: у каналов, целых чисел и функций-итераторов переменная цикла
+// одна. Без информации о типе коллекция считается срезом.
+func (t *Transpiler) rangesOverKeys(in *ast.InClause) bool {
+	typ := t.typeOf(in)
+	if typ == nil {
+		return false
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		return true
+	case *types.Basic:
+		return u.Info()&types.IsInteger != 0
+	}
+	return false
+}
+
+// transpileRangeLoop разворачивает for i in low..high [step s] в цикл
+// for с init, условием и шагом. Нелитеральная верхняя граница и шаг
+// сохраняются во временных переменных; направление цикла определяет знак
+// шага, а для нелитерального шага — проверка во время выполнения, которая
+// вызывает panic при нулевом шаге.
+func (t *Transpiler) transpileRangeLoop(s *ast.ForStmt, r *ast.RangeExpr, body *ast.BlockStmt) ast.Stmt {
+	if s.In.Value != nil {
+		t.errorf(s.In.Value.Pos(), "range loop takes a single variable")
+		return &ast.ForStmt{For: s.For, Body: body}
+	}
+
+	var key ast.Expr = s.In.Key
+	if s.In.Key.Name == "_" {
+		key = &ast.Ident{NamePos: token.NoPos, Name: "_godslI"}
+	}
+
+	low, high := t.transpileExpr(r.Low), t.probe(r, t.transpileExpr(r.High))
+	// for i in 0..<n при n int64: i должна иметь тип границы.
+	if isConstLit(r.Low) {
+		if typ := t.rangeBoundType(r); typ != nil {
+			low = &ast.CallExpr{Fun: typ, Args: []ast.Expr{low}}
+		}
+	}
+	names, values := []ast.Expr{key}, []ast.Expr{low}
+	end := high
+	if !isConstLit(r.High) {
+		end = &ast.Ident{NamePos: token.NoPos, Name: "_godslEnd"}
+		names, values = append(names, end), append(values, high)
+	}
+
+	sign, constStep := 1, true
+	var step ast.Expr
+	if r.Step != nil {
+		sign, constStep = constSign(r.Step)
+		if constStep && sign == 0 {
+			t.errorf(r.Step.Pos(), "range step must not be zero")
+		}
+		step = t.transpileExpr(r.Step)
+		if !constStep {
+			v := &ast.Ident{NamePos: token.NoPos, Name: "_godslStep"}
+			names, values = append(names, v), append(values, step)
+			step = v
+		}
+	}
+
+	// Включительный диапазон до максимума типа: после i++ значение
+	// переполнилось бы и снова прошло проверку i <= end. Поэтому цикл
+	// завершается, когда i достигла границы (_godslDone), до увеличения.
+	var done ast.Expr
+	if r.Op == token.RANGE_INCL && !(isConstLit(r.Low) && isConstLit(r.High)) {
+		done = &ast.Ident{NamePos: token.NoPos, Name: "_godslDone"}
+		names, values = append(names, done), append(values, &ast.Ident{NamePos: token.NoPos, Name: "false"})
+	}
+
+	lt, gt := token.LSS, token.GTR
+	if r.Op == token.RANGE_INCL {
+		lt, gt = token.LEQ, token.GEQ
+	}
+	zero := &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}
+	var cond ast.Expr
+	switch {
+	case !constStep:
+		// _godslStep > 0 && i < end || _godslStep < 0 && i > end || _godslStep == 0:
+		// при нулевом шаге тело начинается с panic (см. ниже).
+		cond = &ast.BinaryExpr{
+			X: &ast.BinaryExpr{
+				X: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: step, Op: token.GTR, Y: zero},
+					Op: token.LAND,
+					Y:  &ast.BinaryExpr{X: key, Op: lt, Y: end},
+				},
+				Op: token.LOR,
+				Y: &ast.BinaryExpr{
+					X:  &ast.BinaryExpr{X: step, Op: token.LSS, Y: zero},
+					Op: token.LAND,
+					Y:  &ast.BinaryExpr{X: key, Op: gt, Y: end},
+				},
+			},
+			Op: token.LOR,
+			Y:  &ast.BinaryExpr{X: step, Op: token.EQL, Y: zero},
+		}
+		body.List = append([]ast.Stmt{&ast.IfStmt{
+			If:   token.NoPos,
+			Cond: &ast.BinaryExpr{X: step, Op: token.EQL, Y: zero},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: &ast.CallExpr{
+				Fun:  &ast.Ident{NamePos: token.NoPos, Name: "panic"},
+				Args: []ast.Expr{&ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: `"range step must not be zero"`}},
+			}}}},
+		}}, body.List...)
+	case sign > 0:
+		cond = &ast.BinaryExpr{X: key, Op: lt, Y: end}
+	default:
+		cond = &ast.BinaryExpr{X: key, Op: gt, Y: end}
+	}
+
+	var post ast.Stmt
+	switch {
+	case step == nil || isUnitLit(step):
+		post = &ast.IncDecStmt{X: key, Tok: token.INC}
+	case constStep && sign < 0:
+		if u, ok := step.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+			if isUnitLit(u.X) {
+				post = &ast.IncDecStmt{X: key, Tok: token.DEC}
+			} else {
+				post = &ast.AssignStmt{Lhs: []ast.Expr{key}, Tok: token.SUB_ASSIGN, Rhs: []ast.Expr{u.X}}
+			}
+			break
+		}
+		fallthrough
+	default:
+		post = &ast.AssignStmt{Lhs: []ast.Expr{key}, Tok: token.ADD_ASSIGN, Rhs: []ast.Expr{step}}
+	}
+
+	if done != nil {
+		// !_godslDone && cond; _godslDone, i = i == end, i + step
+		if !constStep {
+			cond = &ast.ParenExpr{X: cond}
+		}
+		cond = &ast.BinaryExpr{X: &ast.UnaryExpr{Op: token.NOT, X: done}, Op: token.LAND, Y: cond}
+		post = &ast.AssignStmt{
+			Lhs: []ast.Expr{done, key},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{&ast.BinaryExpr{X: key, Op: token.EQL, Y: end}, nextValue(key, post)},
+		}
+	}
+
+	return &ast.ForStmt{
+		For:  s.For,
+		Init: &ast.AssignStmt{Lhs: names, TokPos: token.NoPos, Tok: token.DEFINE, Rhs: values},
+		Cond: cond,
+		Post: post,
+		Body: body,
+	}
+}
+
+// nextValue переводит шаг цикла post (i++, i--, i += s, i -= s) в
+// выражение следующего значения key: i + 1, i - 1, i + s, i - s.
+func nextValue(key ast.Expr, post ast.Stmt) ast.Expr {
+	one := &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "1"}
+	switch p := post.(type) {
+	case *ast.IncDecStmt:
+		if p.Tok == token.DEC {
+			return &ast.BinaryExpr{X: key, Op: token.SUB, Y: one}
+		}
+		return &ast.BinaryExpr{X: key, Op: token.ADD, Y: one}
+	case *ast.AssignStmt:
+		if p.Tok == token.SUB_ASSIGN {
+			return &ast.BinaryExpr{X: key, Op: token.SUB, Y: p.Rhs[0]}
+		}
+		return &ast.BinaryExpr{X: key, Op: token.ADD, Y: p.Rhs[0]}
+	}
+	return key
+}
+
+// rangeBoundType возвращает тип верхней границы диапазона, если это
+// числовой тип, отличный от int, или nil.
+func (t *Transpiler) rangeBoundType(r *ast.RangeExpr) ast.Expr {
+	typ := t.typeOf(r)
+	if typ == nil || types.Identical(typ, types.Typ[types.Int]) {
+		return nil
+	}
+	if b, ok := typ.Underlying().(*types.Basic); !ok || b.Info()&types.IsNumeric == 0 {
+		return nil
+	}
+	return t.typeExpr(typ)
+}
+
+// isConstLit сообщает, является ли выражение числовым литералом,
+// возможно со знаком: 10, -2, +0.5.
+func isConstLit(e ast.Expr) bool {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.BasicLit:
+		return x.Kind == token.INT || x.Kind == token.FLOAT
+	case *ast.UnaryExpr:
+		return (x.Op == token.SUB || x.Op == token.ADD) && isConstLit(x.X)
+	}
+	return false
+}
+
+// constSign возвращает знак числового литерала (-1, 0 или 1); ok == false,
+// если выражение не литерал.
+func constSign(e ast.Expr) (sign int, ok bool) {
+	switch x := ast.Unparen(e).(type) {
+	case *ast.BasicLit:
+		var v float64
+		var err error
+		switch x.Kind {
+		case token.INT:
+			var n int64
+			n, err = strconv.ParseInt(x.Value, 0, 64)
+			v = float64(n)
+		case token.FLOAT:
+			v, err = strconv.ParseFloat(x.Value, 64)
+		default:
+			return 0, false
+		}
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case v > 0:
+			return 1, true
+		case v < 0:
+			return -1, true
+		}
+		return 0, true
+	case *ast.UnaryExpr:
+		if x.Op != token.SUB && x.Op != token.ADD {
+			return 0, false
+		}
+		sign, ok = constSign(x.X)
+		if x.Op == token.SUB {
+			sign = -sign
+		}
+		return sign, ok
+	}
+	return 0, false
+}
+
+// isUnitLit сообщает, является ли выражение литералом 1.
+func isUnitLit(e ast.Expr) bool {
+	lit, ok := ast.Unparen(e).(*ast.BasicLit)
+	return ok && lit.Kind == token.INT && lit.Value == "1"
+}
@@ -139,6 +139,427 @@ func bar() (int, error) { return 0, nil }
 	}
 }
 
+func TestFormatFile_Fallback_Preserved(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string) int {
+a := strconv.Atoi(s) ?: 8080
+b := try strconv.Atoi(s) else 0
+return a + b
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	if !strings.Contains(out, "strconv.Atoi(s) ?: 8080") {
+		t.Errorf("FormatFile should preserve '?:' operator\n\nOutput:\n%s", out)
+	}
+	if !strings.Contains(out, "try strconv.Atoi(s) else 0") {
+		t.Errorf("FormatFile should preserve 'try ... else' form\n\nOutput:\n%s", out)
+	}
+}
+
+func TestFormatFile_Retry_Preserved(t *testing.T) {
+	src := `package main
+
+func get() error {
+retry 3 backoff 100ms {
+n := fetch()?
+_ = n
+} catch {
+return err
+}
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	if !strings.Contains(out, "retry 3 backoff 100ms {") {
+		t.Errorf("FormatFile should preserve retry header\n\nOutput:\n%s", out)
+	}
+	if !strings.Contains(out, "} catch {") {
+		t.Errorf("FormatFile should preserve retry catch\n\nOutput:\n%s", out)
+	}
+}
+
+func TestFormatFile_Parallel_Preserved(t *testing.T) {
+	src := `package main
+
+func run() error {
+parallel all {
+go a()?
+go {
+n := b()?
+_ = n
+}
+} catch {
+return err
+}
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"parallel all {", "\tgo a()?", "\tgo {", "} catch {"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_AsyncAwait_Preserved(t *testing.T) {
+	src := `package main
+
+func run() error {
+f := async fetch("a")
+v := await f?
+_ = v
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{`f := async fetch("a")`, "v := await f?"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Coalesce_Preserved(t *testing.T) {
+	src := `package main
+
+func name(u User) string {
+n := u.Nickname??u.Login ?? "anon"
+return n
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	if !strings.Contains(out, `n := u.Nickname ?? u.Login ?? "anon"`) {
+		t.Errorf("FormatFile should preserve '??' operator\n\nOutput:\n%s", out)
+	}
+}
+
+func TestFormatFile_OptChain_Preserved(t *testing.T) {
+	src := `package main
+
+func city(o *Order) string {
+return o?.Customer?.Address?.City ?? "unknown"
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	if !strings.Contains(out, `return o?.Customer?.Address?.City ?? "unknown"`) {
+		t.Errorf("FormatFile should preserve '?.' operator\n\nOutput:\n%s", out)
+	}
+}
+
+func TestFormatFile_Guard_Preserved(t *testing.T) {
+	src := `package main
+
+func get(id int) error {
+guard user, ok := cache[id] else {
+throw NotFound{id}
+}
+_ = user
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	if !strings.Contains(out, "guard user, ok := cache[id] else {\n\t\tthrow NotFound{id}\n\t}") {
+		t.Errorf("FormatFile should preserve guard statement\n\nOutput:\n%s", out)
+	}
+}
+
+func TestFormatFile_Match_Preserved(t *testing.T) {
+	src := `package main
+
+func describe(v any) string {
+return match v { case int n if n > 0: "positive"; case nil: "nil"; default: "?" }
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"return match v {", `case int n if n > 0: "positive"`, `case nil: "nil"`, `default: "?"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_IfSwitchExpr_Preserved(t *testing.T) {
+	src := `package main
+
+func f(a, b, code int) string {
+x := if a > b { a } else { b }
+_ = x
+return switch code { case 200: "ok" default: "error" }
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"x := if a > b {", "} else {", "return switch code {", "default:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+	if _, err := transpiler.FormatFile(out); err != nil {
+		t.Errorf("formatted if/switch expression should parse again: %v", err)
+	}
+}
+
+func TestFormatFile_Enum_Preserved(t *testing.T) {
+	src := `package main
+
+enum Color { Red, Green, Blue }
+
+enum Status: string {
+Active = "active" // активен
+Blocked = "blocked"
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"enum Color { Red, Green, Blue }", "enum Status: string {", "\tActive = \"active\" // активен", "\tBlocked = \"blocked\""} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+	if _, err := transpiler.FormatFile(out); err != nil {
+		t.Errorf("formatted enum should parse again: %v", err)
+	}
+}
+
+func TestFormatFile_Record_Preserved(t *testing.T) {
+	src := `package main
+
+record Point(X, Y int)
+
+record User(ID int64,Name string) derive(json,hash)
+
+record Pair[K comparable, V any](Key K, Value V)
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"record Point(X, Y int)", "record User(ID int64, Name string) derive(json, hash)", "record Pair[K comparable, V any](Key K, Value V)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Interp_Preserved(t *testing.T) {
+	src := `package main
+
+func show(u User, ratio float64) string {
+return $"user {u.Name} ({ratio:%.2f}) {{x}}"
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	want := `return $"user {u.Name} ({ratio:%.2f}) {{x}}"`
+	if !strings.Contains(out, want) {
+		t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+	}
+}
+
+func TestFormatFile_Lambda_Preserved(t *testing.T) {
+	src := `package main
+
+func f(users []User) {
+slices.SortFunc(users, (a, b)=>cmp.Compare(a.Age, b.Age))
+_ = slices.IndexFunc(users, u=>u.Age > 25)
+_ = Map(users, (u User) => u.Name)
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"(a, b) => cmp.Compare(a.Age, b.Age)", "u => u.Age > 25", "(u User) => u.Name"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_ForIn_Preserved(t *testing.T) {
+	src := `package main
+
+func f(xs []int) {
+for i in 0..<len(xs) {
+}
+for i in 10..0 step -2 {
+}
+for i, v in xs {
+}
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"for i in 0..<len(xs) {", "for i in 10..0 step -2 {", "for i, v in xs {"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_DefaultAndNamedArgs_Preserved(t *testing.T) {
+	src := `package main
+
+func Dial(addr string, timeout = 5*time.Second, retries int = 3) error {
+return nil
+}
+
+func main() {
+Dial("x", retries:5)
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"func Dial(addr string, timeout = 5 * time.Second, retries int = 3) error {", `Dial("x", retries: 5)`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Assert_Preserved(t *testing.T) {
+	src := `package main
+
+func f(buf []int, cap int) {
+assert len(buf)<=cap, "buffer overflow"
+assert cap>0
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{`assert len(buf) <= cap, "buffer overflow"`, "assert cap > 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Contracts_Preserved(t *testing.T) {
+	src := `package main
+
+func Transfer(a, b *Acct, amt int) error   requires amt>0 ensures a.Balance>=old(a.Balance)-amt {
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	want := "func Transfer(a, b *Acct, amt int) error requires amt > 0 ensures a.Balance >= old(a.Balance)-amt {"
+	if !strings.Contains(out, want) {
+		t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+	}
+}
+
+func TestFormatFile_Val_Preserved(t *testing.T) {
+	src := `package main
+
+func f() {
+val limit=computeLimit()
+val n = must strconv.Atoi("1")
+m := must strconv.Atoi("2")
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{"val limit = computeLimit()", `val n = must strconv.Atoi("1")`, `m := must strconv.Atoi("2")`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Comprehension_Preserved(t *testing.T) {
+	src := `package main
+
+func f() {
+names:=[u.Name   for u in users if u.Active]
+byID := {u.ID:u for u in users}
+idx := {v: i for i,v in names}
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{
+		"names := [u.Name for u in users if u.Active]",
+		"byID := {u.ID: u for u in users}",
+		"idx := {v: i for i, v in names}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatFile_Pipe_Preserved(t *testing.T) {
+	src := `package main
+
+func f() error {
+result:=input|>parse |>   validate?|> transform(cfg, _)
+input |>
+parse |>
+save?
+return nil
+}
+`
+	out, err := transpiler.FormatFile(src)
+	if err != nil {
+		t.Fatalf("FormatFile returned error: %v", err)
+	}
+	for _, want := range []string{
+		"result := input |> parse |> validate? |> transform(cfg, _)",
+		"\tinput |>\n\t\tparse |>\n\t\tsave?\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatFile should preserve %q\n\nOutput:\n%s", want, out)
+		}
+	}
+}
+
 func TestFormatFile_Idempotent(t *testing.T) {
 	src := `package main
 
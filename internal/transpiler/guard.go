@@ -0,0 +1,272 @@
+package transpiler
+
+import (
+	"go/types"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// transpileGuardStmt разворачивает guard в присваивание и проверку без
+// вложенности:
+//
+//	guard user, ok := cache[id] else { throw NotFound{id} }
+//
+// →
+//
+//	user, ok := cache[id]
+//	if !ok {
+//	    return NotFound{id}
+//	}
+//
+// Для присваивания проверяется последняя переменная: bool — на false,
+// error — на != nil, остальные типы — на нулевое значение. Условие
+// guard cond инвертируется: guard n > 0 → if n <= 0 для целых и
+// if !(n > 0) для float.
+func (t *Transpiler) transpileGuardStmt(s *ast.GuardStmt) []ast.Stmt {
+	if !t.probing && !t.isTerminating(s.Else) {
+		t.errorf(s.Guard, "guard else block must leave the scope (return, throw, panic, break or continue)")
+	}
+
+	var stmts []ast.Stmt
+	var cond ast.Expr
+	switch st := s.Stmt.(type) {
+	case *ast.AssignStmt:
+		stmts = t.transpileAssignStmt(st)
+		v := st.Lhs[len(st.Lhs)-1]
+		if ident, ok := v.(*ast.Ident); ok && ident.Name == "_" {
+			t.errorf(s.Guard, "guard needs a named variable to check, got _")
+		}
+		if t.probing {
+			// _ = _godslProbe(id, v): тип проверяемой переменной для основного прохода.
+			stmts = append(stmts, coalesceAssign(&ast.Ident{NamePos: token.NoPos, Name: "_"}, token.ASSIGN, t.probe(s, v)))
+		}
+		cond = t.guardFailCond(v, t.typeOf(s), len(st.Lhs) > 1)
+	case *ast.ExprStmt:
+		cond = t.negateCond(st.X)
+	default:
+		return []ast.Stmt{t.transpileStmt(s.Stmt)}
+	}
+
+	return append(stmts, &ast.IfStmt{
+		If:   token.NoPos,
+		Cond: cond,
+		Body: &ast.BlockStmt{List: t.transpileStmts(s.Else.List)},
+	})
+}
+
+// guardFailCond строит условие провала guard для переменной v.
+// Если тип неизвестен, v в форме «v, ok :=» считается bool, переменная
+// err — ошибкой, а остальные проверяются через zero.Is.
+func (t *Transpiler) guardFailCond(v ast.Expr, typ types.Type, commaOk bool) ast.Expr {
+	nilIdent := &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	notV := &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: v}
+	if typ == nil {
+		switch {
+		case isErrIdent(v):
+			return &ast.BinaryExpr{X: v, OpPos: token.NoPos, Op: token.NEQ, Y: nilIdent}
+		case commaOk:
+			return notV
+		}
+		return t.zeroCheck(v, zeroUnknown, true)
+	}
+	if types.Identical(typ, types.Universe.Lookup("error").Type()) {
+		return &ast.BinaryExpr{X: v, OpPos: token.NoPos, Op: token.NEQ, Y: nilIdent}
+	}
+	return t.zeroCheck(v, zeroKindOf(typ), true)
+}
+
+// isErrIdent сообщает, является ли выражение переменной err.
+func isErrIdent(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "err"
+}
+
+// orderedOps — сравнения на порядок и их отрицания.
+var orderedOps = map[token.Token]token.Token{
+	token.LSS: token.GEQ,
+	token.GEQ: token.LSS,
+	token.GTR: token.LEQ,
+	token.LEQ: token.GTR,
+}
+
+// negateCond транспилирует условие и строит его отрицание: !x → x,
+// a == b → a != b, остальное → !(cond). Сравнение на порядок меняется на
+// противоположное (a < b → a >= b), только если go/types подтверждает, что
+// операнды — целые числа или строки: для NaN ложны и a < b, и a >= b.
+func (t *Transpiler) negateCond(src ast.Expr) ast.Expr {
+	cond := t.transpileExpr(src)
+	switch c := cond.(type) {
+	case *ast.UnaryExpr:
+		if c.Op == token.NOT {
+			return c.X
+		}
+	case *ast.BinaryExpr:
+		switch c.Op {
+		case token.EQL:
+			return &ast.BinaryExpr{X: c.X, OpPos: c.OpPos, Op: token.NEQ, Y: c.Y}
+		case token.NEQ:
+			return &ast.BinaryExpr{X: c.X, OpPos: c.OpPos, Op: token.EQL, Y: c.Y}
+		}
+		op, ok := orderedOps[c.Op]
+		b, isBinary := src.(*ast.BinaryExpr)
+		if !ok || !isBinary {
+			break
+		}
+		if t.probing {
+			// _godslProbe(id, a) == a && _godslProbe(id, b) == b && a < b:
+			// типы операндов без изменения типа сравнения.
+			probed := &ast.BinaryExpr{X: t.probeOperand(b.Y, c.Y), Op: token.LAND, Y: c}
+			probed = &ast.BinaryExpr{X: t.probeOperand(b.X, c.X), Op: token.LAND, Y: probed}
+			return &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: &ast.ParenExpr{X: probed}}
+		}
+		if t.intOrStringOperands(b.X, b.Y) {
+			return &ast.BinaryExpr{X: c.X, OpPos: c.OpPos, Op: op, Y: c.Y}
+		}
+	case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.IndexExpr, *ast.ParenExpr:
+		return &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: cond}
+	}
+	return &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: &ast.ParenExpr{X: cond}}
+}
+
+// isOrderedCompare сообщает, является ли условие сравнением на порядок,
+// для отрицания которого нужны типы операндов.
+func isOrderedCompare(cond ast.Expr) bool {
+	b, ok := cond.(*ast.BinaryExpr)
+	return ok && orderedOps[b.Op] != token.ILLEGAL
+}
+
+// probeOperand строит _godslProbe(id, x) == x для операнда сравнения src.
+func (t *Transpiler) probeOperand(src, x ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{X: t.probe(src, x), OpPos: token.NoPos, Op: token.EQL, Y: x}
+}
+
+// intOrStringOperands сообщает, что по данным go/types операнды сравнения —
+// целые числа или строки. Нетипизированные константы принимают тип другого
+// операнда и не проверяются.
+func (t *Transpiler) intOrStringOperands(operands ...ast.Expr) bool {
+	known := false
+	for _, x := range operands {
+		typ := t.typeOf(x)
+		if typ == nil {
+			return false
+		}
+		if t.untypedExprs[x] {
+			continue
+		}
+		basic, ok := typ.Underlying().(*types.Basic)
+		if !ok || basic.Info()&(types.IsInteger|types.IsString) == 0 {
+			return false
+		}
+		known = true
+	}
+	return known
+}
+
+// terminatingFuncs — функции и методы по пакетам, после вызова которых
+// выполнение не продолжается: os.Exit, log.Fatal, (*log.Logger).Panic,
+// t.FailNow и т. п.
+var terminatingFuncs = map[string]map[string]bool{
+	"os":      {"Exit": true},
+	"runtime": {"Goexit": true},
+	"log": {
+		"Fatal": true, "Fatalf": true, "Fatalln": true,
+		"Panic": true, "Panicf": true, "Panicln": true,
+	},
+	"testing": {
+		"FailNow": true, "Fatal": true, "Fatalf": true,
+		"SkipNow": true, "Skip": true, "Skipf": true,
+	},
+}
+
+// terminatorProbe — ключ пробного прохода для вызываемой функции, имя
+// которой совпадает с одной из terminatingFuncs.
+type terminatorProbe struct{ *ast.CallExpr }
+
+// mayTerminate сообщает, что вызов x.Name(...) по имени может быть одной
+// из terminatingFuncs: его функцию нужно определить через go/types.
+func mayTerminate(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	for _, funcs := range terminatingFuncs {
+		if funcs[sel.Sel.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminatingCall сообщает, завершает ли вызов выполнение: panic или
+// функция из terminatingFuncs по данным go/types.
+func (t *Transpiler) isTerminatingCall(call *ast.CallExpr) bool {
+	if fun, ok := call.Fun.(*ast.Ident); ok {
+		return fun.Name == "panic" && fun.Obj == nil
+	}
+	fn, ok := t.callees[terminatorProbe{call}].(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	return terminatingFuncs[fn.Pkg().Path()][fn.Name()]
+}
+
+// isTerminating сообщает, покидает ли оператор текущую область видимости:
+// return, throw, break, continue, goto, вызов panic/os.Exit/log.Fatal,
+// бесконечный for без break, а также блок или if/else, оканчивающиеся
+// такими операторами.
+func (t *Transpiler) isTerminating(stmt ast.Stmt) bool {
+	return t.isTerminatingLabeled(stmt, "")
+}
+
+// isTerminatingLabeled — isTerminating для оператора с меткой label.
+func (t *Transpiler) isTerminatingLabeled(stmt ast.Stmt, label string) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt, *ast.ThrowStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok != token.FALLTHROUGH
+	case *ast.BlockStmt:
+		return len(s.List) > 0 && t.isTerminating(s.List[len(s.List)-1])
+	case *ast.IfStmt:
+		return s.Else != nil && t.isTerminating(s.Body) && t.isTerminating(s.Else)
+	case *ast.ForStmt:
+		return s.Cond == nil && !hasBreak(s.Body, label)
+	case *ast.LabeledStmt:
+		return t.isTerminatingLabeled(s.Stmt, s.Label.Name)
+	case *ast.ExprStmt:
+		call, ok := s.X.(*ast.CallExpr)
+		return ok && t.isTerminatingCall(call)
+	}
+	return false
+}
+
+// hasBreak сообщает, есть ли в теле цикла break, который из него выходит:
+// без метки вне вложенных for, switch, select и retry или с меткой label.
+func hasBreak(body ast.Node, label string) bool {
+	found := false
+	var inspect func(n ast.Node, nested bool)
+	inspect = func(n ast.Node, nested bool) {
+		ast.Inspect(n, func(node ast.Node) bool {
+			if found || node == nil {
+				return false
+			}
+			switch node := node.(type) {
+			case *ast.BranchStmt:
+				if node.Tok == token.BREAK {
+					found = node.Label == nil && !nested || node.Label != nil && node.Label.Name == label
+				}
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.RetryStmt:
+				if node != n {
+					inspect(node, true)
+					return false
+				}
+			case *ast.FuncLit:
+				return false
+			}
+			return true
+		})
+	}
+	inspect(body, false)
+	return found
+}
@@ -0,0 +1,52 @@
+package transpiler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// interpVerb — формат выражения в интерполированной строке: один глагол
+// fmt с флагами, шириной и точностью (%5d, %-10s, %.2f, %#v).
+var interpVerb = regexp.MustCompile(`^%[-+# 0]*(\d+|\*)?(\.(\d+|\*)?)?[a-zA-Z]$`)
+
+// transpileInterpolatedLit превращает $"..." в вызов fmt.Sprintf:
+//
+//	$"user {u.Name} has {ratio:%.2f}"
+//
+// становится
+//
+//	fmt.Sprintf("user %v has %.2f", u.Name, ratio)
+//
+// Строка без выражений становится обычным строковым литералом.
+func (t *Transpiler) transpileInterpolatedLit(x *ast.InterpolatedLit) ast.Expr {
+	if len(x.Exprs) == 0 {
+		return &ast.BasicLit{ValuePos: x.ValuePos, Kind: token.STRING, Value: `"` + x.Texts[0] + `"`}
+	}
+
+	var format strings.Builder
+	format.WriteString(strings.ReplaceAll(x.Texts[0], "%", "%%"))
+	args := []ast.Expr{nil}
+	for i, e := range x.Exprs {
+		verb := x.Formats[i]
+		if verb == "" {
+			verb = "%v"
+		} else if !interpVerb.MatchString(verb) {
+			t.errorf(e.Pos(), "invalid format %q in interpolated string", verb)
+		}
+		format.WriteString(verb)
+		format.WriteString(strings.ReplaceAll(x.Texts[i+1], "%", "%%"))
+		args = append(args, t.transpileExpr(e))
+	}
+	args[0] = &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: `"` + format.String() + `"`}
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: x.ValuePos, Name: t.requireImport("fmt")},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: "Sprintf"},
+		},
+		Args: args,
+	}
+}
@@ -0,0 +1,284 @@
+package transpiler
+
+import (
+	"go/types"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// calleeProbe — ключ пробного прохода для сигнатуры вызываемой функции.
+// Для вызова с лямбдой или тернарным оператором в аргументах нужен тип не
+// самого вызова, а его Fun (после вывода параметров типа), поэтому ключ
+// отличается от узла вызова.
+type calleeProbe struct{ *ast.CallExpr }
+
+// needsCalleeType сообщает, нужна ли вызову сигнатура вызываемой функции:
+// в него передаётся лямбда или тернарный оператор.
+func needsCalleeType(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		switch arg.(type) {
+		case *ast.LambdaExpr, *ast.TernaryExpr:
+			return true
+		}
+	}
+	return false
+}
+
+// lambdaTarget — ключ пробного прохода для типа, который ждёт от лямбды
+// контекст: левая часть присваивания, объявленный тип переменной или тип
+// результата функции.
+type lambdaTarget struct{ *ast.LambdaExpr }
+
+// paramType возвращает тип, который ожидает i-й параметр вызова call, или
+// nil, если сигнатура неизвестна.
+func (t *Transpiler) paramType(call *ast.CallExpr, i int) types.Type {
+	sig, ok := t.typeOf(calleeProbe{call}).(*types.Signature)
+	// Сигнатура с параметрами типа означает, что вывод типов не удался.
+	if !ok || sig.TypeParams().Len() > 0 {
+		return nil
+	}
+	params := sig.Params()
+	switch {
+	case sig.Variadic() && i >= params.Len()-1:
+		last := params.At(params.Len() - 1).Type()
+		if slice, ok := last.(*types.Slice); ok && !call.Ellipsis.IsValid() {
+			return slice.Elem()
+		}
+		return last
+	case i < params.Len():
+		return params.At(i).Type()
+	}
+	return nil
+}
+
+// lambdaExpected возвращает тип функции, который ожидает i-й параметр
+// вызова call, или nil, если сигнатура неизвестна.
+func (t *Transpiler) lambdaExpected(call *ast.CallExpr, i int) *types.Signature {
+	typ := t.paramType(call, i)
+	if typ == nil {
+		return nil
+	}
+	fn, _ := typ.Underlying().(*types.Signature)
+	return fn
+}
+
+// transpileTargetLambda транспилирует лямбду, тип которой задаёт не вызов,
+// а контекст: target — выражение ожидаемого типа (левая часть присваивания
+// или *new(T)). В пробном проходе лямбда без аннотаций заменяется на
+// _godslProbe(id, target), и тип target становится ожидаемой сигнатурой.
+func (t *Transpiler) transpileTargetLambda(l *ast.LambdaExpr, target ast.Expr) ast.Expr {
+	if t.probing && !lambdaAnnotated(l) {
+		return t.probe(lambdaTarget{l}, target)
+	}
+	var expected *types.Signature
+	if typ := t.typeOf(lambdaTarget{l}); typ != nil {
+		expected, _ = typ.Underlying().(*types.Signature)
+	}
+	return t.transpileLambda(l, expected)
+}
+
+// newValue строит *new(typ) — выражение типа typ для пробного прохода.
+func newValue(typ ast.Expr) ast.Expr {
+	return &ast.StarExpr{X: &ast.CallExpr{
+		Fun:  &ast.Ident{NamePos: token.NoPos, Name: "new"},
+		Args: []ast.Expr{typ},
+	}}
+}
+
+// transpileLambda превращает лямбду в функциональный литерал. Типы
+// параметров и результата берутся из аннотаций, из ожидаемого типа
+// функции (сигнатуры вызываемой функции, типа переменной или результата,
+// см. transpileTargetLambda) или из типа тела.
+//
+//	slices.SortFunc(users, (a, b) => cmp.Compare(a.Age, b.Age))
+//
+// становится
+//
+//	slices.SortFunc(users, func(a User, b User) int {
+//		return cmp.Compare(a.Age, b.Age)
+//	})
+func (t *Transpiler) transpileLambda(l *ast.LambdaExpr, expected *types.Signature) ast.Expr {
+	annotated := lambdaAnnotated(l)
+	if t.probing {
+		return t.probeLambda(l, annotated)
+	}
+
+	var names []*ast.Ident
+	for _, f := range l.Params.List {
+		names = append(names, f.Names...)
+	}
+	if expected != nil && expected.Params().Len() != len(names) {
+		t.errorf(l.Pos(), "lambda has %d parameters, but %d are expected", len(names), expected.Params().Len())
+		return l
+	}
+
+	params := l.Params
+	if !annotated {
+		if expected == nil {
+			t.errorf(l.Pos(), "cannot infer lambda parameter types; annotate them: (a T) => ...")
+			return l
+		}
+		params = &ast.FieldList{Opening: token.NoPos, Closing: token.NoPos}
+		for i, n := range names {
+			typ := t.typeExpr(expected.Params().At(i).Type())
+			if typ == nil {
+				t.errorf(n.Pos(), "cannot infer type of lambda parameter %s; annotate it: (%s T) => ...", n.Name, n.Name)
+				return l
+			}
+			params.List = append(params.List, &ast.Field{Names: []*ast.Ident{n}, Type: typ})
+		}
+	}
+
+	var results *ast.FieldList
+	switch {
+	case expected != nil:
+		if expected.Results().Len() > 0 {
+			results = &ast.FieldList{}
+			for i := 0; i < expected.Results().Len(); i++ {
+				typ := t.typeExpr(expected.Results().At(i).Type())
+				if typ == nil {
+					t.errorf(l.Pos(), "cannot infer lambda result type")
+					return l
+				}
+				results.List = append(results.List, &ast.Field{Type: typ})
+			}
+		}
+	case l.Block == nil:
+		typ := t.typeExpr(t.typeOf(l))
+		if typ == nil {
+			t.errorf(l.Pos(), "cannot infer lambda result type")
+			return l
+		}
+		results = &ast.FieldList{List: []*ast.Field{{Type: typ}}}
+	default:
+		var ok bool
+		if results, ok = t.blockLambdaResults(l); !ok {
+			t.errorf(l.Pos(), "cannot infer lambda result type")
+			return l
+		}
+	}
+
+	body := &ast.BlockStmt{Lbrace: l.Arrow, Rbrace: token.NoPos}
+	switch {
+	case l.Block != nil:
+		body = l.Block
+	case results == nil:
+		body.List = []ast.Stmt{&ast.ExprStmt{X: l.Body}}
+	default:
+		body.List = []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{l.Body}}}
+	}
+
+	return t.transpileFuncLit(&ast.FuncLit{
+		Type: &ast.FuncType{Func: token.NoPos, Params: params, Results: results},
+		Body: body,
+	})
+}
+
+// blockLambdaResults выводит типы результатов лямбды с телом-блоком по
+// значениям её return: каждое значение запрошено пробным проходом (см.
+// probeLambdaReturn), общий тип выводится как у веток match. Лямбда без
+// return со значениями ничего не возвращает. ok = false, если число
+// значений в return различается или тип вывести нельзя.
+func (t *Transpiler) blockLambdaResults(l *ast.LambdaExpr) (*ast.FieldList, bool) {
+	var values [][]ast.Expr
+	for i, ret := range lambdaReturnStmts(l.Block) {
+		if i > 0 && len(ret.Results) != len(values) {
+			return nil, false
+		}
+		if i == 0 {
+			values = make([][]ast.Expr, len(ret.Results))
+		}
+		for j, res := range ret.Results {
+			values[j] = append(values[j], lambdaReturn{res})
+		}
+	}
+	if len(values) == 0 {
+		return nil, true
+	}
+	results := &ast.FieldList{}
+	for _, vs := range values {
+		typ := t.typeExpr(t.branchGoType(vs))
+		if typ == nil {
+			return nil, false
+		}
+		results.List = append(results.List, &ast.Field{Type: typ})
+	}
+	return results, true
+}
+
+// lambdaReturn — ключ пробного прохода для значения в return лямбды с
+// телом-блоком.
+type lambdaReturn struct{ ast.Expr }
+
+// lambdaReturnStmts возвращает return тела лямбды, не считая return
+// вложенных функций и лямбд.
+func lambdaReturnStmts(body *ast.BlockStmt) []*ast.ReturnStmt {
+	var rets []*ast.ReturnStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.LambdaExpr:
+			return false
+		case *ast.ReturnStmt:
+			rets = append(rets, n)
+		}
+		return true
+	})
+	return rets
+}
+
+// probeLambdaReturn заменяет в пробном проходе return value лямбды с
+// телом-блоком на _ = _godslProbe(id, value); return: у функционального
+// литерала пробного прохода нет результатов.
+func (t *Transpiler) probeLambdaReturn(s *ast.ReturnStmt) []ast.Stmt {
+	var stmts []ast.Stmt
+	for _, res := range s.Results {
+		stmts = append(stmts, t.transpileAssignStmt(&ast.AssignStmt{
+			Lhs: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{t.probe(lambdaReturn{res}, res)},
+		})...)
+	}
+	return append(stmts, &ast.ReturnStmt{Return: s.Return})
+}
+
+// probeLambda строит лямбду для пробного прохода. Лямбда без аннотаций
+// становится nil: так go/types выводит параметры типа вызываемой функции
+// по остальным аргументам. У лямбды с аннотациями запрашивается тип тела:
+// func(a T) { _ = _godslProbe(id, body) }, а у лямбды с телом-блоком —
+// типы значений её return.
+func (t *Transpiler) probeLambda(l *ast.LambdaExpr, annotated bool) ast.Expr {
+	if !annotated {
+		return &ast.Ident{NamePos: l.Pos(), Name: "nil"}
+	}
+	body := l.Block
+	if body != nil {
+		if t.lambdaReturns == nil {
+			t.lambdaReturns = make(map[*ast.ReturnStmt]bool)
+		}
+		for _, ret := range lambdaReturnStmts(body) {
+			t.lambdaReturns[ret] = true
+		}
+	}
+	if body == nil {
+		body = &ast.BlockStmt{Lbrace: l.Arrow, Rbrace: token.NoPos, List: []ast.Stmt{&ast.AssignStmt{
+			Lhs: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}},
+			Tok: token.ASSIGN,
+			Rhs: []ast.Expr{t.probe(l, l.Body)},
+		}}}
+	}
+	return t.transpileFuncLit(&ast.FuncLit{
+		Type: &ast.FuncType{Func: token.NoPos, Params: l.Params},
+		Body: body,
+	})
+}
+
+// lambdaAnnotated сообщает, заданы ли типы параметров лямбды.
+func lambdaAnnotated(l *ast.LambdaExpr) bool {
+	for _, f := range l.Params.List {
+		if f.Type == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,507 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// matchSink строит операторы, передающие значение ветки match дальше:
+// присваивание переменной, return или вычисление выражения.
+type matchSink func(value ast.Expr) []ast.Stmt
+
+// predeclaredTypes — встроенные типы Go, которые в образце match
+// однозначно означают проверку типа, а не значения.
+var predeclaredTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true,
+	"float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"rune": true, "string": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// transpileMatchAssign разворачивает присваивание match-выражения в
+// объявление переменной и обычный switch:
+//
+//	label := match v { case int n: itoa(n); default: "?" }
+//
+// →
+//
+//	var label string
+//	switch n := v.(type) {
+//	case int:
+//	    label = itoa(n)
+//	default:
+//	    label = "?"
+//	}
+func (t *Transpiler) transpileMatchAssign(lhs ast.Expr, tok token.Token, m *ast.MatchExpr) []ast.Stmt {
+	lhs = t.transpileExpr(lhs)
+	sink := func(v ast.Expr) []ast.Stmt {
+		return []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, v)}
+	}
+
+	if tok == token.DEFINE {
+		name, ok := lhs.(*ast.Ident)
+		if !ok {
+			return []ast.Stmt{coalesceAssign(lhs, tok, t.transpileMatchExpr(m))}
+		}
+		typ := t.matchType(m)
+		if typ == nil {
+			t.requireResolved(m)
+			typ = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+		}
+		decl := &ast.DeclStmt{Decl: &ast.GenDecl{
+			TokPos: token.NoPos,
+			Tok:    token.VAR,
+			Specs:  []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{name}, Type: typ}},
+		}}
+		return append([]ast.Stmt{decl}, t.lowerMatch(m, sink, nil)...)
+	}
+
+	// Без default переменная получает нулевое значение, как и при :=.
+	var zero ast.Expr
+	if !hasDefaultArm(m) {
+		zero = t.matchZero(m, nil)
+		if zero == nil {
+			zero = t.zeroCall("Like", lhs)
+		}
+	}
+	return t.lowerMatch(m, sink, zero)
+}
+
+// transpileMatchReturn разворачивает return match ... в switch, каждая
+// ветка которого возвращает своё значение.
+func (t *Transpiler) transpileMatchReturn(m *ast.MatchExpr) []ast.Stmt {
+	return t.lowerMatch(m, returnSink, t.matchReturnZero(m, t.returnTypeHint))
+}
+
+// transpileMatchStmt разворачивает match, значение которого не используется:
+// ветки вычисляются как операторы (обычно это вызовы функций).
+func (t *Transpiler) transpileMatchStmt(m *ast.MatchExpr) []ast.Stmt {
+	return t.lowerMatch(m, func(v ast.Expr) []ast.Stmt {
+		return []ast.Stmt{&ast.ExprStmt{X: v}}
+	}, nil)
+}
+
+// transpileMatchExpr транспилирует match внутри произвольного выражения в IIFE:
+//
+//	func() T { switch ... { case ...: return ... }; return <нулевое значение> }()
+func (t *Transpiler) transpileMatchExpr(m *ast.MatchExpr) ast.Expr {
+	typ := t.matchType(m)
+	if typ == nil {
+		t.requireResolved(m)
+		typ = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+	}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    token.NoPos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   t.lowerMatch(m, returnSink, t.matchReturnZero(m, typ)),
+				Rbrace: token.NoPos,
+			},
+		},
+	}
+}
+
+// returnSink возвращает значение ветки из функции.
+func returnSink(v ast.Expr) []ast.Stmt {
+	return []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{v}}}
+}
+
+// matchReturnZero возвращает значение для return, если ни одна ветка не
+// подошла, или nil, если у match есть default.
+func (t *Transpiler) matchReturnZero(m *ast.MatchExpr, hint ast.Expr) ast.Expr {
+	if hasDefaultArm(m) {
+		return nil
+	}
+	if zero := t.matchZero(m, hint); zero != nil {
+		return zero
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+}
+
+// matchZero возвращает нулевое значение типа результата match или nil,
+// если тип неизвестен.
+func (t *Transpiler) matchZero(m *ast.MatchExpr, hint ast.Expr) ast.Expr {
+	return t.branchZero(armValues(m), hint)
+}
+
+// matchType выводит тип результата match (см. branchType).
+func (t *Transpiler) matchType(m *ast.MatchExpr) ast.Expr {
+	return t.branchType(armValues(m))
+}
+
+// armValues возвращает значения веток match.
+func armValues(m *ast.MatchExpr) []ast.Expr {
+	values := make([]ast.Expr, len(m.Arms))
+	for i, arm := range m.Arms {
+		values[i] = arm.Value
+	}
+	return values
+}
+
+// branchZero возвращает нулевое значение типа результата ветвящегося
+// выражения (match, if или switch) или nil, если тип неизвестен.
+func (t *Transpiler) branchZero(values []ast.Expr, hint ast.Expr) ast.Expr {
+	if zero := t.zeroValue(t.branchGoType(values)); zero != nil {
+		return zero
+	}
+	typ := t.branchType(values)
+	if typ == nil {
+		typ = hint
+	}
+	if typ == nil {
+		return nil
+	}
+	if ident, ok := typ.(*ast.Ident); ok && ident.Name == "any" {
+		return &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	}
+	return zeroOfTypeExpr(typ)
+}
+
+// branchType выводит тип результата ветвящегося выражения: общий тип
+// значений веток по данным go/types (см. branchGoType), затем по литералам
+// (как literalBranchType), затем по типу результата текущей функции.
+// Возвращает nil, если вывести не удалось.
+func (t *Transpiler) branchType(values []ast.Expr) ast.Expr {
+	if typ := t.typeExpr(t.branchGoType(values)); typ != nil {
+		return typ
+	}
+	var typ ast.Expr
+	for _, v := range values {
+		lit := inferLiteralType(v)
+		if lit == nil {
+			continue
+		}
+		if typ == nil || numericRank(typ) > 0 && numericRank(lit) > numericRank(typ) {
+			typ = lit
+		}
+	}
+	if typ != nil {
+		return typ
+	}
+	return t.returnTypeHint
+}
+
+// branchGoType выводит общий тип значений веток так же, как Go выводит
+// параметр типа обобщённой функции по аргументам: типизированное значение
+// задаёт тип, а нетипизированные константы получают тип по умолчанию
+// старшего вида (int < rune < float64 < complex128). Возвращает nil, если
+// типы веток неизвестны.
+func (t *Transpiler) branchGoType(values []ast.Expr) types.Type {
+	var constType types.Type
+	for _, v := range values {
+		typ := t.typeOf(v)
+		if typ == nil {
+			continue
+		}
+		if !t.untypedExprs[v] {
+			return typ
+		}
+		if constType == nil || constRank(typ) > constRank(constType) {
+			constType = typ
+		}
+	}
+	return constType
+}
+
+// constRank возвращает старшинство типа по умолчанию нетипизированной
+// числовой константы: int < rune < float64 < complex128. Для остальных
+// типов — 0.
+func constRank(typ types.Type) int {
+	basic, ok := typ.(*types.Basic)
+	if !ok {
+		return 0
+	}
+	switch basic.Kind() {
+	case types.Int:
+		return 1
+	case types.Int32:
+		return 2
+	case types.Float64:
+		return 3
+	case types.Complex128:
+		return 4
+	}
+	return 0
+}
+
+// hasDefaultArm сообщает, есть ли у match ветка default.
+func hasDefaultArm(m *ast.MatchExpr) bool {
+	for _, arm := range m.Arms {
+		if arm.Patterns == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isTypeMatch сообщает, сопоставляет ли match типы: хотя бы одна ветка
+// привязывает переменную или содержит образец, который может быть только
+// типом ([]T, map[K]V, встроенный тип и т. п.).
+func isTypeMatch(m *ast.MatchExpr) bool {
+	for _, arm := range m.Arms {
+		if arm.Binding != nil {
+			return true
+		}
+		for _, pat := range arm.Patterns {
+			switch p := pat.(type) {
+			case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType:
+				return true
+			case *ast.Ident:
+				if predeclaredTypes[p.Name] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// usesIdent сообщает, упоминается ли имя name в узлах.
+func usesIdent(name string, nodes ...ast.Node) bool {
+	found := false
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		ast.Inspect(n, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// armBinding возвращает переменную ветки, если она используется в условии
+// или значении ветки.
+func armBinding(arm *ast.MatchArm) *ast.Ident {
+	b := arm.Binding
+	if b == nil || b.Name == "_" {
+		return nil
+	}
+	var guard ast.Node
+	if arm.Guard != nil {
+		guard = arm.Guard
+	}
+	if !usesIdent(b.Name, arm.Value, guard) {
+		return nil
+	}
+	return b
+}
+
+// newMatchTemp возвращает имя временной переменной для match.
+func (t *Transpiler) newMatchTemp() *ast.Ident {
+	t.matchCount++
+	name := "_godslMatch"
+	if t.matchCount > 1 {
+		name += strconv.Itoa(t.matchCount)
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: name}
+}
+
+// lowerMatch строит switch (или цепочку if для веток с условиями),
+// передавая значение каждой ветки в sink. Если у match нет default,
+// а zero не nil, добавляется ветка default со значением zero.
+func (t *Transpiler) lowerMatch(m *ast.MatchExpr, sink matchSink, zero ast.Expr) []ast.Stmt {
+	armValue := func(arm *ast.MatchArm) []ast.Stmt {
+		return sink(t.probe(arm.Value, t.transpileExpr(arm.Value)))
+	}
+	var def []ast.Stmt
+	for _, arm := range m.Arms {
+		if arm.Patterns == nil {
+			def = armValue(arm)
+		}
+	}
+	if def == nil && zero != nil {
+		def = sink(zero)
+	}
+
+	subj := t.transpileExpr(m.X)
+	hasGuard := false
+	for _, arm := range m.Arms {
+		if arm.Guard != nil {
+			hasGuard = true
+		}
+	}
+	if hasGuard {
+		// Субъект, образцы и условия попадают в сравнения, собранные из
+		// разных строк исходника; без позиций printer не переносит строки.
+		clearPositions(subj)
+		for _, arm := range m.Arms {
+			for _, pat := range arm.Patterns {
+				clearPositions(pat)
+			}
+			if arm.Guard != nil {
+				clearPositions(arm.Guard)
+			}
+		}
+	}
+
+	if isTypeMatch(m) {
+		if hasGuard {
+			return t.lowerTypeMatchChain(m, subj, armValue, def)
+		}
+		return []ast.Stmt{t.lowerTypeSwitch(m, subj, armValue, def)}
+	}
+	return []ast.Stmt{t.lowerValueSwitch(m, subj, hasGuard, armValue, def)}
+}
+
+// lowerTypeSwitch строит type switch. Если все ветки привязывают одно и
+// то же имя, оно становится переменной switch; иначе значение хранится
+// во временной переменной и присваивается привязкам внутри веток.
+func (t *Transpiler) lowerTypeSwitch(m *ast.MatchExpr, subj ast.Expr, armValue func(*ast.MatchArm) []ast.Stmt, def []ast.Stmt) ast.Stmt {
+	var names []string
+	seen := make(map[string]bool)
+	for _, arm := range m.Arms {
+		if b := armBinding(arm); b != nil && !seen[b.Name] {
+			seen[b.Name] = true
+			names = append(names, b.Name)
+		}
+	}
+	var switchVar *ast.Ident
+	perArm := false
+	switch {
+	case len(names) == 1:
+		switchVar = &ast.Ident{NamePos: token.NoPos, Name: names[0]}
+	case len(names) > 1:
+		switchVar = t.newMatchTemp()
+		perArm = true
+	}
+
+	var clauses []ast.Stmt
+	for _, arm := range m.Arms {
+		if arm.Patterns == nil {
+			continue
+		}
+		var body []ast.Stmt
+		if b := armBinding(arm); b != nil && perArm {
+			body = append(body, coalesceAssign(&ast.Ident{NamePos: token.NoPos, Name: b.Name}, token.DEFINE, switchVar))
+		}
+		body = append(body, armValue(arm)...)
+		clauses = append(clauses, &ast.CaseClause{Case: token.NoPos, List: arm.Patterns, Colon: token.NoPos, Body: body})
+	}
+	if def != nil {
+		clauses = append(clauses, &ast.CaseClause{Case: token.NoPos, Colon: token.NoPos, Body: def})
+	}
+
+	var assign ast.Stmt = &ast.ExprStmt{X: &ast.TypeAssertExpr{X: subj}}
+	if switchVar != nil {
+		assign = coalesceAssign(switchVar, token.DEFINE, &ast.TypeAssertExpr{X: subj})
+	}
+	return &ast.TypeSwitchStmt{
+		Switch: token.NoPos,
+		Assign: assign,
+		Body:   &ast.BlockStmt{Lbrace: token.NoPos, List: clauses, Rbrace: token.NoPos},
+	}
+}
+
+// lowerTypeMatchChain строит цепочку if/else if для сопоставления типов
+// с условиями-охранниками, которые type switch выразить не может:
+//
+//	if n, _godslOk := v.(int); _godslOk && n > 0 { ... } else if v == nil { ... } else { ... }
+func (t *Transpiler) lowerTypeMatchChain(m *ast.MatchExpr, subj ast.Expr, armValue func(*ast.MatchArm) []ast.Stmt, def []ast.Stmt) []ast.Stmt {
+	var pre []ast.Stmt
+	if !isSimpleRef(subj) {
+		tmp := t.newMatchTemp()
+		pre = append(pre, coalesceAssign(tmp, token.DEFINE, subj))
+		subj = tmp
+	}
+	okIdent := &ast.Ident{NamePos: token.NoPos, Name: "_godslOk"}
+
+	var branches []*ast.IfStmt
+	for _, arm := range m.Arms {
+		if arm.Patterns == nil {
+			continue
+		}
+		body := armValue(arm)
+		for _, pat := range arm.Patterns {
+			branch := &ast.IfStmt{If: token.NoPos, Body: &ast.BlockStmt{List: body}}
+			if ident, ok := pat.(*ast.Ident); ok && ident.Name == "nil" {
+				branch.Cond = &ast.BinaryExpr{X: subj, OpPos: token.NoPos, Op: token.EQL, Y: pat}
+			} else {
+				var bind ast.Expr = &ast.Ident{NamePos: token.NoPos, Name: "_"}
+				if b := armBinding(arm); b != nil {
+					bind = &ast.Ident{NamePos: token.NoPos, Name: b.Name}
+				}
+				branch.Init = &ast.AssignStmt{
+					Lhs:    []ast.Expr{bind, okIdent},
+					TokPos: token.NoPos,
+					Tok:    token.DEFINE,
+					Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: subj, Type: pat}},
+				}
+				branch.Cond = okIdent
+			}
+			if arm.Guard != nil {
+				branch.Cond = &ast.BinaryExpr{X: branch.Cond, OpPos: token.NoPos, Op: token.LAND, Y: t.transpileExpr(arm.Guard)}
+			}
+			branches = append(branches, branch)
+		}
+	}
+	if len(branches) == 0 {
+		return append(pre, def...)
+	}
+	for i := len(branches) - 1; i > 0; i-- {
+		branches[i-1].Else = branches[i]
+	}
+	if def != nil {
+		branches[len(branches)-1].Else = &ast.BlockStmt{List: def}
+	}
+	return append(pre, branches[0])
+}
+
+// lowerValueSwitch строит switch по значению. Если у веток есть условия,
+// используется switch без тега: case (v == 1 || v == 2) && guard.
+func (t *Transpiler) lowerValueSwitch(m *ast.MatchExpr, subj ast.Expr, hasGuard bool, armValue func(*ast.MatchArm) []ast.Stmt, def []ast.Stmt) ast.Stmt {
+	sw := &ast.SwitchStmt{Switch: token.NoPos}
+	if hasGuard && !isSimpleRef(subj) {
+		tmp := t.newMatchTemp()
+		sw.Init = coalesceAssign(tmp, token.DEFINE, subj)
+		subj = tmp
+	}
+	if !hasGuard {
+		sw.Tag = subj
+	}
+
+	var clauses []ast.Stmt
+	for _, arm := range m.Arms {
+		if arm.Patterns == nil {
+			continue
+		}
+		list := t.transpileExprs(arm.Patterns)
+		if hasGuard {
+			var cond ast.Expr
+			for _, pat := range list {
+				eq := &ast.BinaryExpr{X: subj, OpPos: token.NoPos, Op: token.EQL, Y: pat}
+				if cond == nil {
+					cond = eq
+				} else {
+					cond = &ast.BinaryExpr{X: cond, OpPos: token.NoPos, Op: token.LOR, Y: eq}
+				}
+			}
+			if arm.Guard != nil {
+				if len(list) > 1 {
+					cond = &ast.ParenExpr{X: cond}
+				}
+				cond = &ast.BinaryExpr{X: cond, OpPos: token.NoPos, Op: token.LAND, Y: t.transpileExpr(arm.Guard)}
+			}
+			list = []ast.Expr{cond}
+		}
+		clauses = append(clauses, &ast.CaseClause{Case: token.NoPos, List: list, Colon: token.NoPos, Body: armValue(arm)})
+	}
+	if def != nil {
+		clauses = append(clauses, &ast.CaseClause{Case: token.NoPos, Colon: token.NoPos, Body: def})
+	}
+	sw.Body = &ast.BlockStmt{Lbrace: token.NoPos, List: clauses, Rbrace: token.NoPos}
+	return sw
+}
@@ -0,0 +1,262 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// optGuard — проверка на nil одного звена опциональной цепочки.
+type optGuard struct {
+	check ast.Expr // выражение, сравниваемое с nil
+	init  ast.Stmt // _godslOpt := <звено>, если звено нельзя вычислять повторно; или nil
+}
+
+// hasOptChain сообщает, содержит ли цепочка селекторов, вызовов и индексов
+// выражения опциональный селектор ?.
+func hasOptChain(expr ast.Expr) bool {
+	for {
+		switch x := expr.(type) {
+		case *ast.OptSelectorExpr:
+			return true
+		case *ast.SelectorExpr:
+			expr = x.X
+		case *ast.CallExpr:
+			expr = x.Fun
+		case *ast.IndexExpr:
+			expr = x.X
+		default:
+			return false
+		}
+	}
+}
+
+// lowerOptChain раскладывает опциональную цепочку на проверки звеньев и
+// итоговое выражение, в котором ?. заменены обычными селекторами:
+//
+//	order?.Customer?.City → [order != nil, order.Customer != nil], order.Customer.City
+//
+// Звенья с вызовами сохраняются во временные переменные, чтобы не
+// вычисляться дважды. Звенья, которые по данным go/types не могут быть
+// nil (например, структуры), не проверяются.
+func (t *Transpiler) lowerOptChain(expr ast.Expr) ([]optGuard, ast.Expr) {
+	switch x := expr.(type) {
+	case *ast.OptSelectorExpr:
+		guards, recv := t.lowerOptChain(x.X)
+		if typ := t.typeOf(x.X); typ != nil && !isNilable(typ) {
+			return guards, &ast.SelectorExpr{X: recv, Sel: x.Sel}
+		}
+		g := optGuard{}
+		if !isSimpleRef(recv) {
+			t.optCount++
+			name := "_godslOpt"
+			if t.optCount > 1 {
+				name += strconv.Itoa(t.optCount)
+			}
+			g.init = coalesceAssign(&ast.Ident{NamePos: token.NoPos, Name: name}, token.DEFINE, recv)
+			recv = &ast.Ident{NamePos: token.NoPos, Name: name}
+		}
+		g.check = &ast.BinaryExpr{X: recv, OpPos: token.NoPos, Op: token.NEQ, Y: &ast.Ident{NamePos: token.NoPos, Name: "nil"}}
+		return append(guards, g), &ast.SelectorExpr{X: recv, Sel: x.Sel}
+	case *ast.SelectorExpr:
+		guards, recv := t.lowerOptChain(x.X)
+		return guards, &ast.SelectorExpr{X: recv, Sel: x.Sel}
+	case *ast.CallExpr:
+		guards, fun := t.lowerOptChain(x.Fun)
+		return guards, &ast.CallExpr{Fun: fun, Args: t.transpileExprs(x.Args), Ellipsis: x.Ellipsis}
+	case *ast.IndexExpr:
+		guards, recv := t.lowerOptChain(x.X)
+		return guards, &ast.IndexExpr{X: recv, Index: t.transpileExpr(x.Index)}
+	}
+	return nil, t.transpileExpr(expr)
+}
+
+// probeOptChain строит форму цепочки для пробного прохода: ?. заменены
+// обычными селекторами, а получатели и вся цепочка обёрнуты маркером.
+func (t *Transpiler) probeOptChain(expr ast.Expr) ast.Expr {
+	switch x := expr.(type) {
+	case *ast.OptSelectorExpr:
+		return &ast.SelectorExpr{X: t.probe(x.X, t.probeOptChain(x.X)), Sel: x.Sel}
+	case *ast.SelectorExpr:
+		return &ast.SelectorExpr{X: t.probeOptChain(x.X), Sel: x.Sel}
+	case *ast.CallExpr:
+		return &ast.CallExpr{Fun: t.probeOptChain(x.Fun), Args: t.transpileExprs(x.Args), Ellipsis: x.Ellipsis}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: t.probeOptChain(x.X), Index: t.transpileExpr(x.Index)}
+	}
+	return t.transpileExpr(expr)
+}
+
+// guardedStmts оборачивает body в последовательные проверки звеньев.
+// Соседние проверки без временных переменных объединяются через &&:
+//
+//	if order != nil && order.Customer != nil { body }
+func guardedStmts(guards []optGuard, body []ast.Stmt) []ast.Stmt {
+	stmts := body
+	for i := len(guards); i > 0; {
+		j := i - 1
+		for j > 0 && guards[j].init == nil {
+			j--
+		}
+		cond := guards[j].check
+		for _, g := range guards[j+1 : i] {
+			cond = &ast.BinaryExpr{X: cond, OpPos: token.NoPos, Op: token.LAND, Y: g.check}
+		}
+		stmts = []ast.Stmt{&ast.IfStmt{
+			If:   token.NoPos,
+			Init: guards[j].init,
+			Cond: cond,
+			Body: &ast.BlockStmt{List: stmts},
+		}}
+		i = j
+	}
+	return stmts
+}
+
+// transpileOptChainAssign разворачивает присваивание опциональной цепочки:
+//
+//	city := order?.Customer?.City
+//
+// →
+//
+//	var city string
+//	if order != nil && order.Customer != nil {
+//	    city = order.Customer.City
+//	}
+//
+// Тип переменной берётся из go/types, а если он неизвестен — из hint
+// (например, из литерала справа от ??).
+func (t *Transpiler) transpileOptChainAssign(lhs ast.Expr, tok token.Token, root ast.Expr, hint ast.Expr) []ast.Stmt {
+	if t.probing {
+		return []ast.Stmt{coalesceAssign(t.transpileExpr(lhs), tok, t.transpileExpr(root))}
+	}
+	lhs = t.transpileExpr(lhs)
+	typ := t.typeOf(root)
+	guards, value := t.lowerOptChain(root)
+	body := []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, value)}
+
+	if tok == token.DEFINE {
+		texpr := t.typeExpr(typ)
+		if texpr == nil {
+			texpr = hint
+		}
+		name, ok := lhs.(*ast.Ident)
+		if texpr == nil || !ok {
+			t.errorf(root.Pos(), "cannot infer type of optional chain; declare the variable with var first")
+			return []ast.Stmt{coalesceAssign(lhs, tok, value)}
+		}
+		decl := &ast.DeclStmt{Decl: &ast.GenDecl{
+			TokPos: token.NoPos,
+			Tok:    token.VAR,
+			Specs:  []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{name}, Type: texpr}},
+		}}
+		return append([]ast.Stmt{decl}, guardedStmts(guards, body)...)
+	}
+
+	// lhs = <нулевое значение>; if ... { lhs = value }
+	zero := t.zeroValue(typ)
+	if zero == nil && hint != nil {
+		zero = zeroOfTypeExpr(hint)
+	}
+	if zero == nil {
+		zero = t.zeroCall("Like", lhs)
+	}
+	return append([]ast.Stmt{coalesceAssign(lhs, token.ASSIGN, zero)}, guardedStmts(guards, body)...)
+}
+
+// transpileOptChainReturn разворачивает return опциональной цепочки:
+//
+//	return a?.B()?.C()
+//
+// →
+//
+//	if a != nil {
+//	    if _godslOpt := a.B(); _godslOpt != nil {
+//	        return _godslOpt.C()
+//	    }
+//	}
+//	return ""
+func (t *Transpiler) transpileOptChainReturn(root ast.Expr) []ast.Stmt {
+	if t.probing {
+		return []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{t.transpileExpr(root)}}}
+	}
+	zero := t.zeroValue(t.typeOf(root))
+	if zero == nil && t.returnTypeHint != nil {
+		zero = zeroOfTypeExpr(t.returnTypeHint)
+	}
+	if zero == nil {
+		t.errorf(root.Pos(), "cannot infer zero value of optional chain")
+		zero = &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	}
+	return t.optChainReturnStmts(root, zero)
+}
+
+// optChainReturnStmts строит проверки с return value и return zero в конце.
+func (t *Transpiler) optChainReturnStmts(root ast.Expr, zero ast.Expr) []ast.Stmt {
+	guards, value := t.lowerOptChain(root)
+	stmts := guardedStmts(guards, []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{value}}})
+	return append(stmts, &ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{zero}})
+}
+
+// transpileOptChainStmt разворачивает оператор-выражение: a?.B()?.Close()
+// вызывается только если все звенья не nil.
+func (t *Transpiler) transpileOptChainStmt(root ast.Expr) []ast.Stmt {
+	if t.probing {
+		return []ast.Stmt{&ast.ExprStmt{X: t.transpileExpr(root)}}
+	}
+	guards, value := t.lowerOptChain(root)
+	return guardedStmts(guards, []ast.Stmt{&ast.ExprStmt{X: value}})
+}
+
+// transpileOptChainExpr транспилирует опциональную цепочку внутри
+// произвольного выражения в IIFE с типом из go/types (или any).
+func (t *Transpiler) transpileOptChainExpr(root ast.Expr) ast.Expr {
+	if t.probing {
+		return t.probe(root, t.probeOptChain(root))
+	}
+	typ := t.typeOf(root)
+	retType := t.typeExpr(typ)
+	zero := t.zeroValue(typ)
+	if retType == nil || zero == nil {
+		t.requireResolved(root)
+		retType = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+		zero = &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    token.NoPos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: retType}}},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   t.optChainReturnStmts(root, zero),
+				Rbrace: token.NoPos,
+			},
+		},
+	}
+}
+
+// zeroKindOf возвращает вид нулевого значения для известного типа.
+func zeroKindOf(typ types.Type) zeroKind {
+	if typ == nil {
+		return zeroUnknown
+	}
+	if isNilable(typ) {
+		return zeroNil
+	}
+	if b, ok := typ.Underlying().(*types.Basic); ok {
+		switch {
+		case b.Info()&types.IsString != 0:
+			return zeroString
+		case b.Info()&types.IsBoolean != 0:
+			return zeroBool
+		case b.Info()&types.IsNumeric != 0:
+			return zeroNumber
+		}
+	}
+	return zeroUnknown
+}
@@ -0,0 +1,212 @@
+package transpiler
+
+import (
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// parallelScope описывает ветку parallel-блока: ? и throw в ней отправляют
+// ошибку в общий канал, отменяют контекст и завершают горутину.
+// Реализует errTarget.
+type parallelScope struct {
+	errsVar   string // буферизованный канал ошибок
+	cancelVar string // функция отмены производного контекста
+}
+
+// propagate передаёт ошибку ветки блоку:
+//
+//	_godslErrs <- err
+//	_godslCancel()
+//	return
+func (s *parallelScope) propagate(errExpr ast.Expr) []ast.Stmt {
+	return []ast.Stmt{
+		&ast.SendStmt{
+			Chan:  &ast.Ident{NamePos: token.NoPos, Name: s.errsVar},
+			Arrow: token.NoPos,
+			Value: errExpr,
+		},
+		&ast.ExprStmt{X: &ast.CallExpr{
+			Fun: &ast.Ident{NamePos: token.NoPos, Name: s.cancelVar},
+		}},
+		&ast.ReturnStmt{Return: token.NoPos},
+	}
+}
+
+// transpileParallelStmt транспилирует parallel-блок:
+//
+//	parallel {
+//	    go a()?
+//	    go b()?
+//	} catch {
+//	    log.Println(err)
+//	}
+//
+// →
+//
+//	{
+//	    ctx, _godslCancel := context.WithCancel(context.Background())
+//	    var _godslWg sync.WaitGroup
+//	    _godslErrs := make(chan error, 2)
+//	    _godslWg.Add(2)
+//	    go func(ctx context.Context) {
+//	        defer _godslWg.Done()
+//	        if err := a(); err != nil {
+//	            _godslErrs <- err
+//	            _godslCancel()
+//	            return
+//	        }
+//	    }(ctx)
+//	    go func(ctx context.Context) { ... }(ctx)
+//	    _godslWg.Wait()
+//	    _godslCancel()
+//	    close(_godslErrs)
+//	    if err := <-_godslErrs; err != nil {
+//	        log.Println(err)
+//	    }
+//	}
+//
+// Ветки видят производный контекст ctx, который отменяется первой ошибкой.
+// Если у функции есть параметр context.Context, контекст производится от
+// него. В режиме parallel all в catch передаются все ошибки, объединённые
+// errors.Join. Используются только пакеты стандартной библиотеки.
+func (t *Transpiler) transpileParallelStmt(s *ast.ParallelStmt) ast.Stmt {
+	t.parallelCount++
+	suffix := ""
+	if t.parallelCount > 1 {
+		suffix = strconv.Itoa(t.parallelCount)
+	}
+	scope := &parallelScope{
+		errsVar:   "_godslErrs" + suffix,
+		cancelVar: "_godslCancel" + suffix,
+	}
+	wg := "_godslWg" + suffix
+
+	contextName := t.requireImport("context")
+	syncName := t.requireImport("sync")
+
+	ctxName := t.ctxName
+	var parent ast.Expr = &ast.Ident{NamePos: token.NoPos, Name: ctxName}
+	if ctxName == "" {
+		ctxName = "ctx"
+		parent = &ast.CallExpr{Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: contextName},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: "Background"},
+		}}
+	}
+
+	ident := func(name string) *ast.Ident { return &ast.Ident{NamePos: token.NoPos, Name: name} }
+	call := func(fun ast.Expr, args ...ast.Expr) *ast.CallExpr { return &ast.CallExpr{Fun: fun, Args: args} }
+	method := func(recv, name string) ast.Expr { return &ast.SelectorExpr{X: ident(recv), Sel: ident(name)} }
+	n := &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: strconv.Itoa(len(s.Body.List))}
+
+	stmts := []ast.Stmt{
+		// ctx, _godslCancel := context.WithCancel(parent)
+		&ast.AssignStmt{
+			Lhs:    []ast.Expr{ident(ctxName), ident(scope.cancelVar)},
+			TokPos: token.NoPos,
+			Tok:    token.DEFINE,
+			Rhs:    []ast.Expr{call(method(contextName, "WithCancel"), parent)},
+		},
+		// var _godslWg sync.WaitGroup
+		&ast.DeclStmt{Decl: &ast.GenDecl{
+			TokPos: token.NoPos,
+			Tok:    token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names: []*ast.Ident{ident(wg)},
+				Type:  method(syncName, "WaitGroup"),
+			}},
+		}},
+		// _godslErrs := make(chan error, N)
+		&ast.AssignStmt{
+			Lhs:    []ast.Expr{ident(scope.errsVar)},
+			TokPos: token.NoPos,
+			Tok:    token.DEFINE,
+			Rhs: []ast.Expr{call(ident("make"),
+				&ast.ChanType{Begin: token.NoPos, Dir: ast.SEND | ast.RECV, Value: ident("error")},
+				n,
+			)},
+		},
+		// _godslWg.Add(N)
+		&ast.ExprStmt{X: call(method(wg, "Add"), n)},
+	}
+
+	prevTarget, prevCtx := t.errTarget, t.ctxName
+	t.errTarget, t.ctxName = scope, ctxName
+	for _, stmt := range s.Body.List {
+		branch, ok := stmt.(*ast.ParallelBranch)
+		if !ok {
+			continue
+		}
+		var body []ast.Stmt
+		if block, ok := branch.Stmt.(*ast.BlockStmt); ok {
+			body = block.List
+		} else {
+			body = []ast.Stmt{branch.Stmt}
+		}
+
+		goBody := []ast.Stmt{&ast.DeferStmt{Defer: token.NoPos, Call: call(method(wg, "Done"))}}
+		goBody = append(goBody, t.transpileStmts(body)...)
+
+		// go func(ctx context.Context) { defer _godslWg.Done(); ... }(ctx)
+		stmts = append(stmts, &ast.GoStmt{
+			Go: token.NoPos,
+			Call: call(&ast.FuncLit{
+				Type: &ast.FuncType{
+					Func: token.NoPos,
+					Params: &ast.FieldList{List: []*ast.Field{{
+						Names: []*ast.Ident{ident(ctxName)},
+						Type:  method(contextName, "Context"),
+					}}},
+				},
+				Body: &ast.BlockStmt{Lbrace: token.NoPos, List: goBody, Rbrace: token.NoPos},
+			}, ident(ctxName)),
+		})
+	}
+	t.errTarget, t.ctxName = prevTarget, prevCtx
+
+	stmts = append(stmts,
+		&ast.ExprStmt{X: call(method(wg, "Wait"))},
+		&ast.ExprStmt{X: call(ident(scope.cancelVar))},
+		&ast.ExprStmt{X: call(ident("close"), ident(scope.errsVar))},
+	)
+
+	// Первая ошибка: <-_godslErrs (nil, если канал пуст и закрыт).
+	var errExpr ast.Expr = &ast.UnaryExpr{OpPos: token.NoPos, Op: token.ARROW, X: ident(scope.errsVar)}
+	if s.Mode != nil && s.Mode.Name == "all" {
+		// Все ошибки: собираем канал и объединяем через errors.Join.
+		all := "_godslAll" + suffix
+		stmts = append(stmts,
+			&ast.DeclStmt{Decl: &ast.GenDecl{
+				TokPos: token.NoPos,
+				Tok:    token.VAR,
+				Specs: []ast.Spec{&ast.ValueSpec{
+					Names: []*ast.Ident{ident(all)},
+					Type:  &ast.ArrayType{Lbrack: token.NoPos, Elt: ident("error")},
+				}},
+			}},
+			&ast.RangeStmt{
+				For:    token.NoPos,
+				Key:    ident("err"),
+				TokPos: token.NoPos,
+				Tok:    token.DEFINE,
+				X:      ident(scope.errsVar),
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+					Lhs:    []ast.Expr{ident(all)},
+					TokPos: token.NoPos,
+					Tok:    token.ASSIGN,
+					Rhs:    []ast.Expr{call(ident("append"), ident(all), ident("err"))},
+				}}},
+			},
+		)
+		errExpr = &ast.CallExpr{
+			Fun:      method(t.requireImport("errors"), "Join"),
+			Args:     []ast.Expr{ident(all)},
+			Ellipsis: 1, // любая валидная позиция: печатается _godslAll...
+		}
+	}
+	stmts = append(stmts, t.createErrorCheckFor(errExpr, s.Catches))
+
+	return &ast.BlockStmt{Lbrace: token.NoPos, List: stmts, Rbrace: token.NoPos}
+}
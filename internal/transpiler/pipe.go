@@ -0,0 +1,239 @@
+package transpiler
+
+import (
+	"go/types"
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// pipeStages раскладывает цепочку конвейера на исходное значение и стадии
+// в порядке выполнения.
+func pipeStages(x *ast.PipeExpr) (ast.Expr, []*ast.PipeExpr) {
+	var stages []*ast.PipeExpr
+	var input ast.Expr = x
+	for {
+		p, ok := input.(*ast.PipeExpr)
+		if !ok {
+			break
+		}
+		stages = append([]*ast.PipeExpr{p}, stages...)
+		input = p.X
+	}
+	return input, stages
+}
+
+// transpilePipeExpr транспилирует конвейер внутри выражения во вложенные
+// вызовы: x |> f |> g(_, 1) → g(f(x), 1). ? здесь недоступен — ошибку
+// некуда передать без отдельного оператора.
+func (t *Transpiler) transpilePipeExpr(x *ast.PipeExpr) ast.Expr {
+	input, stages := pipeStages(x)
+	value := t.transpileExpr(input)
+	for _, s := range stages {
+		if s.Question.IsValid() {
+			t.errorf(s.Question, "? in a pipeline is only allowed when the pipeline is a statement, an assignment or a return value")
+		}
+		value = t.pipeCall(s, value)
+	}
+	return value
+}
+
+// transpilePipeAssign разворачивает lhs := конвейер. Значение слева
+// передаётся первым аргументом стадии или на место заглушки _, а стадии
+// выполняются по очереди через временные переменные:
+//
+//	result := input |> parse |> validate? |> transform(cfg, _)
+//
+// →
+//
+//	_godslPipe := parse(input)
+//	_godslPipe2, err := validate(_godslPipe)
+//	if err != nil {
+//	    return err
+//	}
+//	result := transform(cfg, _godslPipe2)
+//
+// ? на стадии передаёт ошибку, как оператор ?. Стадия с ?, возвращающая
+// только error, проверяет значение и передаёт его дальше без изменений.
+func (t *Transpiler) transpilePipeAssign(lhs ast.Expr, tok token.Token, x *ast.PipeExpr) []ast.Stmt {
+	stmts, last, value := t.lowerPipe(x)
+	lhs = t.transpileExpr(lhs)
+	switch {
+	case !last.Question.IsValid():
+		return append(stmts, coalesceAssign(lhs, tok, t.pipeCall(last, value)))
+	case t.pipeReturnsOnlyError(last):
+		check, result := t.pipeStage(last, value)
+		return append(append(stmts, check...), coalesceAssign(lhs, tok, result))
+	}
+	return append(stmts,
+		&ast.AssignStmt{
+			Lhs:    []ast.Expr{lhs, &ast.Ident{NamePos: token.NoPos, Name: "err"}},
+			TokPos: token.NoPos,
+			Tok:    tok,
+			Rhs:    []ast.Expr{t.pipeCall(last, value)},
+		},
+		t.createPropagateCheck(last.Question),
+	)
+}
+
+// transpilePipeReturn разворачивает return конвейер.
+func (t *Transpiler) transpilePipeReturn(x *ast.PipeExpr) []ast.Stmt {
+	stmts, last, value := t.lowerPipe(x)
+	if last.Question.IsValid() {
+		var check []ast.Stmt
+		check, value = t.pipeStage(last, value)
+		stmts = append(stmts, check...)
+	} else {
+		value = t.pipeCall(last, value)
+	}
+	return append(stmts, &ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{value}})
+}
+
+// transpilePipeStmt разворачивает конвейер, записанный отдельным оператором:
+// input |> parse |> save?. Результат последней стадии отбрасывается.
+func (t *Transpiler) transpilePipeStmt(x *ast.PipeExpr) []ast.Stmt {
+	stmts, last, value := t.lowerPipe(x)
+	if !last.Question.IsValid() {
+		return append(stmts, &ast.ExprStmt{X: t.pipeCall(last, value)})
+	}
+	// Без информации о типах стадия считается возвращающей только error,
+	// как в f()?.
+	lhs := []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}}
+	if sig := t.pipeSignature(last); sig != nil && sig.Results().Len() == 2 {
+		lhs = append([]ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}}, lhs...)
+	}
+	return append(stmts, t.pipeErrCheck(last, lhs, t.pipeCall(last, value)))
+}
+
+// lowerPipe разворачивает все стадии, кроме последней, во временные
+// переменные. Возвращает операторы, последнюю стадию и значение, которое
+// ей передаётся.
+func (t *Transpiler) lowerPipe(x *ast.PipeExpr) ([]ast.Stmt, *ast.PipeExpr, ast.Expr) {
+	input, stages := pipeStages(x)
+	value := t.transpileExpr(input)
+	var stmts []ast.Stmt
+	for _, s := range stages[:len(stages)-1] {
+		var stage []ast.Stmt
+		stage, value = t.pipeStage(s, value)
+		stmts = append(stmts, stage...)
+	}
+	return stmts, stages[len(stages)-1], value
+}
+
+// pipeStage разворачивает стадию в операторы и возвращает выражение с её
+// результатом:
+//
+//	tmp := f(value)                                 // f
+//	tmp, err := f(value); if err != nil { ... }     // f? с результатом и error
+//	if err := f(value); err != nil { ... }          // f? только с error: результат — value
+func (t *Transpiler) pipeStage(s *ast.PipeExpr, value ast.Expr) ([]ast.Stmt, ast.Expr) {
+	var stmts []ast.Stmt
+	if s.Question.IsValid() && t.pipeReturnsOnlyError(s) {
+		// Значение проверяется и передаётся дальше, поэтому
+		// вычисляется один раз.
+		if !isSimpleRef(value) {
+			tmp := t.pipeTemp()
+			stmts = append(stmts, coalesceAssign(tmp, token.DEFINE, value))
+			value = tmp
+		}
+		lhs := []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}}
+		return append(stmts, t.pipeErrCheck(s, lhs, t.pipeCall(s, value))), value
+	}
+
+	tmp := t.pipeTemp()
+	lhs := []ast.Expr{tmp}
+	if s.Question.IsValid() {
+		lhs = append(lhs, &ast.Ident{NamePos: token.NoPos, Name: "err"})
+	}
+	stmts = append(stmts, &ast.AssignStmt{Lhs: lhs, TokPos: token.NoPos, Tok: token.DEFINE, Rhs: []ast.Expr{t.pipeCall(s, value)}})
+	if s.Question.IsValid() {
+		stmts = append(stmts, t.createPropagateCheck(s.Question))
+	}
+	return stmts, tmp
+}
+
+// pipeErrCheck строит if lhs := call; err != nil { return err }.
+func (t *Transpiler) pipeErrCheck(s *ast.PipeExpr, lhs []ast.Expr, call ast.Expr) ast.Stmt {
+	check := t.createPropagateCheck(s.Question).(*ast.IfStmt)
+	check.Init = &ast.AssignStmt{Lhs: lhs, TokPos: token.NoPos, Tok: token.DEFINE, Rhs: []ast.Expr{call}}
+	return check
+}
+
+// pipeCall строит вызов стадии со значением value: value становится
+// первым аргументом или подставляется вместо заглушки _.
+func (t *Transpiler) pipeCall(s *ast.PipeExpr, value ast.Expr) ast.Expr {
+	call, ok := ast.Unparen(s.Stage).(*ast.CallExpr)
+	if !ok {
+		return &ast.CallExpr{Fun: t.pipeFunc(s, s.Stage), Args: []ast.Expr{value}}
+	}
+
+	placeholder := -1
+	for i, arg := range call.Args {
+		if id, ok := arg.(*ast.Ident); ok && id.Name == "_" {
+			if placeholder >= 0 {
+				t.errorf(id.Pos(), "pipeline stage can use the _ placeholder only once")
+				break
+			}
+			placeholder = i
+		}
+	}
+	args := t.transpileExprs(call.Args)
+	if placeholder >= 0 {
+		args[placeholder] = value
+	} else {
+		args = append([]ast.Expr{value}, args...)
+	}
+	return &ast.CallExpr{
+		Fun:      t.pipeFunc(s, call.Fun),
+		Lparen:   call.Lparen,
+		Args:     args,
+		Ellipsis: call.Ellipsis,
+		Rparen:   call.Rparen,
+	}
+}
+
+// pipeFunc транспилирует функцию стадии. Для стадии с ? пробный проход
+// запрашивает её сигнатуру: от числа результатов зависит развёртка.
+func (t *Transpiler) pipeFunc(s *ast.PipeExpr, fun ast.Expr) ast.Expr {
+	fun = t.transpileExpr(fun)
+	if s.Question.IsValid() {
+		fun = t.probe(s, fun)
+	}
+	return fun
+}
+
+// pipeSignature возвращает сигнатуру функции стадии по данным пробного
+// прохода или nil.
+func (t *Transpiler) pipeSignature(s *ast.PipeExpr) *types.Signature {
+	typ := t.typeOf(s)
+	if typ == nil {
+		return nil
+	}
+	sig, _ := typ.Underlying().(*types.Signature)
+	return sig
+}
+
+// pipeReturnsOnlyError сообщает, возвращает ли функция стадии только error.
+// Без информации о типах считается, что стадия возвращает значение и error,
+// если это не функция файла с единственным результатом error.
+func (t *Transpiler) pipeReturnsOnlyError(s *ast.PipeExpr) bool {
+	if sig := t.pipeSignature(s); sig != nil {
+		return sig.Results().Len() == 1
+	}
+	fun := s.Stage
+	if call, ok := ast.Unparen(s.Stage).(*ast.CallExpr); ok {
+		fun = call.Fun
+	}
+	return t.returnsOnlyError(fun)
+}
+
+// pipeTemp возвращает новую временную переменную конвейера.
+func (t *Transpiler) pipeTemp() *ast.Ident {
+	t.pipeCount++
+	name := "_godslPipe"
+	if t.pipeCount > 1 {
+		name += strconv.Itoa(t.pipeCount)
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: name}
+}
@@ -0,0 +1,261 @@
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/format"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// recordDerives — поддерживаемые элементы derive(...).
+var recordDerives = map[string]bool{"json": true, "hash": true}
+
+// recordField — поле record с именем параметра конструктора.
+type recordField struct {
+	name  string // имя поля
+	param string // имя параметра в NewX и WithX
+	typ   string // тип поля в виде Go-кода
+	field *ast.Field
+}
+
+// transpileRecordDecl разворачивает record в структуру. Конструктор NewX,
+// методы Equal, String, WithField и методы из derive(...) генерируются
+// текстом в t.declCode и дописываются в конец файла.
+//
+//	record Point(X, Y int)
+//
+// становится
+//
+//	type Point struct {
+//		X, Y int
+//	}
+func (t *Transpiler) transpileRecordDecl(rec *ast.RecordDecl) []ast.Decl {
+	derives := make(map[string]bool)
+	for _, d := range rec.Derives {
+		if !recordDerives[d.Name] {
+			t.errorf(d.Pos(), "record %s: unknown derive %q (supported: json, hash)", rec.Name.Name, d.Name)
+			continue
+		}
+		derives[d.Name] = true
+	}
+
+	var fields []recordField
+	for _, f := range rec.Fields.List {
+		for _, n := range f.Names {
+			fields = append(fields, recordField{
+				name:  n.Name,
+				param: recordParamName(n.Name),
+				typ:   t.nodeString(f.Type),
+				field: f,
+			})
+		}
+	}
+
+	t.declCode = append(t.declCode, t.recordMethods(rec, fields, derives))
+	decls := []ast.Decl{t.recordStruct(rec, derives)}
+	// В пробном проходе запрашиваем типы полей, чтобы выбрать между ==
+	// и reflect.DeepEqual в Equal.
+	if t.probing {
+		decls = append(decls, t.recordProbe(rec))
+	}
+	return decls
+}
+
+// recordStruct строит объявление структуры record. С derive(json) каждое
+// экспортируемое поле получает тег json с именем в camelCase.
+func (t *Transpiler) recordStruct(rec *ast.RecordDecl, derives map[string]bool) ast.Decl {
+	list := &ast.FieldList{Opening: token.NoPos, Closing: token.NoPos}
+	for _, f := range rec.Fields.List {
+		if !derives["json"] {
+			list.List = append(list.List, &ast.Field{Names: f.Names, Type: f.Type})
+			continue
+		}
+		for _, n := range f.Names {
+			field := &ast.Field{Names: []*ast.Ident{n}, Type: f.Type}
+			if token.IsExported(n.Name) {
+				field.Tag = &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: "`json:\"" + jsonFieldName(n.Name) + "\"`"}
+			}
+			list.List = append(list.List, field)
+		}
+	}
+	return &ast.GenDecl{
+		Doc:    rec.Doc,
+		TokPos: rec.Record,
+		Tok:    token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{
+			Name:       rec.Name,
+			TypeParams: rec.TypeParams,
+			Type:       &ast.StructType{Struct: token.NoPos, Fields: list},
+		}},
+	}
+}
+
+// recordProbe строит func _[TypeParams]() { _godslProbe(id, *new(T)); ... }
+// для типов полей record.
+func (t *Transpiler) recordProbe(rec *ast.RecordDecl) ast.Decl {
+	body := &ast.BlockStmt{}
+	for _, f := range rec.Fields.List {
+		body.List = append(body.List, &ast.ExprStmt{X: t.probe(f, zeroOfTypeExpr(f.Type))})
+	}
+	return &ast.FuncDecl{
+		Name: &ast.Ident{NamePos: token.NoPos, Name: "_"},
+		Type: &ast.FuncType{Func: token.NoPos, TypeParams: rec.TypeParams, Params: &ast.FieldList{}},
+		Body: body,
+	}
+}
+
+// recordMethods генерирует конструктор и методы record в виде Go-кода.
+func (t *Transpiler) recordMethods(rec *ast.RecordDecl, fields []recordField, derives map[string]bool) string {
+	name := rec.Name.Name
+	recv := receiverName(name)
+	fmtName := t.requireImport("fmt")
+
+	// Для обобщённого record: [K comparable, V any] и Pair[K, V].
+	tparams, typ := "", name
+	if rec.TypeParams != nil {
+		var decls, names []string
+		for _, f := range rec.TypeParams.List {
+			var group []string
+			for _, n := range f.Names {
+				group = append(group, n.Name)
+			}
+			names = append(names, group...)
+			decls = append(decls, strings.Join(group, ", ")+" "+t.nodeString(f.Type))
+		}
+		tparams = "[" + strings.Join(decls, ", ") + "]"
+		typ = name + "[" + strings.Join(names, ", ") + "]"
+	}
+
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	var params, inits []string
+	for _, f := range fields {
+		params = append(params, f.param+" "+f.typ)
+		inits = append(inits, f.name+": "+f.param)
+	}
+	w("\n// New%s создаёт %s из значений полей.\n", name, name)
+	w("func New%s%s(%s) %s {\n", name, tparams, strings.Join(params, ", "), typ)
+	w("\treturn %s{%s}\n", typ, strings.Join(inits, ", "))
+	w("}\n")
+
+	var eqs []string
+	for _, f := range fields {
+		if t.recordFieldComparable(rec, f.field) {
+			eqs = append(eqs, fmt.Sprintf("%s.%s == other.%s", recv, f.name, f.name))
+		} else {
+			eqs = append(eqs, fmt.Sprintf("%s.DeepEqual(%s.%s, other.%s)", t.requireImport("reflect"), recv, f.name, f.name))
+		}
+	}
+	w("\n// Equal сообщает, равны ли все поля %s и other.\n", name)
+	w("func (%s %s) Equal(other %s) bool {\n", recv, typ, typ)
+	w("\treturn %s\n", strings.Join(eqs, " && "))
+	w("}\n")
+
+	var verbs, args []string
+	for _, f := range fields {
+		verbs = append(verbs, f.name+": %v")
+		args = append(args, recv+"."+f.name)
+	}
+	w("\n// String возвращает строковое представление %s.\n", name)
+	w("func (%s %s) String() string {\n", recv, typ)
+	w("\treturn %s.Sprintf(%q, %s)\n", fmtName, name+"{"+strings.Join(verbs, ", ")+"}", strings.Join(args, ", "))
+	w("}\n")
+
+	for _, f := range fields {
+		// Получатель — одна буква, поэтому value с ним не совпадает.
+		param := f.param
+		if param == recv {
+			param = "value"
+		}
+		w("\n// With%s возвращает копию %s с новым значением %s.\n", f.name, name, f.name)
+		w("func (%s %s) With%s(%s %s) %s {\n", recv, typ, f.name, param, f.typ, typ)
+		w("\t%s.%s = %s\n", recv, f.name, param)
+		w("\treturn %s\n", recv)
+		w("}\n")
+	}
+
+	if derives["hash"] {
+		fnvName := t.requireImport("hash/fnv")
+		verbs = verbs[:0]
+		for range fields {
+			verbs = append(verbs, "%#v")
+		}
+		w("\n// Hash возвращает FNV-1a хеш полей %s.\n", name)
+		w("func (%s %s) Hash() uint64 {\n", recv, typ)
+		w("\thasher := %s.New64a()\n", fnvName)
+		w("\t%s.Fprintf(hasher, %q, %s)\n", fmtName, strings.Join(verbs, "|"), strings.Join(args, ", "))
+		w("\treturn hasher.Sum64()\n")
+		w("}\n")
+	}
+
+	return b.String()
+}
+
+// recordFieldComparable сообщает, можно ли сравнивать поле через ==.
+// Использует тип из пробного прохода, а без него — синтаксис типа.
+func (t *Transpiler) recordFieldComparable(rec *ast.RecordDecl, f *ast.Field) bool {
+	if typ := t.typeOf(f); typ != nil {
+		return types.Comparable(typ)
+	}
+	switch typ := f.Type.(type) {
+	case *ast.ArrayType:
+		return typ.Len != nil
+	case *ast.MapType, *ast.FuncType:
+		return false
+	case *ast.Ident:
+		// Параметр типа сравним только с ограничением comparable.
+		if rec.TypeParams != nil {
+			for _, tp := range rec.TypeParams.List {
+				for _, n := range tp.Names {
+					if n.Name == typ.Name {
+						c, ok := tp.Type.(*ast.Ident)
+						return ok && c.Name == "comparable"
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+// recordParamName возвращает имя параметра для поля: X → x, ID → id.
+// Ключевые слова Go получают суффикс _: Type → type_.
+func recordParamName(field string) string {
+	name := jsonFieldName(field)
+	if token.IsKeyword(name) {
+		name += "_"
+	}
+	return name
+}
+
+// jsonFieldName переводит имя поля в camelCase: Name → name, ID → id,
+// URLPath → urlPath.
+func jsonFieldName(field string) string {
+	runes := []rune(field)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// nodeString печатает узел в Go-код.
+func (t *Transpiler) nodeString(node ast.Node) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, t.fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
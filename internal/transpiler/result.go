@@ -0,0 +1,151 @@
+package transpiler
+
+import (
+	"go/types"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+const resultImportPath = "github.com/sviridovkonstantin42/godsl/runtime/result"
+
+// unwrapKind — вид значения под оператором ?.
+type unwrapKind int
+
+const (
+	unwrapTuple  unwrapKind = iota // вызов, возвращающий (T, error) или error
+	unwrapResult                   // result.Result[T]
+	unwrapOption                   // result.Option[T]
+)
+
+// questionKind определяет по данным пробного прохода, к чему применён ?.
+// В пробном проходе рядом с оператором добавляется _ = _godslProbe(id, x):
+// сам оператор остаётся в форме (T, error), чтобы ошибка типов в нём не
+// испортила типы переменных для остальных пробных выражений. Без
+// информации о типах ? разворачивается как для (T, error).
+func (t *Transpiler) questionKind(s *ast.QuestionStmt, x ast.Expr) (unwrapKind, []ast.Stmt) {
+	if t.probing {
+		return unwrapTuple, []ast.Stmt{&ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}},
+			TokPos: token.NoPos,
+			Tok:    token.ASSIGN,
+			Rhs:    []ast.Expr{t.probe(s, x)},
+		}}
+	}
+	named, ok := t.typeOf(s).(*types.Named)
+	if !ok {
+		return unwrapTuple, nil
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != resultImportPath {
+		return unwrapTuple, nil
+	}
+	switch obj.Name() {
+	case "Result":
+		return unwrapResult, nil
+	case "Option":
+		return unwrapOption, nil
+	}
+	return unwrapTuple, nil
+}
+
+// transpileUnwrapAssign разворачивает v := x? для Result и Option:
+//
+//	v := r?   →   v, err := r.Get()
+//	              if err != nil { return err }
+//
+//	v := o?   →   v, _godslOk := o.Get()
+//	              if !_godslOk { return result.ErrNone }
+//
+//	v = o?    →   if _godslVal, _godslOk := o.Get(); !_godslOk {
+//	                  return result.ErrNone
+//	              } else {
+//	                  v = _godslVal
+//	              }
+//
+// Признак значения Option хранится в _godslOk, чтобы не требовать и не
+// перезаписывать переменную ok пользователя.
+func (t *Transpiler) transpileUnwrapAssign(s *ast.QuestionStmt, assign *ast.AssignStmt, x ast.Expr, kind unwrapKind) []ast.Stmt {
+	if len(assign.Lhs) != 1 {
+		t.errorf(s.Question, "? on %s assigns exactly one value, got %d", t.sourceText(t.typeExpr(t.typeOf(s))), len(assign.Lhs))
+		return []ast.Stmt{s.Stmt}
+	}
+	if kind == unwrapResult {
+		return []ast.Stmt{&ast.AssignStmt{
+			Lhs:    []ast.Expr{assign.Lhs[0], &ast.Ident{NamePos: token.NoPos, Name: "err"}},
+			TokPos: assign.TokPos,
+			Tok:    assign.Tok,
+			Rhs:    []ast.Expr{methodCall(x, "Get")},
+		}, t.createPropagateCheck(s.Question)}
+	}
+
+	ok := &ast.Ident{NamePos: token.NoPos, Name: "_godslOk"}
+	if assign.Tok == token.DEFINE {
+		return []ast.Stmt{&ast.AssignStmt{
+			Lhs:    []ast.Expr{assign.Lhs[0], ok},
+			TokPos: assign.TokPos,
+			Tok:    token.DEFINE,
+			Rhs:    []ast.Expr{methodCall(x, "Get")},
+		}, t.noneCheck(ok, s.Question)}
+	}
+	val := &ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}
+	check := t.noneCheck(ok, s.Question).(*ast.IfStmt)
+	check.Init = &ast.AssignStmt{
+		Lhs:    []ast.Expr{val, ok},
+		TokPos: token.NoPos,
+		Tok:    token.DEFINE,
+		Rhs:    []ast.Expr{methodCall(x, "Get")},
+	}
+	check.Else = &ast.BlockStmt{
+		Lbrace: token.NoPos,
+		List:   []ast.Stmt{coalesceAssign(assign.Lhs[0], assign.Tok, val)},
+		Rbrace: token.NoPos,
+	}
+	return []ast.Stmt{check}
+}
+
+// transpileUnwrapExpr разворачивает x? без присваивания: значение
+// отбрасывается, проверяется только ошибка или наличие значения.
+//
+//	r?   →   if err := r.Err(); err != nil { return err }
+//	o?   →   if !o.IsSome() { return result.ErrNone }
+func (t *Transpiler) transpileUnwrapExpr(s *ast.QuestionStmt, x ast.Expr, kind unwrapKind) ast.Stmt {
+	if kind == unwrapOption {
+		return t.noneCheck(methodCall(x, "IsSome"), s.Question)
+	}
+	check := t.createPropagateCheck(s.Question).(*ast.IfStmt)
+	check.Init = &ast.AssignStmt{
+		Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
+		TokPos: token.NoPos,
+		Tok:    token.DEFINE,
+		Rhs:    []ast.Expr{methodCall(x, "Err")},
+	}
+	return check
+}
+
+// noneCheck строит if !some { return result.ErrNone }.
+func (t *Transpiler) noneCheck(some ast.Expr, pos token.Pos) ast.Stmt {
+	errNone := &ast.SelectorExpr{
+		X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport(resultImportPath)},
+		Sel: &ast.Ident{NamePos: token.NoPos, Name: "ErrNone"},
+	}
+	return &ast.IfStmt{
+		If:   token.NoPos,
+		Cond: &ast.UnaryExpr{OpPos: token.NoPos, Op: token.NOT, X: some},
+		Body: &ast.BlockStmt{
+			Lbrace: token.NoPos,
+			List:   t.propagateErr(errNone, pos),
+			Rbrace: token.NoPos,
+		},
+	}
+}
+
+// methodCall строит вызов x.name() без аргументов, заключая x в скобки,
+// если это унарное или бинарное выражение (<-ch, *p).
+func methodCall(x ast.Expr, name string) ast.Expr {
+	switch x.(type) {
+	case *ast.UnaryExpr, *ast.BinaryExpr, *ast.StarExpr:
+		x = &ast.ParenExpr{Lparen: token.NoPos, X: x, Rparen: token.NoPos}
+	}
+	return &ast.CallExpr{Fun: &ast.SelectorExpr{X: x, Sel: &ast.Ident{NamePos: token.NoPos, Name: name}}}
+}
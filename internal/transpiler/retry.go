@@ -0,0 +1,370 @@
+package transpiler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// retryScope описывает retry-блок, внутри которого транспилируется тело:
+// ? и throw в нём не выходят из функции, а завершают текущую попытку.
+// Реализует errTarget.
+type retryScope struct {
+	errVar    string // переменная с ошибкой последней попытки
+	label     string // метка цикла попыток
+	labelUsed bool
+}
+
+// propagate завершает неудачную попытку:
+//
+//	_godslErr = err
+//	continue _godslRetry
+func (r *retryScope) propagate(errExpr ast.Expr) []ast.Stmt {
+	r.labelUsed = true
+	return []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: r.errVar}},
+			TokPos: token.NoPos,
+			Tok:    token.ASSIGN,
+			Rhs:    []ast.Expr{errExpr},
+		},
+		&ast.BranchStmt{
+			TokPos: token.NoPos,
+			Tok:    token.CONTINUE,
+			Label:  &ast.Ident{NamePos: token.NoPos, Name: r.label},
+		},
+	}
+}
+
+// transpileRetryStmt транспилирует retry-блок:
+//
+//	retry 3 backoff 100ms {
+//	    resp := fetch(url)?
+//	} catch {
+//	    log.Println(err)
+//	}
+//
+// →
+//
+//	var _godslErr error
+//	_godslRetry:
+//	for _godslAttempt := 0; _godslAttempt < 3; _godslAttempt++ {
+//	    if _godslAttempt > 0 {
+//	        time.Sleep((100 * time.Millisecond) << (_godslAttempt - 1))
+//	    }
+//	    resp, err := fetch(url)
+//	    if err != nil {
+//	        _godslErr = err
+//	        continue _godslRetry
+//	    }
+//	    _godslErr = nil
+//	    break
+//	}
+//	if err := _godslErr; err != nil {
+//	    log.Println(err)
+//	}
+//
+// Задержка удваивается с каждой попыткой. Если у функции есть параметр
+// context.Context, ожидание прерывается его отменой. Catch-блоки (или
+// return err, если их нет) выполняются только после последней неудачной
+// попытки.
+func (t *Transpiler) transpileRetryStmt(s *ast.RetryStmt) []ast.Stmt {
+	t.retryCount++
+	suffix := ""
+	if t.retryCount > 1 {
+		suffix = strconv.Itoa(t.retryCount)
+	}
+	scope := &retryScope{
+		errVar: "_godslErr" + suffix,
+		label:  "_godslRetry" + suffix,
+	}
+	attempt := "_godslAttempt" + suffix
+
+	var loopBody []ast.Stmt
+	if s.Backoff != nil {
+		loopBody = append(loopBody, &ast.IfStmt{
+			If: token.NoPos,
+			Cond: &ast.BinaryExpr{
+				X:     &ast.Ident{NamePos: token.NoPos, Name: attempt},
+				OpPos: token.NoPos,
+				Op:    token.GTR,
+				Y:     &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   []ast.Stmt{t.retryWait(s.Backoff, attempt, scope)},
+				Rbrace: token.NoPos,
+			},
+		})
+	}
+
+	// break и continue без метки в теле относились бы к циклу попыток.
+	if b := escapingBranch(s.Body.List, true); b != nil {
+		t.errorf(b.TokPos, "%s without label inside retry block; label the enclosing loop and use %s <label>", b.Tok, b.Tok)
+	}
+
+	prev := t.errTarget
+	t.errTarget = scope
+	loopBody = append(loopBody, t.transpileStmts(s.Body.List)...)
+	t.errTarget = prev
+
+	loopBody = append(loopBody,
+		&ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: scope.errVar}},
+			TokPos: token.NoPos,
+			Tok:    token.ASSIGN,
+			Rhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "nil"}},
+		},
+		&ast.BranchStmt{TokPos: token.NoPos, Tok: token.BREAK},
+	)
+
+	var loop ast.Stmt = &ast.ForStmt{
+		For: token.NoPos,
+		Init: &ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: attempt}},
+			TokPos: token.NoPos,
+			Tok:    token.DEFINE,
+			Rhs:    []ast.Expr{&ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}},
+		},
+		Cond: &ast.BinaryExpr{
+			X:     &ast.Ident{NamePos: token.NoPos, Name: attempt},
+			OpPos: token.NoPos,
+			Op:    token.LSS,
+			Y:     retryCount(t.transpileExpr(s.Count)),
+		},
+		Post: &ast.IncDecStmt{
+			X:      &ast.Ident{NamePos: token.NoPos, Name: attempt},
+			TokPos: token.NoPos,
+			Tok:    token.INC,
+		},
+		Body: &ast.BlockStmt{Lbrace: token.NoPos, List: loopBody, Rbrace: token.NoPos},
+	}
+	if scope.labelUsed {
+		loop = &ast.LabeledStmt{
+			Label: &ast.Ident{NamePos: token.NoPos, Name: scope.label},
+			Colon: token.NoPos,
+			Stmt:  loop,
+		}
+	}
+
+	errDecl := &ast.DeclStmt{Decl: &ast.GenDecl{
+		TokPos: token.NoPos,
+		Tok:    token.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{
+			Names: []*ast.Ident{{NamePos: token.NoPos, Name: scope.errVar}},
+			Type:  &ast.Ident{NamePos: token.NoPos, Name: "error"},
+		}},
+	}}
+
+	// После последней попытки: if err := _godslErr; err != nil { <catch> }
+	errCheck := t.createErrorCheckFor(&ast.Ident{NamePos: token.NoPos, Name: scope.errVar}, s.Catches)
+
+	return []ast.Stmt{errDecl, loop, errCheck}
+}
+
+// escapingBranch возвращает первый break или continue без метки, который
+// относится не к вложенному циклу или retry. withBreak — искать break; внутри switch
+// и select break относится к ним, поэтому там ищется только continue.
+func escapingBranch(stmts []ast.Stmt, withBreak bool) *ast.BranchStmt {
+	var found *ast.BranchStmt
+	for _, s := range stmts {
+		ast.Inspect(s, func(n ast.Node) bool {
+			if found != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.FuncLit, *ast.ForStmt, *ast.RangeStmt, *ast.RetryStmt, *ast.ParallelStmt:
+				return false
+			case *ast.SwitchStmt:
+				found = escapingBranch(n.Body.List, false)
+				return false
+			case *ast.TypeSwitchStmt:
+				found = escapingBranch(n.Body.List, false)
+				return false
+			case *ast.SelectStmt:
+				found = escapingBranch(n.Body.List, false)
+				return false
+			case *ast.BranchStmt:
+				if n.Label == nil && (n.Tok == token.CONTINUE || n.Tok == token.BREAK && withBreak) {
+					found = n
+				}
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// createErrorCheckFor создаёт проверку ошибки, вычисленной блоком:
+//
+//	if err := <errExpr>; err != nil { <catch> }
+//
+// Без catch-блоков ошибка возвращается из функции или передаётся
+// охватывающему retry/parallel.
+func (t *Transpiler) createErrorCheckFor(errExpr ast.Expr, catches []*ast.CatchStmt) ast.Stmt {
+	errCheck := t.createErrorCheck(catches).(*ast.IfStmt)
+	if len(catches) == 0 && t.errTarget != nil {
+		errCheck.Body.List = t.errTarget.propagate(&ast.Ident{NamePos: token.NoPos, Name: "err"})
+	}
+	errCheck.Init = &ast.AssignStmt{
+		Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
+		TokPos: token.NoPos,
+		Tok:    token.DEFINE,
+		Rhs:    []ast.Expr{errExpr},
+	}
+	return errCheck
+}
+
+// retryCount отвязывает простое число попыток от исходной позиции,
+// чтобы printer не переносил условие цикла на новую строку.
+func retryCount(expr ast.Expr) ast.Expr {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		return &ast.BasicLit{ValuePos: token.NoPos, Kind: x.Kind, Value: x.Value}
+	case *ast.Ident:
+		return &ast.Ident{NamePos: token.NoPos, Name: x.Name}
+	}
+	return expr
+}
+
+// retryWait строит ожидание перед очередной попыткой. Без контекста:
+//
+//	time.Sleep(delay << (attempt - 1))
+//
+// С параметром ctx context.Context:
+//
+//	select {
+//	case <-ctx.Done():
+//	    _godslErr = ctx.Err()
+//	    break _godslRetry
+//	case <-time.After(delay << (attempt - 1)):
+//	}
+func (t *Transpiler) retryWait(backoff ast.Expr, attempt string, scope *retryScope) ast.Stmt {
+	delay := &ast.BinaryExpr{
+		X:     &ast.ParenExpr{X: t.delayExpr(backoff)},
+		OpPos: token.NoPos,
+		Op:    token.SHL,
+		Y: &ast.ParenExpr{X: &ast.BinaryExpr{
+			X:     &ast.Ident{NamePos: token.NoPos, Name: attempt},
+			OpPos: token.NoPos,
+			Op:    token.SUB,
+			Y:     &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "1"},
+		}},
+	}
+
+	if t.ctxName == "" {
+		return &ast.ExprStmt{X: &ast.CallExpr{
+			Fun:  t.timeSelector("Sleep"),
+			Args: []ast.Expr{delay},
+		}}
+	}
+
+	scope.labelUsed = true
+	ctx := func(method string) ast.Expr {
+		return &ast.CallExpr{Fun: &ast.SelectorExpr{
+			X:   &ast.Ident{NamePos: token.NoPos, Name: t.ctxName},
+			Sel: &ast.Ident{NamePos: token.NoPos, Name: method},
+		}}
+	}
+	return &ast.SelectStmt{
+		Select: token.NoPos,
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.CommClause{
+				Case: token.NoPos,
+				Comm: &ast.ExprStmt{X: &ast.UnaryExpr{OpPos: token.NoPos, Op: token.ARROW, X: ctx("Done")}},
+				Body: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: scope.errVar}},
+						TokPos: token.NoPos,
+						Tok:    token.ASSIGN,
+						Rhs:    []ast.Expr{ctx("Err")},
+					},
+					&ast.BranchStmt{
+						TokPos: token.NoPos,
+						Tok:    token.BREAK,
+						Label:  &ast.Ident{NamePos: token.NoPos, Name: scope.label},
+					},
+				},
+			},
+			&ast.CommClause{
+				Case: token.NoPos,
+				Comm: &ast.ExprStmt{X: &ast.UnaryExpr{
+					OpPos: token.NoPos,
+					Op:    token.ARROW,
+					X:     &ast.CallExpr{Fun: t.timeSelector("After"), Args: []ast.Expr{delay}},
+				}},
+			},
+		}},
+	}
+}
+
+// delayExpr преобразует задержку в выражение типа time.Duration.
+// Литерал длительности раскладывается по самой крупной единице, на которую
+// он делится без остатка: 100ms → 100 * time.Millisecond, 1.5s → 1500 * time.Millisecond.
+func (t *Transpiler) delayExpr(expr ast.Expr) ast.Expr {
+	lit, ok := expr.(*ast.DurationLit)
+	if !ok {
+		return t.transpileExpr(expr)
+	}
+	d, err := time.ParseDuration(lit.Value)
+	if err != nil || d == 0 {
+		return &ast.Ident{NamePos: token.NoPos, Name: "0"}
+	}
+
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+		{time.Nanosecond, "Nanosecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return &ast.BinaryExpr{
+				X:     &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: strconv.FormatInt(int64(d/u.d), 10)},
+				OpPos: token.NoPos,
+				Op:    token.MUL,
+				Y:     t.timeSelector(u.name),
+			}
+		}
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: "0"}
+}
+
+// timeSelector возвращает time.<name> и добавляет импорт пакета.
+func (t *Transpiler) timeSelector(name string) ast.Expr {
+	return &ast.SelectorExpr{
+		X:   &ast.Ident{NamePos: token.NoPos, Name: t.requireImport("time")},
+		Sel: &ast.Ident{NamePos: token.NoPos, Name: name},
+	}
+}
+
+// contextParamName возвращает имя параметра функции типа context.Context
+// или пустую строку, если такого параметра нет. pkg — имя, под которым
+// файл импортирует context.
+func contextParamName(funcType *ast.FuncType, pkg string) string {
+	if funcType == nil || funcType.Params == nil {
+		return ""
+	}
+	for _, field := range funcType.Params.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Context" {
+			continue
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != pkg {
+			continue
+		}
+		for _, name := range field.Names {
+			if name.Name != "_" {
+				return name.Name
+			}
+		}
+	}
+	return ""
+}
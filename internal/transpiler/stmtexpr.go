@@ -0,0 +1,257 @@
+package transpiler
+
+import (
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// transpileStmtExprAssign разворачивает присваивание if- или
+// switch-выражения в объявление переменной и обычный оператор:
+//
+//	x := if a > b { a } else { b }
+//
+// →
+//
+//	var x int
+//	if a > b {
+//	    x = a
+//	} else {
+//	    x = b
+//	}
+func (t *Transpiler) transpileStmtExprAssign(lhs ast.Expr, tok token.Token, x *ast.StmtExpr) []ast.Stmt {
+	lhs = t.transpileExpr(lhs)
+	values := branchValues(x.Stmt)
+	sink := func(v ast.Expr) []ast.Stmt {
+		return []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, v)}
+	}
+
+	if tok == token.DEFINE {
+		name, ok := lhs.(*ast.Ident)
+		if !ok {
+			return []ast.Stmt{coalesceAssign(lhs, tok, t.transpileStmtExpr(x))}
+		}
+		typ := t.branchType(values)
+		if typ == nil {
+			t.requireResolved(x)
+			typ = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+		}
+		decl := &ast.DeclStmt{Decl: &ast.GenDecl{
+			TokPos: token.NoPos,
+			Tok:    token.VAR,
+			Specs:  []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{name}, Type: typ}},
+		}}
+		return []ast.Stmt{decl, t.lowerBranches(x.Stmt, sink, nil)}
+	}
+
+	// Без else/default переменная получает нулевое значение, как и при :=.
+	var zero ast.Expr
+	if !isExhaustive(x.Stmt) {
+		zero = t.branchZero(values, nil)
+		if zero == nil {
+			zero = t.zeroCall("Like", lhs)
+		}
+	}
+	return []ast.Stmt{t.lowerBranches(x.Stmt, sink, zero)}
+}
+
+// transpileStmtExprReturn разворачивает return if ... / return switch ...
+// в оператор, каждая ветка которого возвращает своё значение.
+func (t *Transpiler) transpileStmtExprReturn(x *ast.StmtExpr) []ast.Stmt {
+	return []ast.Stmt{t.lowerBranches(x.Stmt, returnSink, t.stmtExprZero(x, t.returnTypeHint))}
+}
+
+// transpileStmtExpr транспилирует if- или switch-выражение внутри
+// произвольного выражения в IIFE — когда объявить переменную заранее нельзя.
+func (t *Transpiler) transpileStmtExpr(x *ast.StmtExpr) ast.Expr {
+	typ := t.branchType(branchValues(x.Stmt))
+	if typ == nil {
+		t.requireResolved(x)
+		typ = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+	}
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    token.NoPos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   []ast.Stmt{t.lowerBranches(x.Stmt, returnSink, t.stmtExprZero(x, typ))},
+				Rbrace: token.NoPos,
+			},
+		},
+	}
+}
+
+// stmtExprZero возвращает значение для веток, которых нет (if без else,
+// switch без default), или nil, если все ветки есть.
+func (t *Transpiler) stmtExprZero(x *ast.StmtExpr, hint ast.Expr) ast.Expr {
+	if isExhaustive(x.Stmt) {
+		return nil
+	}
+	if zero := t.branchZero(branchValues(x.Stmt), hint); zero != nil {
+		return zero
+	}
+	return &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+}
+
+// branchValues возвращает значения веток: последние выражения блоков,
+// в том числе во вложенных if/switch на месте значения.
+func branchValues(stmt ast.Stmt) []ast.Expr {
+	var values []ast.Expr
+	var block func(list []ast.Stmt)
+	block = func(list []ast.Stmt) {
+		if len(list) == 0 {
+			return
+		}
+		switch last := list[len(list)-1].(type) {
+		case *ast.ExprStmt:
+			values = append(values, last.X)
+		case *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt:
+			values = append(values, branchValues(last)...)
+		}
+	}
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		block(s.Body.List)
+		switch e := s.Else.(type) {
+		case *ast.IfStmt:
+			values = append(values, branchValues(e)...)
+		case *ast.BlockStmt:
+			block(e.List)
+		}
+	case *ast.SwitchStmt:
+		for _, c := range s.Body.List {
+			block(c.(*ast.CaseClause).Body)
+		}
+	case *ast.TypeSwitchStmt:
+		for _, c := range s.Body.List {
+			block(c.(*ast.CaseClause).Body)
+		}
+	}
+	return values
+}
+
+// isExhaustive сообщает, есть ли у if ветка else (на конце цепочки
+// else if), а у switch — ветка default.
+func isExhaustive(stmt ast.Stmt) bool {
+	var body *ast.BlockStmt
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		switch e := s.Else.(type) {
+		case *ast.IfStmt:
+			return isExhaustive(e)
+		case *ast.BlockStmt:
+			return true
+		}
+		return false
+	case *ast.SwitchStmt:
+		body = s.Body
+	case *ast.TypeSwitchStmt:
+		body = s.Body
+	default:
+		return false
+	}
+	for _, c := range body.List {
+		if c.(*ast.CaseClause).List == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerBranches строит копию if/switch, в которой значение каждой ветки
+// передаётся в sink. Если zero не nil, недостающие ветки else/default
+// получают значение zero.
+func (t *Transpiler) lowerBranches(stmt ast.Stmt, sink matchSink, zero ast.Expr) ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.IfStmt:
+		out := &ast.IfStmt{
+			If:   s.If,
+			Init: t.transpileInit(s.Init),
+			Cond: t.transpileExpr(s.Cond),
+			Body: &ast.BlockStmt{Lbrace: s.Body.Lbrace, List: t.lowerBranchBlock(s.Body.List, s.Body.Lbrace, sink, zero), Rbrace: s.Body.Rbrace},
+		}
+		switch e := s.Else.(type) {
+		case *ast.IfStmt:
+			out.Else = t.lowerBranches(e, sink, zero)
+		case *ast.BlockStmt:
+			out.Else = &ast.BlockStmt{Lbrace: e.Lbrace, List: t.lowerBranchBlock(e.List, e.Lbrace, sink, zero), Rbrace: e.Rbrace}
+		case nil:
+			if zero != nil {
+				out.Else = &ast.BlockStmt{List: sink(zero)}
+			}
+		}
+		return out
+	case *ast.SwitchStmt:
+		return &ast.SwitchStmt{
+			Switch: s.Switch,
+			Init:   t.transpileInit(s.Init),
+			Tag:    t.transpileExpr(s.Tag),
+			Body:   t.lowerCaseClauses(s.Body, true, sink, zero),
+		}
+	case *ast.TypeSwitchStmt:
+		return &ast.TypeSwitchStmt{
+			Switch: s.Switch,
+			Init:   t.transpileInit(s.Init),
+			Assign: s.Assign,
+			Body:   t.lowerCaseClauses(s.Body, false, sink, zero),
+		}
+	}
+	return t.transpileStmt(stmt)
+}
+
+// lowerCaseClauses строит ветки switch; values сообщает, что в case
+// стоят значения (а не типы) и их нужно транспилировать.
+func (t *Transpiler) lowerCaseClauses(body *ast.BlockStmt, values bool, sink matchSink, zero ast.Expr) *ast.BlockStmt {
+	var clauses []ast.Stmt
+	hasDefault := false
+	for _, c := range body.List {
+		cc := c.(*ast.CaseClause)
+		list := cc.List
+		if values && list != nil {
+			list = t.transpileExprs(cc.List)
+		}
+		if cc.List == nil {
+			hasDefault = true
+		}
+		clauses = append(clauses, &ast.CaseClause{
+			Case:  cc.Case,
+			List:  list,
+			Colon: cc.Colon,
+			Body:  t.lowerBranchBlock(cc.Body, cc.Colon, sink, zero),
+		})
+	}
+	if !hasDefault && zero != nil {
+		clauses = append(clauses, &ast.CaseClause{Case: token.NoPos, Colon: token.NoPos, Body: sink(zero)})
+	}
+	return &ast.BlockStmt{Lbrace: body.Lbrace, List: clauses, Rbrace: body.Rbrace}
+}
+
+// lowerBranchBlock транспилирует операторы ветки, заменяя последнее
+// выражение (значение ветки) на sink(значение).
+func (t *Transpiler) lowerBranchBlock(list []ast.Stmt, pos token.Pos, sink matchSink, zero ast.Expr) []ast.Stmt {
+	if len(list) == 0 {
+		t.errorf(pos, "branch of if/switch expression has no value")
+		return nil
+	}
+	stmts := t.transpileStmts(list[:len(list)-1])
+	switch last := list[len(list)-1].(type) {
+	case *ast.ExprStmt:
+		return append(stmts, sink(t.probe(last.X, t.transpileExpr(last.X)))...)
+	case *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt:
+		return append(stmts, t.lowerBranches(last, sink, zero))
+	default:
+		t.errorf(last.Pos(), "last statement of if/switch expression branch must be a value")
+		return append(stmts, t.transpileStmt(last))
+	}
+}
+
+// transpileInit транспилирует init-оператор if/switch (или возвращает nil).
+func (t *Transpiler) transpileInit(init ast.Stmt) ast.Stmt {
+	if init == nil {
+		return nil
+	}
+	return t.transpileStmt(init)
+}
@@ -0,0 +1,246 @@
+package transpiler
+
+import (
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// ternaryParts — части тернарного оператора, транспилированного в IIFE:
+// по ним оператор, в котором стоит IIFE, разворачивается в обычный if/else.
+type ternaryParts struct {
+	cond, then, els ast.Expr
+	typ             ast.Expr // тип результата IIFE
+}
+
+// lowerTernaries разворачивает тернарные операторы транспилированного
+// оператора в if/else без IIFE:
+//
+//	label := n > 0 ? "positive" : "other"
+//	fmt.Println(ok ? "yes" : "no")
+//
+// →
+//
+//	var label string
+//	if n > 0 {
+//	    label = "positive"
+//	} else {
+//	    label = "other"
+//	}
+//	var _godslTern string
+//	if ok {
+//	    _godslTern = "yes"
+//	} else {
+//	    _godslTern = "no"
+//	}
+//	fmt.Println(_godslTern)
+//
+// Разворачиваются вся правая часть присваивания, значения объявлений var,
+// результаты return и аргументы вызовов (в том числе вложенных). Тернарный оператор выносится
+// перед оператором, только если до него не вычисляется ничего с побочными
+// эффектами (вызовы, чтение из канала), иначе порядок вычисления
+// изменился бы, и остаётся IIFE.
+func (t *Transpiler) lowerTernaries(stmt ast.Stmt) []ast.Stmt {
+	if len(t.ternaries) == 0 {
+		return []ast.Stmt{stmt}
+	}
+	var pre []ast.Stmt
+	impure := false
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range s.Lhs {
+			if !isPureExpr(lhs) {
+				return []ast.Stmt{stmt}
+			}
+		}
+		if len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+			if p, ok := t.ternaryOf(s.Rhs[0]); ok {
+				return t.lowerTernaryAssign(s, p)
+			}
+		}
+		for i, rhs := range s.Rhs {
+			s.Rhs[i] = t.liftTernary(rhs, &pre, &impure)
+		}
+	case *ast.ReturnStmt:
+		if len(s.Results) == 1 {
+			if p, ok := t.ternaryOf(s.Results[0]); ok && t.sameResultType(p) {
+				return []ast.Stmt{t.ternaryIf(p, returnSink)}
+			}
+		}
+		for i, res := range s.Results {
+			s.Results[i] = t.liftTernary(res, &pre, &impure)
+		}
+	case *ast.ExprStmt:
+		if p, ok := t.ternaryOf(s.X); ok {
+			return []ast.Stmt{t.ternaryIf(p, func(v ast.Expr) []ast.Stmt {
+				return []ast.Stmt{&ast.ExprStmt{X: v}}
+			})}
+		}
+		s.X = t.liftTernary(s.X, &pre, &impure)
+	case *ast.DeclStmt:
+		gen, ok := s.Decl.(*ast.GenDecl)
+		if !ok {
+			return []ast.Stmt{stmt}
+		}
+		if vs := singleValueSpec(gen); vs != nil && vs.Type != nil {
+			name := vs.Names[0]
+			if p, ok := t.ternaryOf(vs.Values[0]); ok && t.sourceText(p.typ) == t.sourceText(vs.Type) && !ternaryRefers(p, name.Name) {
+				return []ast.Stmt{ternaryVar(name, vs.Type), t.ternaryIf(p, func(v ast.Expr) []ast.Stmt {
+					return []ast.Stmt{coalesceAssign(name, token.ASSIGN, v)}
+				})}
+			}
+		}
+		for _, spec := range gen.Specs {
+			if vs, ok := spec.(*ast.ValueSpec); ok {
+				for i, v := range vs.Values {
+					vs.Values[i] = t.liftTernary(v, &pre, &impure)
+				}
+			}
+		}
+	}
+	return append(pre, stmt)
+}
+
+// lowerTernaryAssign разворачивает присваивание, вся правая часть которого —
+// тернарный оператор: x := c ? a : b объявляет x типа результата и
+// присваивает ей значение в ветках. Остальные присваивания получают
+// значение через временную переменную: тип x может быть интерфейсом, и
+// значения веток без приведения к типу результата попали бы в него с
+// другим динамическим типом (1 вместо float64(1)).
+func (t *Transpiler) lowerTernaryAssign(s *ast.AssignStmt, p ternaryParts) []ast.Stmt {
+	// Ветки, ссылающиеся на одноимённую внешнюю переменную, увидели бы
+	// новую, поэтому значение вычисляется во временную переменную.
+	name, ok := s.Lhs[0].(*ast.Ident)
+	if s.Tok != token.DEFINE || !ok || ternaryRefers(p, name.Name) {
+		var pre []ast.Stmt
+		impure := false
+		s.Rhs[0] = t.liftTernary(s.Rhs[0], &pre, &impure)
+		return append(pre, s)
+	}
+	return []ast.Stmt{
+		ternaryVar(name, p.typ),
+		t.ternaryIf(p, func(v ast.Expr) []ast.Stmt {
+			return []ast.Stmt{coalesceAssign(name, token.ASSIGN, v)}
+		}),
+	}
+}
+
+// liftTernary обходит выражение в порядке вычисления и выносит тернарные
+// операторы — само выражение или аргументы вызовов — во временные
+// переменные, объявленные в pre. impure отмечает, что уже встретилось
+// выражение с побочными эффектами: после него ничего не выносится.
+func (t *Transpiler) liftTernary(x ast.Expr, pre *[]ast.Stmt, impure *bool) ast.Expr {
+	if *impure {
+		return x
+	}
+	if p, ok := t.ternaryOf(x); ok {
+		t.ternaryCount++
+		name := "_godslTern"
+		if t.ternaryCount > 1 {
+			name += strconv.Itoa(t.ternaryCount)
+		}
+		tmp := &ast.Ident{NamePos: token.NoPos, Name: name}
+		*pre = append(*pre, ternaryVar(tmp, p.typ), t.ternaryIf(p, func(v ast.Expr) []ast.Stmt {
+			return []ast.Stmt{coalesceAssign(tmp, token.ASSIGN, v)}
+		}))
+		return tmp
+	}
+	call, ok := x.(*ast.CallExpr)
+	if !ok || !isPureExpr(call.Fun) || !t.hasTernaryArg(call) {
+		if !isPureExpr(x) {
+			*impure = true
+		}
+		return x
+	}
+	for i, arg := range call.Args {
+		call.Args[i] = t.liftTernary(arg, pre, impure)
+	}
+	*impure = true
+	return call
+}
+
+// hasTernaryArg сообщает, есть ли среди аргументов вызова (в том числе
+// вложенных вызовов) тернарный оператор. Вызовы без них не копируются и
+// не меняются: это могут быть узлы исходного файла.
+func (t *Transpiler) hasTernaryArg(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if _, ok := t.ternaryOf(arg); ok {
+			return true
+		}
+		if inner, ok := arg.(*ast.CallExpr); ok && t.hasTernaryArg(inner) {
+			return true
+		}
+	}
+	return false
+}
+
+// ternaryIf строит if cond { sink(then) } else { sink(else) }. Вложенные
+// тернарные операторы того же типа разворачиваются во вложенные if и
+// else if.
+func (t *Transpiler) ternaryIf(p ternaryParts, sink matchSink) *ast.IfStmt {
+	s := &ast.IfStmt{If: token.NoPos, Cond: p.cond, Body: &ast.BlockStmt{List: t.ternaryBranch(p, p.then, sink)}}
+	if inner, ok := t.nestedTernary(p, p.els); ok {
+		s.Else = t.ternaryIf(inner, sink)
+	} else {
+		s.Else = &ast.BlockStmt{List: sink(p.els)}
+	}
+	return s
+}
+
+// sameResultType сообщает, совпадает ли тип результата тернарного
+// оператора с типом результата функции: тогда ветки могут вернуть значения
+// напрямую.
+func (t *Transpiler) sameResultType(p ternaryParts) bool {
+	return t.returnTypeHint != nil && t.sourceText(p.typ) == t.sourceText(t.returnTypeHint)
+}
+
+// ternaryBranch возвращает операторы ветки со значением v.
+func (t *Transpiler) ternaryBranch(p ternaryParts, v ast.Expr, sink matchSink) []ast.Stmt {
+	if inner, ok := t.nestedTernary(p, v); ok {
+		return []ast.Stmt{t.ternaryIf(inner, sink)}
+	}
+	return sink(v)
+}
+
+// nestedTernary возвращает части тернарного оператора в ветке v, если его
+// тип совпадает с типом внешнего: иначе значения его веток нужно сначала
+// привести к его типу, и он остаётся IIFE.
+func (t *Transpiler) nestedTernary(outer ternaryParts, v ast.Expr) (ternaryParts, bool) {
+	inner, ok := t.ternaryOf(v)
+	return inner, ok && t.sourceText(inner.typ) == t.sourceText(outer.typ)
+}
+
+// ternaryOf возвращает части тернарного оператора, если выражение — его IIFE.
+func (t *Transpiler) ternaryOf(x ast.Expr) (ternaryParts, bool) {
+	call, ok := ast.Unparen(x).(*ast.CallExpr)
+	if !ok {
+		return ternaryParts{}, false
+	}
+	p, ok := t.ternaries[call]
+	return p, ok
+}
+
+// ternaryRefers сообщает, упоминается ли имя name в частях тернарного
+// оператора.
+func ternaryRefers(p ternaryParts, name string) bool {
+	found := false
+	for _, x := range []ast.Expr{p.cond, p.then, p.els} {
+		ast.Inspect(x, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == name {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// ternaryVar строит var name T.
+func ternaryVar(name *ast.Ident, typ ast.Expr) ast.Stmt {
+	return &ast.DeclStmt{Decl: &ast.GenDecl{
+		TokPos: token.NoPos,
+		Tok:    token.VAR,
+		Specs:  []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{name}, Type: typ}},
+	}}
+}
@@ -4,6 +4,8 @@ package transpiler
 import (
 	"bytes"
 	"fmt"
+	"go/types"
+	"io"
 	"strings"
 
 	"github.com/sviridovkonstantin42/godsl/internal/format"
@@ -15,40 +17,125 @@ import (
 	"github.com/sviridovkonstantin42/godsl/internal/token"
 )
 
+// Options задаёт режимы транспиляции.
+type Options struct {
+	Filename    string    // имя исходного .godsl файла (для позиций в сообщениях)
+	TraceErrors bool      // оборачивать ошибки из ?, throw и must позицией в .godsl (errtrace)
+	Warnings    io.Writer // куда выводить предупреждения (например, неполный switch по enum); nil — не выводить
+
+	// Signatures — функции с параметрами по умолчанию из других файлов
+	// проекта: путь импорта пакета ("" — пакет самого файла) → имя → сигнатура.
+	Signatures map[string]map[string]*FuncSignature
+
+	// Package — объявления других файлов пакета на Go: имя файла → код
+	// (см. PackageDecls). Пробный проход go/types проверяет файл вместе с
+	// ними и видит типы и функции соседних файлов.
+	Package map[string]string
+
+	// Imports — объявления других пакетов проекта на Go: путь импорта →
+	// имя файла → код. Пробный проход загружает из них пакеты проекта,
+	// которые импортирует файл.
+	Imports map[string]map[string]string
+
+	// Dir — директория исходного файла. Зависимости модуля, которых нет в
+	// Imports и стандартной библиотеке, загружаются из исходников
+	// относительно неё.
+	Dir string
+}
+
 type Transpiler struct {
 	fset             *token.FileSet
+	opts             Options
 	comments         []*ast.CommentGroup
-	errcheckComments map[token.Pos]bool // Позиции комментариев @errcheck для удаления
-	returnTypeHint   ast.Expr           // тип первого возвращаемого значения текущей функции
+	errcheckComments map[token.Pos]bool                   // Позиции комментариев @errcheck для удаления
+	returnTypeHint   ast.Expr                             // тип первого возвращаемого значения текущей функции
+	funcName         string                               // имя текущей функции (для трассировки ошибок)
+	ctxName          string                               // параметр context.Context текущей функции, если есть
+	testName         string                               // параметр *testing.T (B, F, TB) текущей функции, если есть
+	errTarget        errTarget                            // куда ? и throw передают ошибку; nil — return из функции
+	retryCount       int                                  // число retry-блоков в текущей функции
+	parallelCount    int                                  // число parallel-блоков в текущей функции
+	errorOnlyFuncs   map[string]bool                      // функции файла, возвращающие только error
+	futureSlices     map[string]bool                      // срезы фьючерсов в текущей функции (для await)
+	optCount         int                                  // число временных переменных ?. в текущей функции
+	matchCount       int                                  // число временных переменных match в текущей функции
+	pipeCount        int                                  // число временных переменных конвейеров в текущей функции
+	ternaryCount     int                                  // число временных переменных тернарных операторов в текущей функции
+	ternaries        map[*ast.CallExpr]ternaryParts       // IIFE тернарных операторов (для развёртки в if/else)
+	errs             []error                              // ошибки транспиляции
+	importNames      map[string]string                    // импорты исходного файла: путь → имя
+	probing          bool                                 // идёт пробный проход для go/types
+	probes           []ast.Node                           // выражения пробного прохода по номеру маркера
+	exprTypes        map[ast.Node]types.Type              // типы выражений, выведенные пробным проходом
+	callees          map[ast.Node]types.Object            // вызываемые функции, выведенные пробным проходом
+	lambdaReturns    map[*ast.ReturnStmt]bool             // return блочных лямбд, типы значений которых запрашивает пробный проход
+	untypedExprs     map[ast.Node]bool                    // нетипизированные константы и тернарные операторы с ними в обеих ветках
+	prevTypes        map[ast.Node]types.Type              // типы предыдущего пробного прохода (для генераторов)
+	typesPkg         *types.Package                       // пакет файла по данным go/types
+	importer         *projectImporter                     // импортёр пакетов для пробного прохода
+	imports          map[string]string                    // импорты, которые нужны сгенерированному коду: путь → имя
+	enumMembers      map[string]*ast.EnumDecl             // enum файла по именам членов
+	declCode         []string                             // сгенерированные методы enum и record, дописываемые в конец файла
+	signatures       map[string]map[string]*FuncSignature // функции с параметрами по умолчанию по пакетам
+	resolvedCalls    map[*ast.CallExpr]*ast.CallExpr      // вызовы с подставленными аргументами по умолчанию
+	oldValues        map[*ast.CallExpr]*ast.Ident         // old(expr) в ensures → переменная со значением при входе
+	valMutations     []valMutation                        // изменения частей val-переменных (проверяются по типам)
 }
 
 // NewTranspiler создает новый экземпляр транспилятора
 func NewTranspiler() *Transpiler {
+	return NewTranspilerWithOptions(Options{})
+}
+
+// NewTranspilerWithOptions создает транспилятор с заданными режимами
+func NewTranspilerWithOptions(opts Options) *Transpiler {
 	return &Transpiler{
 		fset:             token.NewFileSet(),
+		opts:             opts,
 		errcheckComments: make(map[token.Pos]bool),
+		imports:          make(map[string]string),
 	}
 }
 
 func (t *Transpiler) Transpile(source string) (string, error) {
-	file, err := parser.ParseFile(t.fset, "", source, parser.ParseComments)
+	file, err := parser.ParseFile(t.fset, t.opts.Filename, source, parser.ParseComments)
 	if err != nil {
 		return "", fmt.Errorf("parse error: %v", err)
 	}
+	return t.transpileParsed(file)
+}
 
+// transpileParsed транспилирует разобранный файл в код Go.
+func (t *Transpiler) transpileParsed(file *ast.File) (string, error) {
 	t.comments = file.Comments
+	t.importNames = collectImportNames(file)
+	t.enumMembers = collectEnums(file)
+	t.signatures = t.packageSignatures(file)
+	t.resolvedCalls = make(map[*ast.CallExpr]*ast.CallExpr)
+	t.checkDefaultParams(file)
+	t.checkVals(file)
+	if needsTypeCheck(file) || len(t.valMutations) > 0 {
+		t.checkTypes(file)
+	}
+	t.checkValMutations()
 
 	newFile := t.transpileFile(file)
+	if len(t.errs) > 0 {
+		return "", fmt.Errorf("transpile error: %v", t.errs[0])
+	}
+	t.addImports(newFile)
 
 	newFile.Comments = t.filterComments(newFile.Comments)
 
 	var buf bytes.Buffer
-	err = format.Node(&buf, t.fset, newFile)
-	if err != nil {
+	if err := format.Node(&buf, t.fset, newFile); err != nil {
 		return "", fmt.Errorf("format error: %v", err)
 	}
 
+	t.checkEnumSwitches(file)
+
 	result := t.cleanupFormatting(buf.String())
+	result += strings.Join(t.declCode, "")
 	return result, nil
 }
 
@@ -120,11 +207,20 @@ func (t *Transpiler) transpileFile(file *ast.File) *ast.File {
 		Comments: file.Comments, // Сначала копируем все комментарии
 	}
 
+	t.errorOnlyFuncs = collectErrorOnlyFuncs(file)
+	t.declCode = nil
+
 	for _, decl := range file.Decls {
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			newDecl := t.transpileFuncDecl(funcDecl)
-			newFile.Decls = append(newFile.Decls, newDecl)
-		} else {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			newFile.Decls = append(newFile.Decls, t.transpileFuncDecl(decl))
+		case *ast.EnumDecl:
+			newFile.Decls = append(newFile.Decls, t.transpileEnumDecl(decl)...)
+		case *ast.RecordDecl:
+			newFile.Decls = append(newFile.Decls, t.transpileRecordDecl(decl)...)
+		case *ast.GenDecl:
+			newFile.Decls = append(newFile.Decls, t.transpileVarDecl(decl))
+		default:
 			newFile.Decls = append(newFile.Decls, decl)
 		}
 	}
@@ -132,24 +228,83 @@ func (t *Transpiler) transpileFile(file *ast.File) *ast.File {
 	return newFile
 }
 
+// transpileVarDecl транспилирует значения объявления var (например,
+// интерполированные строки). Остальные объявления не меняются.
+func (t *Transpiler) transpileVarDecl(decl *ast.GenDecl) *ast.GenDecl {
+	if decl.Tok != token.VAR {
+		return decl
+	}
+	changed := false
+	specs := make([]ast.Spec, len(decl.Specs))
+	for i, spec := range decl.Specs {
+		specs[i] = spec
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok || len(vs.Values) == 0 {
+			continue
+		}
+		values := make([]ast.Expr, len(vs.Values))
+		for j, v := range vs.Values {
+			// var f func(int) int = x => ...: сигнатуру лямбды задаёт тип f
+			if l, ok := v.(*ast.LambdaExpr); ok && vs.Type != nil {
+				values[j] = t.transpileTargetLambda(l, newValue(vs.Type))
+				continue
+			}
+			values[j] = t.transpileExpr(v)
+		}
+		for j := range values {
+			if values[j] != vs.Values[j] {
+				cp := *vs
+				cp.Values = values
+				specs[i] = &cp
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return decl
+	}
+	cp := *decl
+	cp.Specs = specs
+	return &cp
+}
+
 // transpileFuncDecl транспилирует функцию
 func (t *Transpiler) transpileFuncDecl(funcDecl *ast.FuncDecl) *ast.FuncDecl {
 	if funcDecl.Body == nil {
+		if len(funcDecl.Contracts) > 0 {
+			t.errorf(funcDecl.Contracts[0].Keyword, "requires and ensures need a function body")
+		}
 		return funcDecl
 	}
 
-	prev := t.returnTypeHint
+	prev, prevName, prevCtx, prevTest := t.returnTypeHint, t.funcName, t.ctxName, t.testName
 	t.returnTypeHint = extractFirstReturnType(funcDecl.Type)
-	defer func() { t.returnTypeHint = prev }()
+	t.funcName = funcDeclName(funcDecl)
+	t.ctxName = contextParamName(funcDecl.Type, t.importName("context"))
+	t.testName = testingParamName(funcDecl.Type, t.importName("testing"))
+	t.retryCount, t.parallelCount, t.optCount, t.matchCount, t.pipeCount, t.ternaryCount = 0, 0, 0, 0, 0, 0
+	t.futureSlices = collectFutureSlices(funcDecl)
+	defer func() { t.returnTypeHint, t.funcName, t.ctxName, t.testName = prev, prevName, prevCtx, prevTest }()
+
+	funcType := funcDecl.Type
+	var prologue []ast.Stmt
+	if funcDecl.Recv == nil && hasDefaults(funcType) {
+		funcType, prologue = t.lowerDefaultParams(funcDecl)
+	}
+
+	if len(funcDecl.Contracts) > 0 {
+		prologue = append(prologue, t.transpileContracts(funcDecl)...)
+	}
 
 	newBody := &ast.BlockStmt{}
-	newBody.List = t.transpileStmts(funcDecl.Body.List)
+	newBody.List = append(prologue, t.transpileStmts(funcDecl.Body.List)...)
 
 	return &ast.FuncDecl{
 		Doc:  funcDecl.Doc,
 		Recv: funcDecl.Recv,
 		Name: funcDecl.Name,
-		Type: funcDecl.Type,
+		Type: funcType,
 		Body: newBody,
 	}
 }
@@ -173,6 +328,9 @@ func (t *Transpiler) transpileStmts(stmts []ast.Stmt) []ast.Stmt {
 	var result []ast.Stmt
 
 	for _, stmt := range stmts {
+		if t.probing {
+			result = append(result, t.probeValRoots(stmt)...)
+		}
 		switch s := stmt.(type) {
 		case *ast.TryStmt:
 			transpiled := t.transpileTryStmt(s)
@@ -183,6 +341,43 @@ func (t *Transpiler) transpileStmts(stmts []ast.Stmt) []ast.Stmt {
 		case *ast.MustStmt:
 			transpiled := t.transpileMustStmt(s)
 			result = append(result, transpiled...)
+		case *ast.AssignStmt:
+			transpiled := t.transpileAssignStmt(s)
+			result = append(result, transpiled...)
+		case *ast.DeclStmt:
+			transpiled := t.transpileDeclStmt(s)
+			result = append(result, transpiled...)
+		case *ast.ThrowStmt:
+			transpiled := t.transpileThrowStmt(s)
+			result = append(result, transpiled...)
+		case *ast.RetryStmt:
+			transpiled := t.transpileRetryStmt(s)
+			result = append(result, transpiled...)
+		case *ast.ParallelStmt:
+			result = append(result, t.transpileParallelStmt(s))
+		case *ast.GuardStmt:
+			transpiled := t.transpileGuardStmt(s)
+			result = append(result, transpiled...)
+		case *ast.AssertStmt:
+			transpiled := t.transpileAssertStmt(s)
+			result = append(result, transpiled...)
+		case *ast.ReturnStmt:
+			transpiled := t.transpileReturnStmt(s)
+			result = append(result, transpiled...)
+		case *ast.ExprStmt:
+			if hasOptChain(s.X) {
+				result = append(result, t.transpileOptChainStmt(s.X)...)
+				continue
+			}
+			if m, ok := ast.Unparen(s.X).(*ast.MatchExpr); ok {
+				result = append(result, t.transpileMatchStmt(m)...)
+				continue
+			}
+			if x, ok := ast.Unparen(s.X).(*ast.PipeExpr); ok {
+				result = append(result, t.transpilePipeStmt(x)...)
+				continue
+			}
+			result = append(result, t.lowerTernaries(t.transpileStmt(s))...)
 		default:
 			newStmt := t.transpileStmt(stmt)
 			result = append(result, newStmt)
@@ -204,19 +399,35 @@ func (t *Transpiler) transpileStmt(stmt ast.Stmt) ast.Stmt {
 	case *ast.IfStmt:
 		return &ast.IfStmt{
 			If:   s.If,
-			Init: s.Init,
+			Init: t.transpileInit(s.Init),
 			Cond: t.transpileExpr(s.Cond),
 			Body: &ast.BlockStmt{
 				List: t.transpileStmts(s.Body.List),
 			},
-			Else: s.Else,
+			Else: t.transpileInit(s.Else),
 		}
 	case *ast.ForStmt:
+		if s.In != nil {
+			return t.transpileForIn(s)
+		}
 		return &ast.ForStmt{
 			For:  s.For,
-			Init: s.Init,
+			Init: t.transpileInit(s.Init),
 			Cond: t.transpileExpr(s.Cond),
-			Post: s.Post,
+			Post: t.transpileInit(s.Post),
+			Body: &ast.BlockStmt{
+				List: t.transpileStmts(s.Body.List),
+			},
+		}
+	case *ast.RangeStmt:
+		return &ast.RangeStmt{
+			For:    s.For,
+			Key:    s.Key,
+			Value:  s.Value,
+			TokPos: s.TokPos,
+			Tok:    s.Tok,
+			Range:  s.Range,
+			X:      t.transpileExpr(s.X),
 			Body: &ast.BlockStmt{
 				List: t.transpileStmts(s.Body.List),
 			},
@@ -228,6 +439,13 @@ func (t *Transpiler) transpileStmt(stmt ast.Stmt) ast.Stmt {
 		}
 		newRhs := make([]ast.Expr, len(s.Rhs))
 		for i, e := range s.Rhs {
+			// f = x => ...: сигнатуру лямбды задаёт тип f
+			if l, ok := e.(*ast.LambdaExpr); ok && s.Tok == token.ASSIGN && len(s.Lhs) == len(s.Rhs) {
+				if blank, _ := s.Lhs[i].(*ast.Ident); blank == nil || blank.Name != "_" {
+					newRhs[i] = t.transpileTargetLambda(l, newLhs[i])
+					continue
+				}
+			}
 			newRhs[i] = t.transpileExpr(e)
 		}
 		return &ast.AssignStmt{Lhs: newLhs, TokPos: s.TokPos, Tok: s.Tok, Rhs: newRhs}
@@ -236,26 +454,108 @@ func (t *Transpiler) transpileStmt(stmt ast.Stmt) ast.Stmt {
 	case *ast.ReturnStmt:
 		newResults := make([]ast.Expr, len(s.Results))
 		for i, e := range s.Results {
+			if tern, ok := e.(*ast.TernaryExpr); ok && i == 0 {
+				newResults[i] = t.transpileTernaryExpr(tern, t.returnTypeHint)
+				continue
+			}
+			if l, ok := e.(*ast.LambdaExpr); ok && i == 0 && t.returnTypeHint != nil {
+				newResults[i] = t.transpileTargetLambda(l, newValue(t.returnTypeHint))
+				continue
+			}
 			newResults[i] = t.transpileExpr(e)
 		}
 		return &ast.ReturnStmt{Return: s.Return, Results: newResults}
+	case *ast.LabeledStmt:
+		return &ast.LabeledStmt{Label: s.Label, Colon: s.Colon, Stmt: t.transpileStmt(s.Stmt)}
+	case *ast.SwitchStmt:
+		return &ast.SwitchStmt{
+			Switch: s.Switch,
+			Init:   t.transpileInit(s.Init),
+			Tag:    t.transpileExpr(s.Tag),
+			Body:   t.transpileClauses(s.Body),
+		}
+	case *ast.TypeSwitchStmt:
+		return &ast.TypeSwitchStmt{
+			Switch: s.Switch,
+			Init:   t.transpileInit(s.Init),
+			Assign: s.Assign,
+			Body:   t.transpileClauses(s.Body),
+		}
+	case *ast.SelectStmt:
+		return &ast.SelectStmt{Select: s.Select, Body: t.transpileClauses(s.Body)}
+	case *ast.DeferStmt:
+		if call, ok := t.transpileExpr(s.Call).(*ast.CallExpr); ok {
+			return &ast.DeferStmt{Defer: s.Defer, Call: call}
+		}
+		return stmt
+	case *ast.GoStmt:
+		if call, ok := t.transpileExpr(s.Call).(*ast.CallExpr); ok {
+			return &ast.GoStmt{Go: s.Go, Call: call}
+		}
+		return stmt
 	case *ast.SendStmt:
 		return &ast.SendStmt{Chan: t.transpileExpr(s.Chan), Arrow: s.Arrow, Value: t.transpileExpr(s.Value)}
 	case *ast.ThrowStmt:
-		return t.transpileThrowStmt(s)
+		return singleStmt(t.transpileThrowStmt(s))
 	default:
 		return stmt
 	}
 }
 
+// transpileClauses транспилирует ветки switch, type switch и select.
+func (t *Transpiler) transpileClauses(body *ast.BlockStmt) *ast.BlockStmt {
+	clauses := make([]ast.Stmt, len(body.List))
+	for i, c := range body.List {
+		switch c := c.(type) {
+		case *ast.CaseClause:
+			// List == nil — ветка default
+			list := c.List
+			if list != nil {
+				list = t.transpileExprs(list)
+			}
+			clauses[i] = &ast.CaseClause{Case: c.Case, List: list, Colon: c.Colon, Body: t.transpileStmts(c.Body)}
+		case *ast.CommClause:
+			clauses[i] = &ast.CommClause{Case: c.Case, Comm: t.transpileInit(c.Comm), Colon: c.Colon, Body: t.transpileStmts(c.Body)}
+		default:
+			clauses[i] = c
+		}
+	}
+	return &ast.BlockStmt{Lbrace: body.Lbrace, List: clauses, Rbrace: body.Rbrace}
+}
+
 // transpileExpr рекурсивно обходит выражение, заменяя TernaryExpr на IIFE.
 func (t *Transpiler) transpileExpr(expr ast.Expr) ast.Expr {
 	if expr == nil {
 		return nil
 	}
+	if hasOptChain(expr) {
+		return t.transpileOptChainExpr(expr)
+	}
 	switch x := expr.(type) {
 	case *ast.TernaryExpr:
-		return t.transpileTernaryExpr(x)
+		return t.transpileTernaryExpr(x, nil)
+	case *ast.FallbackExpr:
+		return t.transpileFallbackExpr(x)
+	case *ast.CoalesceExpr:
+		return t.transpileCoalesceExpr(x)
+	case *ast.MatchExpr:
+		return t.transpileMatchExpr(x)
+	case *ast.StmtExpr:
+		return t.transpileStmtExpr(x)
+	case *ast.InterpolatedLit:
+		return t.transpileInterpolatedLit(x)
+	case *ast.LambdaExpr:
+		return t.transpileLambda(x, nil)
+	case *ast.ComprehensionExpr:
+		return t.transpileComprehension(x)
+	case *ast.PipeExpr:
+		return t.transpilePipeExpr(x)
+	case *ast.FuncLit:
+		return t.transpileFuncLit(x)
+	case *ast.AsyncExpr:
+		return t.transpileAsyncExpr(x)
+	case *ast.AwaitExpr:
+		return t.transpileAwaitExpr(x)
 	case *ast.BinaryExpr:
 		newX := t.transpileExpr(x.X)
 		newY := t.transpileExpr(x.Y)
@@ -282,14 +582,30 @@ func (t *Transpiler) transpileExpr(expr ast.Expr) ast.Expr {
 		}
 		return &ast.ParenExpr{Lparen: x.Lparen, X: newX, Rparen: x.Rparen}
 	case *ast.CallExpr:
+		if v, ok := t.oldValues[x]; ok {
+			return v
+		}
+		src := x
+		x = t.resolveCallArgs(x)
 		changed := false
 		newFun := t.transpileExpr(x.Fun)
+		if t.probing && mayTerminate(src) {
+			// _godslProbe(id, log.Fatal)(...): функция для проверки guard.
+			newFun = t.probe(terminatorProbe{src}, newFun)
+		}
 		if newFun != x.Fun {
 			changed = true
 		}
 		newArgs := make([]ast.Expr, len(x.Args))
 		for i, arg := range x.Args {
-			newArgs[i] = t.transpileExpr(arg)
+			switch arg := arg.(type) {
+			case *ast.LambdaExpr:
+				newArgs[i] = t.transpileLambda(arg, t.lambdaExpected(x, i))
+			case *ast.TernaryExpr:
+				newArgs[i] = t.transpileTernaryExpr(arg, t.typeExpr(t.paramType(x, i)))
+			default:
+				newArgs[i] = t.transpileExpr(arg)
+			}
 			if newArgs[i] != arg {
 				changed = true
 			}
@@ -297,7 +613,11 @@ func (t *Transpiler) transpileExpr(expr ast.Expr) ast.Expr {
 		if !changed {
 			return x
 		}
-		return &ast.CallExpr{Fun: newFun, Lparen: x.Lparen, Args: newArgs, Ellipsis: x.Ellipsis, Rparen: x.Rparen}
+		call := &ast.CallExpr{Fun: newFun, Lparen: x.Lparen, Args: newArgs, Ellipsis: x.Ellipsis, Rparen: x.Rparen}
+		if t.probing && needsCalleeType(x) {
+			return t.probe(calleeProbe{x}, call)
+		}
+		return call
 	case *ast.IndexExpr:
 		newX := t.transpileExpr(x.X)
 		newIdx := t.transpileExpr(x.Index)
@@ -349,19 +669,29 @@ func (t *Transpiler) transpileExpr(expr ast.Expr) ast.Expr {
 //
 //	func() T { if cond { return then }; return else }()
 //
-// Тип T выводится из ветвей (литералы) или из контекста возвращаемого типа
-// текущей функции. Если вывести тип невозможно, используется any.
-func (t *Transpiler) transpileTernaryExpr(x *ast.TernaryExpr) ast.Expr {
+// Тип T выбирает ternaryType; expected — тип, которого ждёт контекст
+// (параметр вызываемой функции или результат return), или nil. В пробном
+// проходе вместо IIFE подставляется _godslTernary(cond, then, else): go/types
+// выводит общий тип веток так же, как параметр типа обобщённой функции.
+func (t *Transpiler) transpileTernaryExpr(x *ast.TernaryExpr, expected ast.Expr) ast.Expr {
 	cond := t.transpileExpr(x.Cond)
 	then := t.transpileExpr(x.Then)
 	els := t.transpileExpr(x.Else)
 
-	retType := t.inferTernaryReturnType(x.Then, x.Else)
+	if t.probing {
+		return t.probe(x, &ast.CallExpr{
+			Fun:  &ast.Ident{NamePos: token.NoPos, Name: probeTernaryName},
+			Args: []ast.Expr{cond, then, els},
+		})
+	}
+
+	retType := t.ternaryType(x, expected)
 	if retType == nil {
+		t.requireResolved(x)
 		retType = &ast.Ident{NamePos: token.NoPos, Name: "any"}
 	}
 
-	return &ast.CallExpr{
+	iife := &ast.CallExpr{
 		Fun: &ast.FuncLit{
 			Type: &ast.FuncType{
 				Func:   token.NoPos,
@@ -400,32 +730,93 @@ func (t *Transpiler) transpileTernaryExpr(x *ast.TernaryExpr) ast.Expr {
 		Lparen: token.NoPos,
 		Rparen: token.NoPos,
 	}
+	if t.ternaries == nil {
+		t.ternaries = make(map[*ast.CallExpr]ternaryParts)
+	}
+	t.ternaries[iife] = ternaryParts{cond: cond, then: then, els: els, typ: retType}
+	return iife
 }
 
-// inferTernaryReturnType выводит тип возвращаемого значения тернарного оператора.
+// ternaryType выбирает тип результата тернарного оператора.
 //
 // Порядок приоритетов:
-//  1. Оба литерала одного вида (int/float/string/rune/bool) → этот тип.
-//  2. Один из литералов известного типа → его тип.
-//  3. Контекст: возвращаемый тип текущей функции (t.returnTypeHint).
-//  4. Иначе → nil (будет подставлено any).
+//  1. Обе ветки — нетипизированные константы, а контекст задаёт конкретный
+//     тип → тип контекста, как при присваивании константы в Go.
+//  2. Тип, выведенный go/types по веткам: общий тип, а для констант — тип
+//     по умолчанию старшего вида (cond ? 1 : 2.5 → float64).
+//  3. Тип литералов веток.
+//  4. Тип контекста.
+//  5. Иначе → nil (будет подставлено any).
+func (t *Transpiler) ternaryType(x *ast.TernaryExpr, expected ast.Expr) ast.Expr {
+	if t.untypedExprs[x] && expected != nil && !isAnyType(expected) {
+		return expected
+	}
+	if typ := t.typeExpr(t.typeOf(x)); typ != nil {
+		return typ
+	}
+	if typ := literalBranchType(x.Then, x.Else); typ != nil {
+		return typ
+	}
+	return expected
+}
+
+// isAnyType сообщает, является ли выражение типа пустым интерфейсом:
+// any или interface{}.
+func isAnyType(typ ast.Expr) bool {
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		return typ.Name == "any"
+	case *ast.InterfaceType:
+		return typ.Methods == nil || len(typ.Methods.List) == 0
+	}
+	return false
+}
+
+// inferTernaryReturnType выводит тип значения выражения с ветками then и
+// else (else может быть nil) без данных go/types.
+//
+// Порядок приоритетов:
+//  1. Тип литералов веток (literalBranchType).
+//  2. Контекст: возвращаемый тип текущей функции (t.returnTypeHint).
+//  3. Иначе → nil (будет подставлено any).
 func (t *Transpiler) inferTernaryReturnType(then, els ast.Expr) ast.Expr {
+	if typ := literalBranchType(then, els); typ != nil {
+		return typ
+	}
+	// Если ветки не содержат литералов — используем подсказку из контекста функции.
+	return t.returnTypeHint
+}
+
+// literalBranchType выводит тип по литералам веток. Числовые литералы
+// разных видов приводятся к старшему, как нетипизированные константы Go:
+// 1 и 'a' → rune, 1 и 2.5 → float64. Если литерал только в одной ветке,
+// используется его тип. Возвращает nil, если литералов нет.
+func literalBranchType(then, els ast.Expr) ast.Expr {
 	thenType := inferLiteralType(then)
 	elsType := inferLiteralType(els)
-
-	if thenType != nil && elsType != nil {
-		// Оба типа выведены из литералов: используем тип ветки then
-		// (если ветки конфликтуют, компилятор Go всё равно выдаст ошибку).
-		return thenType
-	}
-	if thenType != nil {
-		return thenType
+	if thenType == nil {
+		return elsType
 	}
-	if elsType != nil {
+	if elsType != nil && numericRank(thenType) > 0 && numericRank(elsType) > numericRank(thenType) {
 		return elsType
 	}
-	// Если ветки не содержат литералов — используем подсказку из контекста функции.
-	return t.returnTypeHint
+	// Для конфликтующих видов (строка и число) компилятор Go всё равно
+	// выдаст ошибку, поэтому берётся тип ветки then.
+	return thenType
+}
+
+// numericRank возвращает старшинство вида числового литерала: int < rune <
+// float64. Для остальных типов — 0.
+func numericRank(typ ast.Expr) int {
+	switch typ.(*ast.Ident).Name {
+	case "int":
+		return 1
+	case "rune":
+		return 2
+	case "float64":
+		return 3
+	}
+	return 0
 }
 
 // inferLiteralType пытается вывести Go-тип выражения по его синтаксису.
@@ -460,39 +851,386 @@ func inferLiteralType(expr ast.Expr) ast.Expr {
 	return nil
 }
 
+// transpileAssignStmt транспилирует присваивание. Если вся правая часть —
+// выражение «значение или значение по умолчанию», оно разворачивается в
+// обычные операторы без IIFE:
+//
+//	port := strconv.Atoi(s) ?: 8080
+//
+// →
+//
+//	port, err := strconv.Atoi(s)
+//	if err != nil {
+//	    port = 8080
+//	}
+func (t *Transpiler) transpileAssignStmt(s *ast.AssignStmt) []ast.Stmt {
+	if len(s.Lhs) == 1 && len(s.Rhs) == 1 && (s.Tok == token.DEFINE || s.Tok == token.ASSIGN) {
+		if fb, ok := ast.Unparen(s.Rhs[0]).(*ast.FallbackExpr); ok {
+			return t.transpileFallbackAssign(s, fb)
+		}
+		if c, ok := ast.Unparen(s.Rhs[0]).(*ast.CoalesceExpr); ok {
+			return t.transpileCoalesceAssign(s.Lhs[0], s.Tok, c)
+		}
+		if m, ok := ast.Unparen(s.Rhs[0]).(*ast.MatchExpr); ok {
+			return t.transpileMatchAssign(s.Lhs[0], s.Tok, m)
+		}
+		if x, ok := ast.Unparen(s.Rhs[0]).(*ast.StmtExpr); ok {
+			return t.transpileStmtExprAssign(s.Lhs[0], s.Tok, x)
+		}
+		if x, ok := ast.Unparen(s.Rhs[0]).(*ast.PipeExpr); ok {
+			return t.transpilePipeAssign(s.Lhs[0], s.Tok, x)
+		}
+		if x, ok := ast.Unparen(s.Rhs[0]).(*ast.ComprehensionExpr); ok {
+			return t.transpileComprehensionAssign(s.Lhs[0], s.Tok, x)
+		}
+		if hasOptChain(s.Rhs[0]) {
+			return t.transpileOptChainAssign(s.Lhs[0], s.Tok, s.Rhs[0], nil)
+		}
+	}
+	return t.lowerTernaries(t.transpileStmt(s))
+}
+
+// transpileDeclStmt транспилирует локальное объявление var. Объявление
+// одной переменной со значением, которое разворачивается в операторы
+// (?:, ??, match, if/switch-выражение, |>, ?. и тернарный оператор),
+// транспилируется как присваивание:
+//
+//	var n = a ?? b            →   n := a ?? b
+//	var q int64 = f() ?: 5    →   var q int64
+//	                              q = f() ?: 5
+//
+// Значения остальных объявлений транспилируются как выражения.
+func (t *Transpiler) transpileDeclStmt(s *ast.DeclStmt) []ast.Stmt {
+	gen, ok := s.Decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.VAR {
+		return []ast.Stmt{s}
+	}
+	if vs := singleValueSpec(gen); vs != nil && loweredValue(vs.Values[0]) {
+		name, value := vs.Names[0], vs.Values[0]
+		tern, isTernary := ast.Unparen(value).(*ast.TernaryExpr)
+		switch {
+		case vs.Type == nil:
+			return t.transpileAssignStmt(&ast.AssignStmt{Lhs: []ast.Expr{name}, TokPos: token.NoPos, Tok: token.DEFINE, Rhs: vs.Values})
+		case isTernary:
+			// Константы веток получают тип переменной, как при var x T = c.
+			cp := *vs
+			cp.Values = []ast.Expr{t.transpileTernaryExpr(tern, vs.Type)}
+			return t.lowerTernaries(&ast.DeclStmt{Decl: &ast.GenDecl{TokPos: gen.TokPos, Tok: token.VAR, Specs: []ast.Spec{&cp}}})
+		case !usesIdent(name.Name, value):
+			// В var x T = v имя x в v относится к внешней переменной,
+			// поэтому такое объявление не разделяется.
+			decl := &ast.DeclStmt{Decl: &ast.GenDecl{
+				TokPos: gen.TokPos,
+				Tok:    token.VAR,
+				Specs:  []ast.Spec{&ast.ValueSpec{Names: vs.Names, Type: vs.Type}},
+			}}
+			assign := &ast.AssignStmt{Lhs: []ast.Expr{name}, TokPos: token.NoPos, Tok: token.ASSIGN, Rhs: vs.Values}
+			return append([]ast.Stmt{decl}, t.transpileAssignStmt(assign)...)
+		}
+	}
+	return t.lowerTernaries(&ast.DeclStmt{Decl: t.transpileVarDecl(gen)})
+}
+
+// singleValueSpec возвращает спецификацию объявления var с одной
+// переменной и одним значением или nil.
+func singleValueSpec(gen *ast.GenDecl) *ast.ValueSpec {
+	if len(gen.Specs) != 1 {
+		return nil
+	}
+	vs, ok := gen.Specs[0].(*ast.ValueSpec)
+	if !ok || len(vs.Names) != 1 || len(vs.Values) != 1 {
+		return nil
+	}
+	return vs
+}
+
+// loweredValue сообщает, разворачивается ли значение, занимающее всю
+// правую часть присваивания, в операторы (см. transpileAssignStmt).
+func loweredValue(x ast.Expr) bool {
+	switch ast.Unparen(x).(type) {
+	case *ast.FallbackExpr, *ast.CoalesceExpr, *ast.MatchExpr, *ast.StmtExpr, *ast.PipeExpr, *ast.TernaryExpr, *ast.ComprehensionExpr:
+		return true
+	}
+	return hasOptChain(x)
+}
+
+// transpileReturnStmt транспилирует return; return x ?? def, return a?.B,
+// return match ... и return if/switch ... разворачиваются без IIFE.
+func (t *Transpiler) transpileReturnStmt(s *ast.ReturnStmt) []ast.Stmt {
+	if t.probing && t.lambdaReturns[s] {
+		return t.probeLambdaReturn(s)
+	}
+	if len(s.Results) == 1 {
+		if c, ok := ast.Unparen(s.Results[0]).(*ast.CoalesceExpr); ok {
+			return t.transpileCoalesceReturn(c)
+		}
+		if m, ok := ast.Unparen(s.Results[0]).(*ast.MatchExpr); ok {
+			return t.transpileMatchReturn(m)
+		}
+		if x, ok := ast.Unparen(s.Results[0]).(*ast.StmtExpr); ok {
+			return t.transpileStmtExprReturn(x)
+		}
+		if x, ok := ast.Unparen(s.Results[0]).(*ast.PipeExpr); ok {
+			return t.transpilePipeReturn(x)
+		}
+		if hasOptChain(s.Results[0]) {
+			return t.transpileOptChainReturn(s.Results[0])
+		}
+	}
+	return t.lowerTernaries(t.transpileStmt(s))
+}
+
+// transpileFallbackAssign разворачивает lhs := X ?: Fallback в присваивание
+// с проверкой ошибки. Fallback вычисляется только при ошибке. Ошибка
+// сохраняется в _godslFallbackErr, чтобы не требовать и не перезаписывать
+// переменную err пользователя:
+//
+//	port := strconv.Atoi(s) ?: 8080   →   port, _godslFallbackErr := strconv.Atoi(s)
+//	                                      if _godslFallbackErr != nil { port = 8080 }
+//
+//	port = strconv.Atoi(s) ?: 8080    →   if _godslVal, _godslFallbackErr := strconv.Atoi(s); _godslFallbackErr != nil {
+//	                                          port = 8080
+//	                                      } else {
+//	                                          port = _godslVal
+//	                                      }
+func (t *Transpiler) transpileFallbackAssign(s *ast.AssignStmt, fb *ast.FallbackExpr) []ast.Stmt {
+	lhs := t.transpileExpr(s.Lhs[0])
+	errVar := &ast.Ident{NamePos: token.NoPos, Name: "_godslFallbackErr"}
+	errCheck := &ast.BinaryExpr{
+		X:     errVar,
+		OpPos: token.NoPos,
+		Op:    token.NEQ,
+		Y:     &ast.Ident{NamePos: token.NoPos, Name: "nil"},
+	}
+	fallback := &ast.BlockStmt{
+		Lbrace: token.NoPos,
+		List:   []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, t.transpileExpr(fb.Fallback))},
+		Rbrace: token.NoPos,
+	}
+	if s.Tok == token.ASSIGN {
+		val := &ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}
+		return []ast.Stmt{&ast.IfStmt{
+			If: s.Pos(),
+			Init: &ast.AssignStmt{
+				Lhs:    []ast.Expr{val, errVar},
+				TokPos: token.NoPos,
+				Tok:    token.DEFINE,
+				Rhs:    []ast.Expr{t.transpileExpr(fb.X)},
+			},
+			Cond: errCheck,
+			Body: fallback,
+			Else: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List:   []ast.Stmt{coalesceAssign(lhs, token.ASSIGN, val)},
+				Rbrace: token.NoPos,
+			},
+		}}
+	}
+	newAssign := &ast.AssignStmt{
+		Lhs:    []ast.Expr{lhs, errVar},
+		TokPos: s.TokPos,
+		Tok:    s.Tok,
+		Rhs:    []ast.Expr{t.transpileExpr(fb.X)},
+	}
+	return []ast.Stmt{newAssign, &ast.IfStmt{If: token.NoPos, Cond: errCheck, Body: fallback}}
+}
+
+// transpileFallbackExpr преобразует X ?: Fallback в позиции выражения в:
+//
+//	func() T {
+//		_godslVal, _godslFallbackErr := X
+//		if _godslFallbackErr != nil { return Fallback }
+//		return _godslVal
+//	}()
+//
+// Ошибка, как и в форме присваивания, хранится в _godslFallbackErr, чтобы
+// не перекрывать переменную err, на которую может ссылаться Fallback.
+// T — общий тип значения X и Fallback по данным go/types, как для
+// тернарного оператора; без них — тип литерала Fallback или any. В
+// пробном проходе выражение заменяется на
+// _godslTernary(true, _godslValue(X), Fallback).
+func (t *Transpiler) transpileFallbackExpr(x *ast.FallbackExpr) ast.Expr {
+	value := t.transpileExpr(x.X)
+	fallback := t.transpileExpr(x.Fallback)
+
+	if t.probing {
+		return t.probe(x, &ast.CallExpr{
+			Fun: &ast.Ident{NamePos: token.NoPos, Name: probeTernaryName},
+			Args: []ast.Expr{
+				&ast.Ident{NamePos: token.NoPos, Name: "true"},
+				&ast.CallExpr{Fun: &ast.Ident{NamePos: token.NoPos, Name: probeValueName}, Args: []ast.Expr{value}},
+				fallback,
+			},
+		})
+	}
+
+	retType := t.typeExpr(t.typeOf(x))
+	if retType == nil {
+		retType = inferLiteralType(x.Fallback)
+	}
+	if retType == nil {
+		t.requireResolved(x)
+		retType = &ast.Ident{NamePos: token.NoPos, Name: "any"}
+	}
+
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:   token.NoPos,
+				Params: &ast.FieldList{},
+				Results: &ast.FieldList{
+					List: []*ast.Field{{
+						Type: retType,
+					}},
+				},
+			},
+			Body: &ast.BlockStmt{
+				Lbrace: token.NoPos,
+				List: []ast.Stmt{
+					&ast.AssignStmt{
+						Lhs: []ast.Expr{
+							&ast.Ident{NamePos: token.NoPos, Name: "_godslVal"},
+							&ast.Ident{NamePos: token.NoPos, Name: "_godslFallbackErr"},
+						},
+						TokPos: token.NoPos,
+						Tok:    token.DEFINE,
+						Rhs:    []ast.Expr{value},
+					},
+					&ast.IfStmt{
+						If: token.NoPos,
+						Cond: &ast.BinaryExpr{
+							X:     &ast.Ident{NamePos: token.NoPos, Name: "_godslFallbackErr"},
+							OpPos: token.NoPos,
+							Op:    token.NEQ,
+							Y:     &ast.Ident{NamePos: token.NoPos, Name: "nil"},
+						},
+						Body: &ast.BlockStmt{
+							Lbrace: token.NoPos,
+							List: []ast.Stmt{
+								&ast.ReturnStmt{
+									Return:  token.NoPos,
+									Results: []ast.Expr{fallback},
+								},
+							},
+							Rbrace: token.NoPos,
+						},
+					},
+					&ast.ReturnStmt{
+						Return:  token.NoPos,
+						Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_godslVal"}},
+					},
+				},
+				Rbrace: token.NoPos,
+			},
+		},
+		Lparen: token.NoPos,
+		Rparen: token.NoPos,
+	}
+}
+
 // transpileThrowStmt транспилирует throw <expr> → return <expr>
-func (t *Transpiler) transpileThrowStmt(s *ast.ThrowStmt) ast.Stmt {
-	return &ast.ReturnStmt{
+func (t *Transpiler) transpileThrowStmt(s *ast.ThrowStmt) []ast.Stmt {
+	return t.propagateErr(s.X, s.Throw)
+}
+
+// propagateErr строит операторы передачи ошибки из ? или throw:
+// return err, а внутри retry или parallel — передача ошибки блоку.
+func (t *Transpiler) propagateErr(errExpr ast.Expr, pos token.Pos) []ast.Stmt {
+	errExpr = t.traceErr(errExpr, pos)
+	if t.errTarget != nil {
+		return t.errTarget.propagate(errExpr)
+	}
+	return []ast.Stmt{&ast.ReturnStmt{
 		Return:  token.NoPos,
-		Results: []ast.Expr{s.X},
+		Results: []ast.Expr{errExpr},
+	}}
+}
+
+// transpileExprs транспилирует список выражений.
+func (t *Transpiler) transpileExprs(exprs []ast.Expr) []ast.Expr {
+	result := make([]ast.Expr, len(exprs))
+	for i, e := range exprs {
+		result[i] = t.transpileExpr(e)
+	}
+	return result
+}
+
+// errorf запоминает ошибку транспиляции с позицией в исходном файле.
+func (t *Transpiler) errorf(pos token.Pos, format string, args ...any) {
+	t.errs = append(t.errs, fmt.Errorf("%s: %s", t.fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+// warnf выводит предупреждение в Options.Warnings, если он задан.
+func (t *Transpiler) warnf(pos token.Pos, format string, args ...any) {
+	if t.opts.Warnings == nil {
+		return
 	}
+	fmt.Fprintf(t.opts.Warnings, "%s: warning: %s\n", t.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+// errTarget — блок, перехватывающий ошибки из ? и throw в своём теле.
+type errTarget interface {
+	// propagate строит операторы, передающие ошибку errExpr блоку.
+	propagate(errExpr ast.Expr) []ast.Stmt
+}
+
+// singleStmt объединяет операторы в один: сам оператор или блок.
+func singleStmt(stmts []ast.Stmt) ast.Stmt {
+	if len(stmts) == 1 {
+		return stmts[0]
+	}
+	return &ast.BlockStmt{Lbrace: token.NoPos, List: stmts, Rbrace: token.NoPos}
 }
 
 // transpileQuestionStmt транспилирует stmt? → stmt + if err != nil { return err }
 // Для AssignStmt (a := f()?): добавляет err в левую часть
 // Для ExprStmt (f()?): генерирует if err := f(); err != nil { return err }
+// Значения result.Result[T] и result.Option[T] разворачиваются через их
+// методы (см. questionKind).
 func (t *Transpiler) transpileQuestionStmt(s *ast.QuestionStmt) []ast.Stmt {
 	switch inner := s.Stmt.(type) {
 	case *ast.AssignStmt:
+		rhs := t.transpileExprs(inner.Rhs)
+		var probe []ast.Stmt
+		if len(rhs) == 1 {
+			var kind unwrapKind
+			kind, probe = t.questionKind(s, rhs[0])
+			if kind != unwrapTuple {
+				return t.transpileUnwrapAssign(s, inner, rhs[0], kind)
+			}
+		}
 		// a := readFile()? → a, err := readFile(); if err != nil { return err }
 		newAssign := &ast.AssignStmt{
 			Lhs: append(inner.Lhs, &ast.Ident{NamePos: token.NoPos, Name: "err"}),
 			TokPos: inner.TokPos,
 			Tok:    inner.Tok,
-			Rhs:    inner.Rhs,
+			Rhs:    rhs,
 		}
-		errCheck := t.createErrorCheck(nil)
-		return []ast.Stmt{newAssign, errCheck}
+		errCheck := t.createPropagateCheck(s.Question)
+		return append(probe, newAssign, errCheck)
 
 	case *ast.ExprStmt:
 		// f()? → if err := f(); err != nil { return err }
+		x := t.transpileExpr(inner.X)
+		lhs := []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}}
+		var probe []ast.Stmt
+		if _, ok := ast.Unparen(inner.X).(*ast.AwaitExpr); ok {
+			// await f? → if _, err := f.Await(); err != nil { return err }
+			lhs = append([]ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}}, lhs...)
+		} else {
+			var kind unwrapKind
+			kind, probe = t.questionKind(s, x)
+			if kind != unwrapTuple {
+				return []ast.Stmt{t.transpileUnwrapExpr(s, x, kind)}
+			}
+		}
 		ifStmt := &ast.IfStmt{
 			If: token.NoPos,
 			Init: &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
+				Lhs:    lhs,
 				TokPos: token.NoPos,
 				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{inner.X},
+				Rhs:    []ast.Expr{x},
 			},
 			Cond: &ast.BinaryExpr{
 				X:     &ast.Ident{NamePos: token.NoPos, Name: "err"},
@@ -502,16 +1240,11 @@ func (t *Transpiler) transpileQuestionStmt(s *ast.QuestionStmt) []ast.Stmt {
 			},
 			Body: &ast.BlockStmt{
 				Lbrace: token.NoPos,
-				List: []ast.Stmt{
-					&ast.ReturnStmt{
-						Return:  token.NoPos,
-						Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
-					},
-				},
+				List:   t.propagateErr(&ast.Ident{NamePos: token.NoPos, Name: "err"}, s.Question),
 				Rbrace: token.NoPos,
 			},
 		}
-		return []ast.Stmt{ifStmt}
+		return append(probe, ifStmt)
 
 	default:
 		// Фолбэк: просто возвращаем оригинальный statement
@@ -531,7 +1264,7 @@ func (t *Transpiler) transpileMustStmt(s *ast.MustStmt) []ast.Stmt {
 			Lhs:    append(inner.Lhs, &ast.Ident{NamePos: token.NoPos, Name: "err"}),
 			TokPos: inner.TokPos,
 			Tok:    inner.Tok,
-			Rhs:    inner.Rhs,
+			Rhs:    t.transpileExprs(inner.Rhs),
 		}
 		panicCheck := &ast.IfStmt{
 			If: token.NoPos,
@@ -543,7 +1276,7 @@ func (t *Transpiler) transpileMustStmt(s *ast.MustStmt) []ast.Stmt {
 			},
 			Body: &ast.BlockStmt{
 				Lbrace: token.NoPos,
-				List:   []ast.Stmt{createPanicErr()},
+				List:   []ast.Stmt{t.createPanicErr(s.Must)},
 				Rbrace: token.NoPos,
 			},
 		}
@@ -557,7 +1290,7 @@ func (t *Transpiler) transpileMustStmt(s *ast.MustStmt) []ast.Stmt {
 				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
 				TokPos: token.NoPos,
 				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{inner.X},
+				Rhs:    []ast.Expr{t.transpileExpr(inner.X)},
 			},
 			Cond: &ast.BinaryExpr{
 				X:     &ast.Ident{NamePos: token.NoPos, Name: "err"},
@@ -567,7 +1300,7 @@ func (t *Transpiler) transpileMustStmt(s *ast.MustStmt) []ast.Stmt {
 			},
 			Body: &ast.BlockStmt{
 				Lbrace: token.NoPos,
-				List:   []ast.Stmt{createPanicErr()},
+				List:   []ast.Stmt{t.createPanicErr(s.Must)},
 				Rbrace: token.NoPos,
 			},
 		}
@@ -579,11 +1312,33 @@ func (t *Transpiler) transpileMustStmt(s *ast.MustStmt) []ast.Stmt {
 }
 
 // createPanicErr создаёт вызов panic(err).
-func createPanicErr() ast.Stmt {
+func (t *Transpiler) createPanicErr(pos token.Pos) ast.Stmt {
 	return &ast.ExprStmt{
 		X: &ast.CallExpr{
 			Fun:  &ast.Ident{NamePos: token.NoPos, Name: "panic"},
-			Args: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
+			Args: []ast.Expr{t.traceErr(&ast.Ident{NamePos: token.NoPos, Name: "err"}, pos)},
+		},
+	}
+}
+
+// createPropagateCheck создает проверку для оператора ?:
+//
+//	if err != nil { return err }
+//
+// Внутри retry и parallel вместо return ошибка передаётся блоку.
+func (t *Transpiler) createPropagateCheck(pos token.Pos) ast.Stmt {
+	return &ast.IfStmt{
+		If: token.NoPos,
+		Cond: &ast.BinaryExpr{
+			X:     &ast.Ident{NamePos: token.NoPos, Name: "err"},
+			OpPos: token.NoPos,
+			Op:    token.NEQ,
+			Y:     &ast.Ident{NamePos: token.NoPos, Name: "nil"},
+		},
+		Body: &ast.BlockStmt{
+			Lbrace: token.NoPos,
+			List:   t.propagateErr(&ast.Ident{NamePos: token.NoPos, Name: "err"}, pos),
+			Rbrace: token.NoPos,
 		},
 	}
 }
@@ -732,26 +1487,9 @@ func (t *Transpiler) createErrorCheckIIFE(catches []*ast.CatchStmt, catchesHaveR
 			Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "true"}},
 		})
 	} else {
-		for i, catchStmt := range catches {
-			if len(catchStmt.ErrorTypes) > 0 {
-				typeCheck := t.createTypeCheckIIFE(catchStmt, i == len(catches)-1, catchesHaveReturn)
-				catchBody = append(catchBody, typeCheck)
-			} else {
-				// catch-all: заменяем ReturnStmt → return true
-				body := t.replaceCatchReturns(catchStmt.Body.List, catchesHaveReturn)
-				if catchStmt.ErrorVar != nil {
-					assignment := &ast.AssignStmt{
-						Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: catchStmt.ErrorVar.Name}},
-						TokPos: token.NoPos,
-						Tok:    token.ASSIGN,
-						Rhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
-					}
-					catchBody = append(catchBody, assignment)
-				}
-				catchBody = append(catchBody, body...)
-				break
-			}
-		}
+		catchBody = t.catchChain(catches, func(body []ast.Stmt) []ast.Stmt {
+			return t.replaceCatchReturns(body, catchesHaveReturn)
+		})
 	}
 
 	return &ast.IfStmt{
@@ -770,66 +1508,6 @@ func (t *Transpiler) createErrorCheckIIFE(catches []*ast.CatchStmt, catchesHaveR
 	}
 }
 
-// createTypeCheckIIFE — как createTypeCheck, но в IIFE-контексте
-func (t *Transpiler) createTypeCheckIIFE(catchStmt *ast.CatchStmt, isLast bool, catchesHaveReturn bool) ast.Stmt {
-	var condition ast.Expr
-	var init ast.Stmt
-	var extraBodyPrefix []ast.Stmt
-
-	if len(catchStmt.ErrorTypes) > 1 {
-		// Multi-type: условие через IIFE func() bool { ... }()
-		condition = t.makeMultiTypeCheck(catchStmt.ErrorTypes)
-		if catchStmt.ErrorVar != nil {
-			// e := err  (тип — error, конкретный тип не известен)
-			extraBodyPrefix = []ast.Stmt{&ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: catchStmt.ErrorVar.Name}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
-			}}
-		}
-	} else {
-		// Single type
-		typ := catchStmt.ErrorTypes[0]
-		if catchStmt.ErrorVar != nil {
-			init = &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: catchStmt.ErrorVar.Name}, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: &ast.Ident{NamePos: token.NoPos, Name: "err"}, Type: typ}},
-			}
-		} else {
-			init = &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: &ast.Ident{NamePos: token.NoPos, Name: "err"}, Type: typ}},
-			}
-		}
-		condition = &ast.Ident{NamePos: token.NoPos, Name: "ok"}
-	}
-
-	body := append(extraBodyPrefix, t.replaceCatchReturns(catchStmt.Body.List, catchesHaveReturn)...)
-
-	ifStmt := &ast.IfStmt{
-		If:   token.NoPos,
-		Init: init,
-		Cond: condition,
-		Body: &ast.BlockStmt{Lbrace: token.NoPos, List: body, Rbrace: token.NoPos},
-	}
-
-	if !isLast {
-		ifStmt.Else = &ast.BlockStmt{
-			List: []ast.Stmt{&ast.ReturnStmt{
-				Return:  token.NoPos,
-				Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "true"}},
-			}},
-		}
-	}
-
-	return ifStmt
-}
-
 // replaceCatchReturns заменяет ReturnStmt в теле catch на return true (для IIFE)
 func (t *Transpiler) replaceCatchReturns(stmts []ast.Stmt, catchesHaveReturn bool) []ast.Stmt {
 	if !catchesHaveReturn {
@@ -837,9 +1515,9 @@ func (t *Transpiler) replaceCatchReturns(stmts []ast.Stmt, catchesHaveReturn boo
 	}
 	var result []ast.Stmt
 	for _, stmt := range stmts {
-		if _, ok := stmt.(*ast.ReturnStmt); ok {
+		if ret, ok := stmt.(*ast.ReturnStmt); ok {
 			result = append(result, &ast.ReturnStmt{
-				Return:  token.NoPos,
+				Return:  ret.Return,
 				Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "true"}},
 			})
 		} else {
@@ -931,34 +1609,7 @@ func (t *Transpiler) createErrorCheck(catches []*ast.CatchStmt) ast.Stmt {
 			},
 		})
 	} else {
-		// Обрабатываем catch блоки
-		for i, catchStmt := range catches {
-			if len(catchStmt.ErrorTypes) > 0 {
-				// Специфичный catch для определенного типа ошибки
-				typeCheck := t.createTypeCheck(catchStmt, i == len(catches)-1)
-				catchBody = append(catchBody, typeCheck)
-			} else {
-				// Catch-all - добавляем тело напрямую
-				if catchStmt.ErrorVar != nil {
-					// Присваиваем ошибку переменной из catch
-					assignment := &ast.AssignStmt{
-						Lhs: []ast.Expr{&ast.Ident{
-							NamePos: token.NoPos,
-							Name:    catchStmt.ErrorVar.Name,
-						}},
-						TokPos: token.NoPos,
-						Tok:    token.ASSIGN,
-						Rhs: []ast.Expr{&ast.Ident{
-							NamePos: token.NoPos,
-							Name:    "err",
-						}},
-					}
-					catchBody = append(catchBody, assignment)
-				}
-				catchBody = append(catchBody, catchStmt.Body.List...)
-				break // catch-all должен быть последним
-			}
-		}
+		catchBody = t.catchChain(catches, func(body []ast.Stmt) []ast.Stmt { return body })
 	}
 
 	return &ast.IfStmt{
@@ -983,113 +1634,14 @@ func (t *Transpiler) createErrorCheck(catches []*ast.CatchStmt) ast.Stmt {
 	}
 }
 
-// makeMultiTypeCheck строит IIFE-условие для нескольких типов:
-//
-//	func() bool {
-//	    if _, ok := err.(T1); ok { return true }
-//	    if _, ok := err.(T2); ok { return true }
-//	    return false
-//	}()
-func (t *Transpiler) makeMultiTypeCheck(types []ast.Expr) *ast.CallExpr {
-	var body []ast.Stmt
-	for _, typ := range types {
-		check := &ast.IfStmt{
-			If: token.NoPos,
-			Init: &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: &ast.Ident{NamePos: token.NoPos, Name: "err"}, Type: typ}},
-			},
-			Cond: &ast.Ident{NamePos: token.NoPos, Name: "ok"},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{&ast.ReturnStmt{
-					Return:  token.NoPos,
-					Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "true"}},
-				}},
-			},
-		}
-		body = append(body, check)
-	}
-	body = append(body, &ast.ReturnStmt{
-		Return:  token.NoPos,
-		Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "false"}},
-	})
-
-	return &ast.CallExpr{
-		Fun: &ast.FuncLit{
-			Type: &ast.FuncType{
-				Params: &ast.FieldList{},
-				Results: &ast.FieldList{
-					List: []*ast.Field{{Type: &ast.Ident{NamePos: token.NoPos, Name: "bool"}}},
-				},
-			},
-			Body: &ast.BlockStmt{List: body},
-		},
-	}
-}
-
-// createTypeCheck создает проверку типа ошибки для конкретного catch
-func (t *Transpiler) createTypeCheck(catchStmt *ast.CatchStmt, isLast bool) ast.Stmt {
-	var condition ast.Expr
-	var init ast.Stmt
-	var bodyPrefix []ast.Stmt
-
-	if len(catchStmt.ErrorTypes) > 1 {
-		// Multi-type: условие через IIFE
-		condition = t.makeMultiTypeCheck(catchStmt.ErrorTypes)
-		if catchStmt.ErrorVar != nil {
-			bodyPrefix = []ast.Stmt{&ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: catchStmt.ErrorVar.Name}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}},
-			}}
-		}
-	} else {
-		typ := catchStmt.ErrorTypes[0]
-		if catchStmt.ErrorVar != nil {
-			// catchVar, ok := err.(ErrorType)
-			init = &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: catchStmt.ErrorVar.Name}, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: &ast.Ident{NamePos: token.NoPos, Name: "err"}, Type: typ}},
-			}
-		} else {
-			// _, ok := err.(ErrorType)
-			init = &ast.AssignStmt{
-				Lhs:    []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "_"}, &ast.Ident{NamePos: token.NoPos, Name: "ok"}},
-				TokPos: token.NoPos,
-				Tok:    token.DEFINE,
-				Rhs:    []ast.Expr{&ast.TypeAssertExpr{X: &ast.Ident{NamePos: token.NoPos, Name: "err"}, Type: typ}},
-			}
-		}
-		condition = &ast.Ident{NamePos: token.NoPos, Name: "ok"}
-	}
-
-	body := append(bodyPrefix, catchStmt.Body.List...)
-
-	ifStmt := &ast.IfStmt{
-		If:   token.NoPos,
-		Init: init,
-		Cond: condition,
-		Body: &ast.BlockStmt{Lbrace: token.NoPos, List: body, Rbrace: token.NoPos},
-	}
-
-	if !isLast {
-		ifStmt.Else = &ast.BlockStmt{
-			Lbrace: token.NoPos,
-			List:   []ast.Stmt{&ast.ReturnStmt{Return: token.NoPos, Results: []ast.Expr{&ast.Ident{NamePos: token.NoPos, Name: "err"}}}},
-			Rbrace: token.NoPos,
-		}
-	}
-
-	return ifStmt
-}
-
 // TranspileFile главная функция для транспиляции
 func TranspileFile(source string) (string, error) {
 	transpiler := NewTranspiler()
 	return transpiler.Transpile(source)
 }
+
+// TranspileFileWithOptions транспилирует исходный код с заданными режимами
+func TranspileFileWithOptions(source string, opts Options) (string, error) {
+	transpiler := NewTranspilerWithOptions(opts)
+	return transpiler.Transpile(source)
+}
@@ -1,6 +1,8 @@
 package transpiler_test
 
 import (
+	"bytes"
+	"go/format"
 	goparser "go/parser"
 	gotoken "go/token"
 	"strings"
@@ -259,7 +261,8 @@ func bar() (int, error) { return 0, nil }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "err.(MyError)")
+	assertContains(t, out, "switch err.(type)")
+	assertContains(t, out, "case MyError:")
 	assertContains(t, out, `errors.New("caught MyError")`)
 	assertNotContains(t, out, "} catch")
 }
@@ -288,8 +291,9 @@ func bar() (int, error) { return 0, nil }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	// Type assertion with variable binding: e, ok := err.(MyError)
-	assertContains(t, out, "err.(MyError)")
+	// Type switch with variable binding: e has type MyError in the case
+	assertContains(t, out, "switch e := err.(type)")
+	assertContains(t, out, "case MyError:")
 	assertContains(t, out, "e.Msg")
 }
 
@@ -338,9 +342,9 @@ func bar() (int, error) { return 0, nil }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	// Multi-type → IIFE with individual type assertions
-	assertContains(t, out, "ErrA")
-	assertContains(t, out, "ErrB")
+	// Multi-type → one case with several types, no closure
+	assertContains(t, out, "case ErrA, ErrB:")
+	assertNotContains(t, out, "func() bool")
 	assertNotContains(t, out, "} catch")
 }
 
@@ -370,8 +374,8 @@ func bar() (int, error) { return 0, nil }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "ErrA")
-	assertContains(t, out, "ErrB")
+	assertContains(t, out, "switch e := err.(type)")
+	assertContains(t, out, "case ErrA, ErrB:")
 }
 
 func TestTranspileFile_TryCatch_TypedThenCatchAll(t *testing.T) {
@@ -399,8 +403,9 @@ func bar() (int, error) { return 0, nil }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "err.(MyError)")
+	assertContains(t, out, "case MyError:")
 	assertContains(t, out, `errors.New("typed")`)
+	assertContains(t, out, "default:")
 }
 
 // ─── finally ──────────────────────────────────────────────────────────────────
@@ -836,7 +841,7 @@ func TestTranspileFile_EmptyString_ReturnsError(t *testing.T) {
 
 // ─── output does not contain godsl keywords ───────────────────────────────────
 
-// ─── typed catch + finally (covers createTypeCheckIIFE) ──────────────────────
+// ─── typed catch + finally ───────────────────────────────────────────────────
 
 func TestTranspileFile_Finally_WithTypedCatch_NoReturn(t *testing.T) {
 	src := `package main
@@ -954,8 +959,8 @@ func bar() (int, error) { return 0, nil }
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
 	assertContains(t, out, "_godslRet")
-	assertContains(t, out, "ErrA")
-	assertContains(t, out, "ErrB")
+	assertContains(t, out, "case ErrA, ErrB:")
+	assertNotContains(t, out, "err.(ErrA)")
 }
 
 func TestTranspileFile_Finally_CatchAll_WithVar(t *testing.T) {
@@ -1169,8 +1174,8 @@ func abs(x int) any {
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
 	assertContains(t, out, "if x >= 0")
-	assertContains(t, out, "return x")
-	assertContains(t, out, "return -x")
+	assertContains(t, out, "_godslTern = x")
+	assertContains(t, out, "_godslTern = -x")
 	assertNotContains(t, out, "?")
 }
 
@@ -1254,50 +1259,54 @@ func TestTranspileFile_Ternary_TypeInference_StringLiterals(t *testing.T) {
 	src := `package main
 
 func foo(flag bool) any {
-	return flag ? "yes" : "no"
+	v := flag ? "yes" : "no"
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() string")
-	assertNotContains(t, out, "func() any")
+	assertContains(t, out, "var v string")
+	assertNotContains(t, out, "var v any")
 }
 
 func TestTranspileFile_Ternary_TypeInference_IntLiterals(t *testing.T) {
 	src := `package main
 
 func foo(x int) any {
-	return x > 0 ? 1 : -1
+	v := x > 0 ? 1 : -1
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() int")
-	assertNotContains(t, out, "func() any")
+	assertContains(t, out, "var v int")
+	assertNotContains(t, out, "var v any")
 }
 
 func TestTranspileFile_Ternary_TypeInference_FloatLiterals(t *testing.T) {
 	src := `package main
 
 func foo(flag bool) any {
-	return flag ? 1.5 : 2.5
+	v := flag ? 1.5 : 2.5
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() float64")
+	assertContains(t, out, "var v float64")
 }
 
 func TestTranspileFile_Ternary_TypeInference_BoolIdents(t *testing.T) {
 	src := `package main
 
 func foo(x int) any {
-	return x > 0 ? true : false
+	v := x > 0 ? true : false
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() bool")
+	assertContains(t, out, "var v bool")
 }
 
 func TestTranspileFile_Ternary_TypeInference_OneLiteralBranch(t *testing.T) {
@@ -1305,12 +1314,13 @@ func TestTranspileFile_Ternary_TypeInference_OneLiteralBranch(t *testing.T) {
 	src := `package main
 
 func foo(x int, msg string) any {
-	return x > 0 ? "default" : msg
+	v := x > 0 ? "default" : msg
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() string")
+	assertContains(t, out, "var v string")
 }
 
 func TestTranspileFile_Ternary_TypeInference_ReturnContext_Int(t *testing.T) {
@@ -1318,20 +1328,22 @@ func TestTranspileFile_Ternary_TypeInference_ReturnContext_Int(t *testing.T) {
 	src := `package main
 
 func max(a, b int) int {
-	return a > b ? a : b
+	v, ok := a > b ? a : b, true
+	_ = ok
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() int")
-	assertNotContains(t, out, "func() any")
+	assertContains(t, out, "var _godslTern int")
+	assertNotContains(t, out, "var _godslTern any")
 }
 
 func TestTranspileFile_Ternary_TypeInference_ReturnContext_String(t *testing.T) {
 	src := `package main
 
-func greet(formal bool) string {
-	return formal ? greeting() : shortGreet()
+func greet(formal bool) (string, error) {
+	return formal ? greeting() : shortGreet(), nil
 }
 
 func greeting() string     { return "Good day" }
@@ -1339,7 +1351,8 @@ func shortGreet() string   { return "Hi" }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() string")
+	assertContains(t, out, "var _godslTern string")
+	assertContains(t, out, "return _godslTern, nil")
 }
 
 func TestTranspileFile_Ternary_TypeInference_FallbackToAny(t *testing.T) {
@@ -1347,24 +1360,3777 @@ func TestTranspileFile_Ternary_TypeInference_FallbackToAny(t *testing.T) {
 	src := `package main
 
 func pick(flag bool, a, b any) any {
-	return flag ? a : b
+	v := flag ? a : b
+	return v
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	assertContains(t, out, "func() any")
+	assertContains(t, out, "var v any")
 }
 
 func TestTranspileFile_Ternary_TypeInference_NegativeIntLiteral(t *testing.T) {
 	src := `package main
 
-func abs(x int) int {
-	return x >= 0 ? x : -x
+func abs(x int) (int, bool) {
+	return x >= 0 ? x : -x, true
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	// -x — унарный минус не литерал, но тип веток известен: int
+	assertContains(t, out, "var _godslTern int")
+	assertNotContains(t, out, "var _godslTern any")
+}
+
+// ─── error-or-default ?: / try ... else ───────────────────────────────────────
+
+func TestTranspileFile_Fallback_Assignment(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string) int {
+	port := strconv.Atoi(s) ?: 8080
+	return port
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "port, _godslFallbackErr := strconv.Atoi(s)")
+	assertContains(t, out, "if _godslFallbackErr != nil")
+	assertContains(t, out, "port = 8080")
+	assertNotContains(t, out, "?:")
+	assertNotContains(t, out, "func() int")
+}
+
+func TestTranspileFile_Fallback_TryElseForm(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func defaultX() int { return 1 }
+
+func foo(s string) int {
+	x := try strconv.Atoi(s) else defaultX()
+	return x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "x, _godslFallbackErr := strconv.Atoi(s)")
+	assertContains(t, out, "x = defaultX()")
+	assertNotContains(t, out, "try")
+}
+
+func TestTranspileFile_Fallback_PlainAssign(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string) int {
+	var port int
+	port = strconv.Atoi(s) ?: 8080
+	return port
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if _godslVal, _godslFallbackErr := strconv.Atoi(s); _godslFallbackErr != nil {")
+	assertContains(t, out, "port = 8080")
+	assertContains(t, out, "port = _godslVal")
+	assertNotContains(t, out, "err =")
+}
+
+func TestTranspileFile_Fallback_KeepsUserErr(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string, err error) (int, error) {
+	port := strconv.Atoi(s) ?: 8080
+	return port, err
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "port, _godslFallbackErr := strconv.Atoi(s)")
+	assertContains(t, out, "return port, err")
+}
+
+func TestTranspileFile_Fallback_ReturnExpr(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string) int {
+	return strconv.Atoi(s) ?: 8080
 }
 `
 	out := transpileOK(t, src)
 	assertValidGo(t, out)
-	// -x — унарный минус не литерал, но return context даёт int
 	assertContains(t, out, "func() int")
-	assertNotContains(t, out, "func() any")
+	assertContains(t, out, "_godslVal, _godslFallbackErr := strconv.Atoi(s)")
+	assertContains(t, out, "return 8080")
+	assertContains(t, out, "return _godslVal")
+}
+
+func TestTranspileFile_Fallback_ExprKeepsOuterErr(t *testing.T) {
+	src := `package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+func foo() {
+	err := errors.New("outer")
+	fmt.Println(strconv.Atoi("x") ?: len(err.Error()))
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslVal, _godslFallbackErr := strconv.Atoi(\"x\")")
+	assertContains(t, out, "return len(err.Error())")
+	assertNotContains(t, out, "_godslVal, err :=")
+}
+
+func TestTranspileFile_Fallback_AsCallArgument(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+func foo(s string) {
+	fmt.Println(strconv.Atoi(s) ?: -1)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "fmt.Println(func() int {")
+	assertNotContains(t, out, "?:")
+}
+
+func TestTranspileFile_Fallback_TypeFromValue(t *testing.T) {
+	src := `package main
+
+func get() (int64, error) { return 1, nil }
+
+func use(n int64) {}
+
+func run() string {
+	use(get() ?: 5)
+	return ""
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "use(func() int64 {")
+	assertNotContains(t, out, "func() int {")
+	assertNotContains(t, out, "func() string {")
+}
+
+func TestTranspileFile_Fallback_TernaryInFallback(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func foo(s string, strict bool) int {
+	n := strconv.Atoi(s) ?: strict ? 0 : 1
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "n, _godslFallbackErr := strconv.Atoi(s)")
+	assertContains(t, out, "if strict")
+	assertNotContains(t, out, "?")
+}
+
+// ─── local var declarations ───────────────────────────────────────────────────
+
+func TestTranspileFile_LocalVar_Fallback(t *testing.T) {
+	src := `package main
+
+func get() (int64, error) { return 1, nil }
+
+func run() int64 {
+	var q int64 = get() ?: 5
+	return q
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var q int64\n")
+	assertContains(t, out, "if _godslVal, _godslFallbackErr := get(); _godslFallbackErr != nil {")
+	assertNotContains(t, out, "?:")
+}
+
+func TestTranspileFile_LocalVar_Interpolation(t *testing.T) {
+	src := `package main
+
+func run(n int) string {
+	var s = $"n={n}"
+	return s
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `var s = fmt.Sprintf("n=%v", n)`)
+}
+
+func TestTranspileFile_LocalVar_Lambda(t *testing.T) {
+	src := `package main
+
+func run() int {
+	var f func(int) int = (x int) => x + 1
+	return f(1)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var f func(int) int = func(x int) int { return x + 1 }")
+	assertNotContains(t, out, "=>")
+}
+
+func TestTranspileFile_LocalVar_Comprehension(t *testing.T) {
+	src := `package main
+
+func run(ys []int) []int {
+	var xs = [x * 2 for x in ys]
+	return xs
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "xs := make([]int, 0, len(ys))")
+	assertContains(t, out, "xs = append(xs, x*2)")
+	assertNotContains(t, out, " for x in ")
+}
+
+func TestTranspileFile_LocalVar_Coalesce(t *testing.T) {
+	src := `package main
+
+func run(name string) string {
+	var n = name ?? "anon"
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "n := name\n")
+	assertContains(t, out, `if n == "" {`)
+}
+
+func TestTranspileFile_LocalVar_TernaryTakesDeclaredType(t *testing.T) {
+	src := `package main
+
+func run(n int) (float64, any) {
+	var c float64 = n > 2 ? 1 : 2
+	var a any = n > 2 ? 1 : 2.5
+	return c, a
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var c float64\n\tif n > 2 {\n\t\tc = 1\n")
+	assertContains(t, out, "var _godslTern float64")
+	assertContains(t, out, "var a any = _godslTern")
+	assertNotContains(t, out, "func() ")
+}
+
+func TestTranspileFile_LocalVar_Match(t *testing.T) {
+	src := `package main
+
+func run(n int) string {
+	var m = match n {
+	case 3: "three"
+	default: "other"
+	}
+	return m
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var m string\n\tswitch n {")
+	assertContains(t, out, `m = "three"`)
+}
+
+func TestTranspileFile_LocalVar_IfExpression(t *testing.T) {
+	src := `package main
+
+func run(n int) string {
+	var y string = if n > 2 { "big" } else { "small" }
+	return y
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var y string\n")
+	assertContains(t, out, `y = "big"`)
+	assertNotContains(t, out, "func() ")
+}
+
+func TestTranspileFile_LocalVar_Pipe(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func run(s string) string {
+	var up = s |> strings.TrimSpace |> strings.ToUpper
+	return up
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "up := strings.ToUpper(_godslPipe)")
+	assertNotContains(t, out, "|>")
+}
+
+func TestTranspileFile_LocalVar_OptChain(t *testing.T) {
+	src := `package main
+
+type User struct{ Name string }
+
+func run(u *User) string {
+	var name string = u?.Name
+	return name
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var name string\n")
+	assertContains(t, out, "if u != nil {\n\t\tname = u.Name\n")
+	assertNotContains(t, out, "?.")
+}
+
+func TestTranspileFile_LocalVar_SelfReferenceNotSplit(t *testing.T) {
+	src := `package main
+
+func run(n int) int {
+	{
+		var n int = n > 0 ? n : 0
+		return n
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var _godslTern int")
+	assertContains(t, out, "var n int = _godslTern")
+}
+
+// ─── --trace-errors ───────────────────────────────────────────────────────────
+
+const traceSrc = `package main
+
+import "errors"
+
+type NotFound struct{}
+
+func (NotFound) Error() string { return "not found" }
+
+type Repo struct{}
+
+func (r *Repo) load() error {
+	throw NotFound{}
+}
+
+func parse() (int, error) { return 0, nil }
+
+func run(r *Repo) error {
+	n := parse()?
+	_ = n
+	r.load()?
+	try {
+		@errcheck
+		err := r.load()
+	} catch(NotFound) {
+		return nil
+	}
+	return errors.New("x")
+}
+
+func main() {
+	must run(&Repo{})
+}
+`
+
+func TestTranspileFile_TraceErrors_OffKeepsOutput(t *testing.T) {
+	plain := transpileOK(t, traceSrc)
+	withOpts, err := transpiler.TranspileFileWithOptions(traceSrc, transpiler.Options{Filename: "main.godsl"})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	if plain != withOpts {
+		t.Errorf("output without --trace-errors must be identical\n\nTranspileFile:\n%s\n\nWithOptions:\n%s", plain, withOpts)
+	}
+	assertNotContains(t, plain, "errtrace")
+}
+
+func TestTranspileFile_TraceErrors_WrapsPropagation(t *testing.T) {
+	out, err := transpiler.TranspileFileWithOptions(traceSrc, transpiler.Options{
+		Filename:    "main.godsl",
+		TraceErrors: true,
+	})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, `"github.com/sviridovkonstantin42/godsl/runtime/errtrace"`)
+	// throw
+	assertContains(t, out, `return errtrace.Wrap(NotFound{}, "main.godsl:12", "(*Repo).load")`)
+	// ? в форме присваивания и выражения
+	assertContains(t, out, `return errtrace.Wrap(err, "main.godsl:18", "run")`)
+	assertContains(t, out, `return errtrace.Wrap(err, "main.godsl:20", "run")`)
+	// must
+	assertContains(t, out, `panic(errtrace.Wrap(err, "main.godsl:31", "main"))`)
+	// catch видит исходный тип ошибки
+	assertContains(t, out, "switch errtrace.Cause(err).(type)")
+}
+
+// ─── retry ────────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Retry_QuestionContinuesLoop(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func fetch(url string) (string, error) { return url, nil }
+
+func get(url string) error {
+	retry 3 backoff 100ms {
+		resp := fetch(url)?
+		fmt.Println(resp)
+	} catch {
+		fmt.Println("gave up:", err)
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `"time"`)
+	assertContains(t, out, "var _godslErr error")
+	assertContains(t, out, "_godslRetry:")
+	assertContains(t, out, "for _godslAttempt := 0; _godslAttempt < 3; _godslAttempt++")
+	assertContains(t, out, "time.Sleep((100 * time.Millisecond) << (_godslAttempt - 1))")
+	assertContains(t, out, "continue _godslRetry")
+	assertContains(t, out, "if err := _godslErr; err != nil")
+	assertContains(t, out, `fmt.Println("gave up:", err)`)
+	assertNotContains(t, out, "retry 3")
+}
+
+func TestTranspileFile_Retry_ThrowAndNoCatch(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func check(n int) error {
+	retry n {
+		if n < 0 {
+			throw errors.New("negative")
+		}
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `_godslErr = errors.New("negative")`)
+	assertContains(t, out, "_godslAttempt < n")
+	// без backoff ожидание и импорт time не нужны
+	assertNotContains(t, out, "time.")
+	// без catch ошибка последней попытки возвращается
+	assertContains(t, out, "return err")
+}
+
+func TestTranspileFile_Retry_RespectsContext(t *testing.T) {
+	src := `package main
+
+import "context"
+
+func fetch(ctx context.Context) (int, error) { return 0, nil }
+
+func get(ctx context.Context) error {
+	retry 5 backoff 1.5s {
+		n := fetch(ctx)?
+		_ = n
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "case <-ctx.Done():")
+	assertContains(t, out, "_godslErr = ctx.Err()")
+	assertContains(t, out, "break _godslRetry")
+	assertContains(t, out, "case <-time.After((1500 * time.Millisecond) << (_godslAttempt - 1)):")
+}
+
+func TestTranspileFile_Retry_UniqueNamesPerBlock(t *testing.T) {
+	src := `package main
+
+func step() (int, error) { return 0, nil }
+
+func run() error {
+	retry 2 {
+		a := step()?
+		_ = a
+	}
+	retry 2 {
+		b := step()?
+		_ = b
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var _godslErr error")
+	assertContains(t, out, "var _godslErr2 error")
+	assertContains(t, out, "continue _godslRetry2")
+}
+
+func TestTranspileFile_Retry_IdentifierStillUsable(t *testing.T) {
+	src := `package main
+
+func retry(n int) int { return n }
+
+func main() {
+	x := retry(3)
+	retry := x
+	_ = retry
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "x := retry(3)")
+	assertNotContains(t, out, "_godslRetry")
+}
+
+func TestTranspileFile_Retry_UnlabeledBranchRejected(t *testing.T) {
+	cases := map[string]string{
+		"break":              "for _, x := range xs {\n\t\tretry 3 {\n\t\t\tif x > 0 {\n\t\t\t\tbreak\n\t\t\t}\n\t\t}\n\t}",
+		"continue":           "for _, x := range xs {\n\t\tretry 3 {\n\t\t\tif x > 0 {\n\t\t\t\tcontinue\n\t\t\t}\n\t\t}\n\t}",
+		"continue in switch": "for _, x := range xs {\n\t\tretry 3 {\n\t\t\tswitch x {\n\t\t\tcase 1:\n\t\t\t\tcontinue\n\t\t\t}\n\t\t}\n\t}",
+	}
+	for name, body := range cases {
+		src := "package main\n\nfunc run(xs []int) {\n\t" + body + "\n}\n"
+		if _, err := transpiler.TranspileFile(src); err == nil {
+			t.Errorf("%s: expected TranspileFile to return an error", name)
+		}
+	}
+}
+
+func TestTranspileFile_Retry_NestedAndLabeledBranches(t *testing.T) {
+	src := `package main
+
+func run(xs []int) {
+outer:
+	for _, x := range xs {
+		retry 3 {
+			for i := 0; i < x; i++ {
+				if i > 1 {
+					break
+				}
+			}
+			switch x {
+			case 1:
+				break
+			}
+			if x > 5 {
+				continue outer
+			}
+		}
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "continue outer")
+}
+
+// ─── parallel ─────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Parallel_FirstError(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func a() error { return nil }
+
+func b() (int, error) { return 0, nil }
+
+func run() {
+	parallel {
+		go a()?
+		go {
+			n := b()?
+			fmt.Println(n)
+		}
+	} catch {
+		fmt.Println("failed:", err)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `"context"`)
+	assertContains(t, out, `"sync"`)
+	assertContains(t, out, "ctx, _godslCancel := context.WithCancel(context.Background())")
+	assertContains(t, out, "var _godslWg sync.WaitGroup")
+	assertContains(t, out, "_godslErrs := make(chan error, 2)")
+	assertContains(t, out, "_godslWg.Add(2)")
+	assertContains(t, out, "go func(ctx context.Context) {")
+	assertContains(t, out, "defer _godslWg.Done()")
+	assertContains(t, out, "_godslErrs <- err")
+	assertContains(t, out, "_godslWg.Wait()")
+	assertContains(t, out, "if err := <-_godslErrs; err != nil")
+	assertContains(t, out, `fmt.Println("failed:", err)`)
+	assertNotContains(t, out, "parallel")
+	assertNotContains(t, out, "errors.Join")
+}
+
+func TestTranspileFile_Parallel_AllErrorsJoined(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func a() error { return nil }
+
+func run() error {
+	parallel all {
+		go a()?
+		go {
+			throw errors.New("boom")
+		}
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `_godslErrs <- errors.New("boom")`)
+	assertContains(t, out, "for err := range _godslErrs")
+	assertContains(t, out, "if err := errors.Join(_godslAll...); err != nil")
+	// без catch ошибка возвращается из функции
+	assertContains(t, out, "return err")
+}
+
+func TestTranspileFile_Parallel_DerivesFromContextParam(t *testing.T) {
+	src := `package main
+
+import "context"
+
+func fetch(ctx context.Context) error { return nil }
+
+func run(ctx context.Context) error {
+	parallel {
+		go fetch(ctx)?
+		go fetch(ctx)?
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "ctx, _godslCancel := context.WithCancel(ctx)")
+	assertNotContains(t, out, "context.Background()")
+}
+
+func TestTranspileFile_Parallel_InsideRetry(t *testing.T) {
+	src := `package main
+
+func a() error { return nil }
+
+func run() error {
+	retry 3 {
+		parallel {
+			go a()?
+		}
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	// ошибка parallel без catch запускает следующую попытку retry
+	assertContains(t, out, "_godslErr = err")
+	assertContains(t, out, "continue _godslRetry")
+}
+
+func TestTranspileFile_Parallel_NonGoBranch_ReturnsError(t *testing.T) {
+	src := `package main
+
+func run() {
+	parallel {
+		x := 1
+	}
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for non-go statement in parallel block")
+	}
+}
+
+func TestTranspileFile_Parallel_UnknownMode_ReturnsError(t *testing.T) {
+	src := `package main
+
+func run() {
+	parallel al {
+		go f()
+	}
+}
+
+func f() error { return nil }
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "unknown parallel mode al") {
+		t.Errorf("expected unknown parallel mode error, got %v", err)
+	}
+}
+
+// ─── async / await ────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Async_CallAndAwait(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func fetch(url string) (string, error) { return url, nil }
+
+func run() error {
+	f := async fetch("a")
+	g := async strconv.Atoi("7")
+	v := await f?
+	n := await g?
+	_, _ = v, n
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, `"github.com/sviridovkonstantin42/godsl/runtime/async"`)
+	assertContains(t, out, `f := async.Call1(fetch, "a")`)
+	assertContains(t, out, `g := async.Call1(strconv.Atoi, "7")`)
+	assertContains(t, out, "v, err := f.Await()")
+	assertContains(t, out, "n, err := g.Await()")
+	assertContains(t, out, "return err")
+}
+
+func TestTranspileFile_Async_ErrorOnlyFunc(t *testing.T) {
+	src := `package main
+
+func save(a, b string) error { return nil }
+
+func run() error {
+	f := async save("k", "v")
+	await f?
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, `f := async.Do2(save, "k", "v")`)
+	assertContains(t, out, "if _, err := f.Await(); err != nil")
+}
+
+func TestTranspileFile_Async_FuncLit(t *testing.T) {
+	src := `package main
+
+func run() error {
+	f := async func() (int, error) {
+		return 1, nil
+	}
+	n := await f?
+	_ = n
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "f := async.Go(func() (int, error) {")
+}
+
+func TestTranspileFile_Await_SliceOfFutures(t *testing.T) {
+	src := `package main
+
+func fetch(url string) (string, error) { return url, nil }
+
+func run(urls []string) error {
+	var fs []*async.Future[string]
+	for _, u := range urls {
+		fs = append(fs, async fetch(u))
+	}
+	bodies := await fs?
+	_ = bodies
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "fs = append(fs, async.Call1(fetch, u))")
+	assertContains(t, out, "bodies, err := async.All(fs)")
+}
+
+func TestTranspileFile_Async_IdentifierStillUsable(t *testing.T) {
+	src := `package main
+
+func main() {
+	async := 1
+	await := async + 1
+	_ = await
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "await := async + 1")
+	assertNotContains(t, out, "runtime/async")
+}
+
+func TestTranspileFile_Async_ManyArgs_BindsArgumentsInClosure(t *testing.T) {
+	src := `package main
+
+func f(a, b, c string, d int) (int, error) { return d, nil }
+
+func run() {
+	x := async f("a", "b", "c", 4)
+	_ = x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "x := func(_godslArg0 string, _godslArg1 string, _godslArg2 string, _godslArg3 int) *async.Future[int] {")
+	assertContains(t, out, "return async.Go(func() (int, error) {")
+	assertContains(t, out, "return f(_godslArg0, _godslArg1, _godslArg2, _godslArg3)")
+	assertContains(t, out, `}("a", "b", "c", 4)`)
+}
+
+func TestTranspileFile_Async_ManyArgs_MethodAndErrorOnly(t *testing.T) {
+	src := `package main
+
+type Conn struct{}
+
+func (c *Conn) Send(a, b, x, d int) error { return nil }
+
+func run(c *Conn) error {
+	f := async c.Send(1, 2, 3, 4)
+	await f?
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "f := func(_godslArg0 func(a int, b int, x int, d int) error, _godslArg1 int, _godslArg2 int, _godslArg3 int, _godslArg4 int) *async.Future[struct{}] {")
+	assertContains(t, out, "return async.Do0(func() error {")
+	assertContains(t, out, "}(c.Send, 1, 2, 3, 4)")
+}
+
+func TestTranspileFile_Async_ErrorOnlyFromSignature(t *testing.T) {
+	src := `package main
+
+import "os"
+
+type Conn struct{}
+
+func (c *Conn) Close() error { return nil }
+
+func run(p string, c *Conn) error {
+	r := async os.Remove(p)
+	cl := async c.Close()
+	await r?
+	await cl?
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "r := async.Do1(os.Remove, p)")
+	assertContains(t, out, "cl := async.Do0(c.Close)")
+}
+
+func TestTranspileFile_Await_SliceFromCall(t *testing.T) {
+	src := `package main
+
+func fetch(url string) (string, error) { return url, nil }
+
+func start(urls []string) []*async.Future[string] {
+	var fs []*async.Future[string]
+	for _, u := range urls {
+		fs = append(fs, async fetch(u))
+	}
+	return fs
+}
+
+func run(urls []string) error {
+	bodies := await start(urls)?
+	_ = bodies
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "bodies, err := async.All(start(urls))")
+}
+
+func TestTranspileFile_Await_WithMustAndFallback(t *testing.T) {
+	src := `package main
+
+func fetch(url string) (string, error) { return url, nil }
+
+func run() {
+	f := async fetch("a")
+	a := must await f
+	b := await f ?: "default"
+	_, _ = a, b
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "a, err := f.Await()")
+	assertContains(t, out, "b, _godslFallbackErr := f.Await()")
+	assertContains(t, out, `b = "default"`)
+}
+
+// ─── null-coalescing ?? ───────────────────────────────────────────────────────
+
+func TestTranspileFile_Coalesce_AssignString(t *testing.T) {
+	src := `package main
+
+type User struct{ Nickname, Login string }
+
+func name(u User) string {
+	n := u.Nickname ?? "anonymous"
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "n := u.Nickname")
+	assertContains(t, out, `if n == "" {`)
+	assertContains(t, out, `n = "anonymous"`)
+	assertNotContains(t, out, "func() string")
+}
+
+func TestTranspileFile_Coalesce_AssignKindFromType(t *testing.T) {
+	src := `package main
+
+type User struct{ Nickname, Login string }
+
+func name(u User) string {
+	n := u.Nickname ?? u.Login
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `if n == "" {`)
+	assertContains(t, out, "n = u.Login")
+	assertNotContains(t, out, "runtime/zero")
+}
+
+func TestTranspileFile_Coalesce_TypeWinsOverLiteral(t *testing.T) {
+	src := `package main
+
+func pick(v any) any {
+	x := v ?? 5
+	return x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if x == nil {")
+	assertNotContains(t, out, "x == 0")
+}
+
+func TestTranspileFile_Coalesce_StructUsesZeroIs(t *testing.T) {
+	src := `package main
+
+type Point struct{ X, Y int }
+
+func pick(a, b Point) Point {
+	p := a ?? b
+	return p
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `"github.com/sviridovkonstantin42/godsl/runtime/zero"`)
+	assertContains(t, out, "if zero.Is(p) {")
+}
+
+func TestTranspileFile_Coalesce_ExprTypeFromOperands(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func getDefault() string { return "x" }
+
+func count(name string, err error) int {
+	fmt.Println(name ?? getDefault())
+	fmt.Println(err ?? fmt.Errorf("no error"))
+	return 0
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "fmt.Println(func() string {")
+	assertContains(t, out, "fmt.Println(func() error {")
+	assertNotContains(t, out, "func() int")
+	assertNotContains(t, out, "func() any")
+}
+
+func TestTranspileFile_Coalesce_NilSlice(t *testing.T) {
+	src := `package main
+
+func tags(in []string) []string {
+	out := in ?? []string{"none"}
+	return out
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if out == nil {")
+	assertNotContains(t, out, "runtime/zero")
+}
+
+func TestTranspileFile_Coalesce_SafeDeref(t *testing.T) {
+	src := `package main
+
+func age(p *int) int {
+	a := *p ?? 18
+	return a
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "a := zero.Deref(p)")
+	assertContains(t, out, "if p == nil {")
+	assertContains(t, out, "a = 18")
+}
+
+func TestTranspileFile_Coalesce_ReturnIsLazy(t *testing.T) {
+	src := `package main
+
+func lookup() string { return "x" }
+
+func name(n string) string {
+	return n ?? lookup()
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `if _godslVal := n; _godslVal != "" {`)
+	assertContains(t, out, "return _godslVal")
+	assertContains(t, out, "return lookup()")
+	assertNotContains(t, out, "func() string")
+}
+
+func TestTranspileFile_Coalesce_ExprWithoutIIFE(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func show(n string, p *int) {
+	fmt.Println(n ?? "anon", *p ?? 0)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `fmt.Println(zero.Or(n, "anon"), zero.DerefOr(p, 0))`)
+}
+
+func TestTranspileFile_Coalesce_Chain(t *testing.T) {
+	src := `package main
+
+func pick(a, b string) string {
+	v := a ?? b ?? "none"
+	return v
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "v = b")
+	assertContains(t, out, `v = "none"`)
+}
+
+func TestTranspileFile_Coalesce_AssignToOperand(t *testing.T) {
+	src := `package main
+
+func fill(n int, name string, p *string, get func() string) {
+	n = n ?? 7
+	name = get() ?? name
+	name = *p ?? name
+	name = get() ?? name + "!"
+	_, _ = n, name
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertNotContains(t, out, "n = n\n")
+	assertNotContains(t, out, "name = name\n")
+	assertContains(t, out, "\tif n == 0 {\n\t\tn = 7\n\t}")
+	assertContains(t, out, "if _godslVal := get(); _godslVal != \"\" {\n\t\tname = _godslVal\n\t}")
+	assertContains(t, out, "if p != nil {\n\t\tname = *p\n\t}\n")
+	assertContains(t, out, "if _godslVal := get(); _godslVal == \"\" {\n\t\tname = name + \"!\"\n\t} else {\n\t\tname = _godslVal\n\t}")
+}
+
+// ─── optional chaining ?. ─────────────────────────────────────────────────────
+
+const optChainTypes = `
+type Address struct{ City string }
+
+type Customer struct{ Address *Address }
+
+type Order struct {
+	Customer *Customer
+	Total    int
+}
+
+func (o *Order) Buyer() *Customer  { return o.Customer }
+func (c *Customer) Addr() *Address { return c.Address }
+func (a *Address) Name() string    { return a.City }
+`
+
+func TestTranspileFile_OptChain_WithCoalesce(t *testing.T) {
+	src := `package main
+` + optChainTypes + `
+func city(order *Order) string {
+	c := order?.Customer?.Address?.City ?? "unknown"
+	return c
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var c string")
+	assertContains(t, out, "if order != nil && order.Customer != nil && order.Customer.Address != nil {")
+	assertContains(t, out, "c = order.Customer.Address.City")
+	assertContains(t, out, `if c == "" {`)
+	assertNotContains(t, out, "?.")
+}
+
+func TestTranspileFile_OptChain_MethodCallsUseTemps(t *testing.T) {
+	src := `package main
+` + optChainTypes + `
+func name(order *Order) string {
+	n := order?.Buyer()?.Addr()?.Name()
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var n string")
+	assertContains(t, out, "if _godslOpt := order.Buyer(); _godslOpt != nil {")
+	assertContains(t, out, "if _godslOpt2 := _godslOpt.Addr(); _godslOpt2 != nil {")
+	assertContains(t, out, "n = _godslOpt2.Name()")
+}
+
+func TestTranspileFile_OptChain_ReturnZeroValue(t *testing.T) {
+	src := `package main
+` + optChainTypes + `
+func total(order *Order) int {
+	return order?.Total
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if order != nil {")
+	assertContains(t, out, "return order.Total")
+	assertContains(t, out, "return 0")
+}
+
+func TestTranspileFile_OptChain_StatementAndExpr(t *testing.T) {
+	src := `package main
+
+import "fmt"
+` + optChainTypes + `
+func show(order *Order) {
+	order?.Buyer()?.Addr()?.Name()
+	fmt.Println("city: " + order?.Customer?.Address?.City)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslOpt2.Name()")
+	assertContains(t, out, `fmt.Println("city: " + func() string {`)
+	assertContains(t, out, `return ""`)
+}
+
+func TestTranspileFile_OptChain_SkipsNonNilableReceiver(t *testing.T) {
+	src := `package main
+
+type Inner struct{ Name string }
+
+type Outer struct{ Inner Inner }
+
+func name(o *Outer) string {
+	return o?.Inner?.Name
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if o != nil {")
+	assertNotContains(t, out, "o.Inner != nil")
+}
+
+func TestTranspileFile_OptChain_UnknownTypeUsesDefaultLiteral(t *testing.T) {
+	src := `package main
+
+func city(order *Order) string {
+	c := order?.Customer?.City ?? "unknown"
+	return c
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "var c string")
+	assertContains(t, out, "c = order.Customer.City")
+}
+
+func TestTranspileFile_OptChain_UnknownType_ReturnsError(t *testing.T) {
+	src := `package main
+
+func city(order *Order) {
+	c := order?.Customer?.City
+	_ = c
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for optional chain of unknown type")
+	}
+}
+
+func TestTranspileFile_OptChain_TernaryWithFloatUnaffected(t *testing.T) {
+	src := `package main
+
+func pick(ok bool) float64 {
+	return ok ?.5 : 1.5
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "return .5")
+}
+
+// ─── guard ────────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Guard_CommaOk(t *testing.T) {
+	src := `package main
+
+type NotFound struct{ ID int }
+
+func (e NotFound) Error() string { return "not found" }
+
+var cache = map[int]string{}
+
+func get(id int) error {
+	guard user, ok := cache[id] else {
+		throw NotFound{id}
+	}
+	_ = user
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "user, ok := cache[id]")
+	assertContains(t, out, "if !ok {")
+	assertContains(t, out, "return NotFound{id}")
+}
+
+func TestTranspileFile_Guard_ErrorAndPointer(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+type User struct{ Name string }
+
+func find(id int) *User { return nil }
+
+func parse(s string, id int) int {
+	guard n, err := strconv.Atoi(s) else {
+		return -1
+	}
+	guard u := find(id) else {
+		return 0
+	}
+	_ = u
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if err != nil {")
+	assertContains(t, out, "if u == nil {")
+	assertNotContains(t, out, "runtime/zero")
+}
+
+func TestTranspileFile_Guard_ConditionNegated(t *testing.T) {
+	src := `package main
+
+func f(items []string, ready bool) {
+	for _, s := range items {
+		guard s != "" else {
+			continue
+		}
+		guard ready else {
+			panic("not ready")
+		}
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `if s == "" {`)
+	assertContains(t, out, "if !ready {")
+}
+
+func TestTranspileFile_Guard_FloatConditionKeepsNaN(t *testing.T) {
+	src := `package main
+
+func f(x float64, n int) {
+	guard x >= 0 else {
+		return
+	}
+	guard n >= 0 else {
+		return
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if !(x >= 0) {")
+	assertContains(t, out, "if n < 0 {")
+	assertNotContains(t, out, "x < 0")
+}
+
+func TestTranspileFile_Guard_ElseMustLeaveScope(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(m map[string]int) {
+	guard v, ok := m["a"] else {
+		fmt.Println("missing")
+	}
+	_ = v
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for guard else block that does not leave the scope")
+	}
+}
+
+func TestTranspileFile_Guard_ElseTerminatingStatements(t *testing.T) {
+	src := `package main
+
+import (
+	"log"
+	"os"
+	"testing"
+)
+
+func f(ok bool, l *log.Logger, t *testing.T) {
+	guard ok else { os.Exit(1) }
+	guard ok else { log.Fatalf("x") }
+	guard ok else { l.Panic("x") }
+	guard ok else { t.Fatal("x") }
+	guard ok else { for {} }
+	guard ok else {
+	outer:
+		for {
+			for {
+				break
+			}
+			continue outer
+		}
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "os.Exit(1)")
+	assertNotContains(t, out, "_godslProbe")
+}
+
+func TestTranspileFile_Guard_ElseNotTerminating(t *testing.T) {
+	cases := map[string]string{
+		"loop with break": "for {\n\t\tbreak\n\t}",
+		"labeled break":   "outer:\n\tfor {\n\t\tfor {\n\t\t\tbreak outer\n\t\t}\n\t}",
+		"break in if":     "for {\n\t\tif ok {\n\t\t\tbreak\n\t\t}\n\t}",
+		"custom Panic":    "logger.Panic()",
+		"custom Exit":     "app.Exit(1)",
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			src := `package main
+
+type Logger struct{}
+
+func (Logger) Panic() {}
+
+type App struct{}
+
+func (App) Exit(code int) {}
+
+var logger Logger
+var app App
+
+func f(ok bool) {
+	guard ok else {
+	` + body + `
+	}
+}
+`
+			_, err := transpiler.TranspileFile(src)
+			if err == nil || !strings.Contains(err.Error(), "guard else block must leave the scope") {
+				t.Errorf("expected guard scope error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTranspileFile_Guard_IdentifierStillUsable(t *testing.T) {
+	src := `package main
+
+func f() int {
+	guard := 1
+	guard++
+	return guard
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "guard := 1")
+}
+
+// ─── match ────────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Match_TypePatterns(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func describe(v any) string {
+	label := match v { case int n: "int " + strconv.Itoa(n); case string s: s; case nil: "nil"; default: "?" }
+	return label
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var label string")
+	assertContains(t, out, "switch _godslMatch := v.(type) {")
+	assertContains(t, out, "n := _godslMatch")
+	assertContains(t, out, "case nil:")
+	assertContains(t, out, `label = "?"`)
+	assertNotContains(t, out, "func() string")
+}
+
+func TestTranspileFile_Match_SingleBindingName(t *testing.T) {
+	src := `package main
+
+func size(v any) int {
+	return match v {
+	case string x: len(x)
+	case []int x: len(x)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "switch x := v.(type) {")
+	assertContains(t, out, "return len(x)")
+	assertContains(t, out, "return 0")
+}
+
+func TestTranspileFile_Match_ValuesAndGuards(t *testing.T) {
+	src := `package main
+
+func status(code int) string {
+	return match code {
+	case 200, 201: "ok"
+	case 500 if code > 0: "error"
+	default: "unknown"
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "case code == 200 || code == 201:")
+	assertContains(t, out, "case code == 500 && code > 0:")
+	assertContains(t, out, `return "unknown"`)
+}
+
+func TestTranspileFile_Match_TypeGuardsUseIfChain(t *testing.T) {
+	src := `package main
+
+func sign(v any) string {
+	return match v {
+	case int n if n > 0: "positive"
+	case int, int64: "zero"
+	default: "other"
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if n, _godslOk := v.(int); _godslOk && n > 0 {")
+	assertContains(t, out, "} else if _, _godslOk := v.(int64); _godslOk {")
+	assertContains(t, out, `return "other"`)
+}
+
+func TestTranspileFile_Match_TypeFromGoTypes(t *testing.T) {
+	src := `package main
+
+type User struct{ Name string }
+
+func name(v any, fallback string) string {
+	n := match v {
+	case User u: u.Name
+	default: fallback
+	}
+	return n
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var n string")
+}
+
+func TestTranspileFile_Match_UnifiesArmTypes(t *testing.T) {
+	src := `package main
+
+func f(x int) {
+	a := match x { case 1: 1; case 2: 2.5; default: 'a' }
+	b := match x { case 1: int64(1); default: 2 }
+	_, _ = a, b
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var a float64")
+	assertContains(t, out, "var b int64")
+}
+
+func TestTranspileFile_Match_InExpressionUsesIIFE(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func show(ok bool) {
+	fmt.Println("v=" + match ok { case true: "yes"; default: "no" })
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `fmt.Println("v=" + func() string {`)
+	assertContains(t, out, "switch ok {")
+}
+
+func TestTranspileFile_Match_CallStillUsable(t *testing.T) {
+	src := `package main
+
+func match(a, b string) bool { return a == b }
+
+func f() bool {
+	return match("a", "b")
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `return match("a", "b")`)
+}
+
+// ─── if / switch expressions ──────────────────────────────────────────────────
+
+func TestTranspileFile_IfExpr_AssignPredeclaresVar(t *testing.T) {
+	src := `package main
+
+func max(a, b int) int {
+	x := if a > b { a } else { b }
+	return x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var x int")
+	assertContains(t, out, "x = a")
+	assertContains(t, out, "x = b")
+	assertNotContains(t, out, "func() int")
+}
+
+func TestTranspileFile_SwitchExpr_CasesOnOneLine(t *testing.T) {
+	src := `package main
+
+func kind(code int) string {
+	k := switch code { case 200: "ok" case 404: "missing" default: "error" }
+	return k
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var k string")
+	assertContains(t, out, "switch code {")
+	assertContains(t, out, `k = "missing"`)
+	assertContains(t, out, `k = "error"`)
+}
+
+func TestTranspileFile_IfExpr_ReturnWithoutElse(t *testing.T) {
+	src := `package main
+
+func grade(n int) string {
+	return if n >= 90 {
+		"A"
+	} else if n >= 70 {
+		"B"
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `return "A"`)
+	assertContains(t, out, "} else if n >= 70 {")
+	assertContains(t, out, `return ""`)
+}
+
+func TestTranspileFile_SwitchExpr_TypeSwitchWithStatements(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func show(v any) string {
+	return switch x := v.(type) {
+	case int:
+		s := fmt.Sprint(x * 2)
+		s
+	default:
+		"?"
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "switch x := v.(type) {")
+	assertContains(t, out, "s := fmt.Sprint(x * 2)")
+	assertContains(t, out, "return s")
+}
+
+func TestTranspileFile_IfExpr_InExpressionUsesIIFE(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func show(ok bool) {
+	fmt.Println("v=" + if ok { "yes" } else { "no" })
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func() string {")
+	assertContains(t, out, `return "yes"`)
+}
+
+func TestTranspileFile_IfExpr_UnifiesBranchTypes(t *testing.T) {
+	src := `package main
+
+func f(c bool) float64 {
+	y := if c { 1 } else { 2.5 }
+	return y
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var y float64")
+}
+
+func TestTranspileFile_IfExpr_BranchWithoutValue_ReturnsError(t *testing.T) {
+	src := `package main
+
+func f(ok bool) int {
+	x := if ok { 1 } else { }
+	return x
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for if expression branch without a value")
+	}
+}
+
+// ─── enum ─────────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Enum_IotaConstsAndMethods(t *testing.T) {
+	src := `package main
+
+enum Color { Red, Green, Blue }
+
+func main() {
+	_ = Red
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "type Color int")
+	assertContains(t, out, "Red Color = iota")
+	assertContains(t, out, "func (c Color) String() string {")
+	assertContains(t, out, `return fmt.Sprintf("Color(%d)", c)`)
+	assertContains(t, out, "func ParseColor(s string) (Color, error) {")
+	assertContains(t, out, "func ColorValues() []Color {")
+	assertContains(t, out, "return []Color{Red, Green, Blue}")
+	assertContains(t, out, "func (c Color) IsValid() bool {")
+	assertContains(t, out, "func (c Color) MarshalText() ([]byte, error) {")
+	assertContains(t, out, "func (c *Color) UnmarshalText(text []byte) error {")
+	assertContains(t, out, `"fmt"`)
+}
+
+func TestTranspileFile_Enum_StringValues(t *testing.T) {
+	src := `package main
+
+enum Status: string {
+	Active = "active"
+	Blocked = "blocked"
+	Unknown
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "type Status string")
+	assertContains(t, out, `Active  Status = "active"`)
+	assertContains(t, out, `Unknown Status = "Unknown"`)
+	assertContains(t, out, "return string(s)")
+	assertContains(t, out, "switch v := Status(s); v {")
+	assertContains(t, out, `return "", fmt.Errorf("invalid Status %q", s)`)
+	assertNotContains(t, out, "iota")
+}
+
+func TestTranspileFile_Enum_ReceiverDoesNotShadowLocals(t *testing.T) {
+	src := `package main
+
+enum Version { V1, V2 }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func (v *Version) UnmarshalText(text []byte) error {")
+	assertContains(t, out, "parsed, err := ParseVersion(string(text))")
+	assertContains(t, out, "*v = parsed")
+}
+
+func TestTranspileFile_Enum_ExplicitIntValues(t *testing.T) {
+	src := `package main
+
+enum Code: uint8 { OK = 0, NotFound = 4, Failed = 5 }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "type Code uint8")
+	assertContains(t, out, "NotFound Code = 4")
+	assertNotContains(t, out, "iota")
+}
+
+func TestTranspileFile_Enum_PartialIntValues_ReturnsError(t *testing.T) {
+	src := `package main
+
+enum Code { OK = 1, Failed }
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for an int enum with partially explicit values")
+	}
+}
+
+func TestTranspileFile_Enum_UnsupportedType_ReturnsError(t *testing.T) {
+	src := `package main
+
+enum Ratio: float64 { Half = 0.5 }
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for a float enum")
+	}
+}
+
+func TestTranspileFile_Enum_MissingSwitchCase_Warns(t *testing.T) {
+	src := `package main
+
+enum Color { Red, Green, Blue }
+
+func name(c Color) string {
+	switch c {
+	case Red:
+		return "r"
+	case Green:
+		return "g"
+	}
+	return match c {
+		case Red: "r"
+		case Green if false: "g"
+		case Blue: "b"
+	}
+}
+
+func full(c Color) int {
+	switch c {
+	case Red, Green, Blue:
+		return 1
+	}
+	switch c {
+	case Red:
+		return 2
+	default:
+		return 3
+	}
+}
+`
+	var warnings bytes.Buffer
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{Filename: "colors.godsl", Warnings: &warnings})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions returned error: %v", err)
+	}
+	assertValidGo(t, out)
+	got := warnings.String()
+	for _, want := range []string{
+		"colors.godsl:6:2: warning: switch over Color is missing cases: Blue",
+		"colors.godsl:12:9: warning: match over Color is missing cases: Green",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("warnings should contain %q\n\nWarnings:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "warning:"); n != 2 {
+		t.Errorf("expected 2 warnings, got %d\n\nWarnings:\n%s", n, got)
+	}
+}
+
+// ─── record ───────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Record_StructAndMethods(t *testing.T) {
+	src := `package main
+
+record Point(X, Y int)
+
+func main() {
+	_ = NewPoint(1, 2).WithY(3)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "type Point struct {")
+	assertContains(t, out, "X, Y int")
+	assertContains(t, out, "func NewPoint(x int, y int) Point {")
+	assertContains(t, out, "return Point{X: x, Y: y}")
+	assertContains(t, out, "func (p Point) Equal(other Point) bool {")
+	assertContains(t, out, "return p.X == other.X && p.Y == other.Y")
+	assertContains(t, out, `return fmt.Sprintf("Point{X: %v, Y: %v}", p.X, p.Y)`)
+	assertContains(t, out, "func (p Point) WithX(x int) Point {")
+	assertContains(t, out, "func (p Point) WithY(y int) Point {")
+	assertNotContains(t, out, "reflect")
+}
+
+func TestTranspileFile_Record_NonComparableFieldsUseDeepEqual(t *testing.T) {
+	src := `package main
+
+type Meta struct {
+	Tags []string
+}
+
+record Doc(Title string, Tags []string, Meta Meta)
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "d.Title == other.Title")
+	assertContains(t, out, "reflect.DeepEqual(d.Tags, other.Tags)")
+	assertContains(t, out, "reflect.DeepEqual(d.Meta, other.Meta)")
+	assertContains(t, out, `"reflect"`)
+}
+
+func TestTranspileFile_Record_DeriveJSONAndHash(t *testing.T) {
+	src := `package main
+
+record User(ID int64, Name string, Type string) derive(json, hash)
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "ID   int64  `json:\"id\"`")
+	assertContains(t, out, "Type string `json:\"type\"`")
+	assertContains(t, out, "func NewUser(id int64, name string, type_ string) User {")
+	assertContains(t, out, "func (u User) Hash() uint64 {")
+	assertContains(t, out, `fmt.Fprintf(hasher, "%#v|%#v|%#v", u.ID, u.Name, u.Type)`)
+	assertContains(t, out, `"hash/fnv"`)
+}
+
+func TestTranspileFile_Record_ReceiverDoesNotShadowLocals(t *testing.T) {
+	src := `package main
+
+record Host(Name string) derive(hash)
+
+record Vec(X int, V int)
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func (h Host) Hash() uint64 {")
+	assertContains(t, out, "hasher := fnv.New64a()")
+	assertContains(t, out, "func (v Vec) WithV(value int) Vec {")
+	assertContains(t, out, "v.V = value")
+}
+
+func TestTranspileFile_Record_Generic(t *testing.T) {
+	src := `package main
+
+record Pair[K comparable, V any](Key K, Value V)
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "type Pair[K comparable, V any] struct {")
+	assertContains(t, out, "func NewPair[K comparable, V any](key K, value V) Pair[K, V] {")
+	assertContains(t, out, "func (p Pair[K, V]) Equal(other Pair[K, V]) bool {")
+	assertContains(t, out, "p.Key == other.Key && reflect.DeepEqual(p.Value, other.Value)")
+}
+
+func TestTranspileFile_Record_UnknownDerive_ReturnsError(t *testing.T) {
+	src := `package main
+
+record Point(X, Y int) derive(yaml)
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for an unknown derive")
+	}
+}
+
+func TestTranspileFile_Record_UnnamedField_ReturnsError(t *testing.T) {
+	src := `package main
+
+record Point(int, int)
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for unnamed record fields")
+	}
+}
+
+// ─── string interpolation ─────────────────────────────────────────────────────
+
+func TestTranspileFile_Interp_Sprintf(t *testing.T) {
+	src := `package main
+
+type User struct{ Name string }
+
+func show(u User, items []int, ratio float64) string {
+	return $"user {u.Name} has {len(items)} items ({ratio:%.2f})"
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `return fmt.Sprintf("user %v has %v items (%.2f)", u.Name, len(items), ratio)`)
+	assertContains(t, out, `"fmt"`)
+}
+
+func TestTranspileFile_Interp_EscapesPercentAndBraces(t *testing.T) {
+	src := `package main
+
+func show(n int) string {
+	return $"{n}% of {{total}}\t\"done\""
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `fmt.Sprintf("%v%% of {total}\t\"done\"", n)`)
+}
+
+func TestTranspileFile_Interp_NoExpressions_PlainString(t *testing.T) {
+	src := `package main
+
+func show() string {
+	return $"just {{text}}"
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `return "just {text}"`)
+	assertNotContains(t, out, "Sprintf")
+}
+
+func TestTranspileFile_Interp_NestedGodslExpressions(t *testing.T) {
+	src := `package main
+
+func show(ok bool, name string, m map[string]int) string {
+	return $"ok={ok ? "yes" : "no"} name={name ?? "anon"} k={m["k"]}"
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `return fmt.Sprintf("ok=%v name=%v k=%v", _godslTern, zero.Or(name, "anon"), m["k"])`)
+	assertContains(t, out, `zero.Or(name, "anon")`)
+	assertContains(t, out, `m["k"]`)
+}
+
+func TestTranspileFile_Interp_PackageVar(t *testing.T) {
+	src := `package main
+
+const version = 2
+
+var banner = $"v{version} ready"
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `var banner = fmt.Sprintf("v%v ready", version)`)
+}
+
+func TestTranspileFile_GeneratedCodeUsesImportAlias(t *testing.T) {
+	src := `package main
+
+import f "fmt"
+
+enum Color { Red, Green }
+
+record Point(X int, Y int) derive(hash)
+
+func show(p Point) string {
+	f.Println(p)
+	return $"p={p.X}"
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "return f.Sprintf(\"Color(%d)\", c)")
+	assertContains(t, out, "return 0, f.Errorf(")
+	assertContains(t, out, "return f.Sprintf(\"Point{")
+	assertContains(t, out, "f.Fprintf(hasher,")
+	assertContains(t, out, "return f.Sprintf(\"p=%v\", p.X)")
+	assertNotContains(t, out, "fmt.")
+	assertNotContains(t, out, "\t\"fmt\"")
+}
+
+func TestTranspileFile_GeneratedImportAvoidsTakenName(t *testing.T) {
+	src := `package main
+
+import (
+	_ "fmt"
+	fnv "example.com/fnv"
+)
+
+record Point(X int) derive(hash)
+
+var _ = fnv.Name
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslFmt \"fmt\"")
+	assertContains(t, out, "_godslFnv \"hash/fnv\"")
+	assertContains(t, out, "hasher := _godslFnv.New64a()")
+	assertContains(t, out, "_godslFmt.Fprintf(hasher,")
+}
+
+func TestTranspileFile_Interp_InvalidFormat_ReturnsError(t *testing.T) {
+	src := `package main
+
+func show(x float64) string {
+	return $"{x:%.2}"
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for an invalid format verb")
+	}
+}
+
+func TestTranspileFile_Interp_EmptyExpression_ReturnsError(t *testing.T) {
+	src := `package main
+
+func show() string {
+	return $"a { } b"
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for an empty embedded expression")
+	}
+}
+
+// ─── lambdas ──────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Lambda_InferredFromGenericCallee(t *testing.T) {
+	src := `package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+type User struct {
+	Name string
+	Age  int
+}
+
+func sortUsers(users []User) int {
+	slices.SortFunc(users, (a, b) => cmp.Compare(a.Age, b.Age))
+	return slices.IndexFunc(users, u => u.Age > 25)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "slices.SortFunc(users, func(a User, b User) int { return cmp.Compare(a.Age, b.Age) })")
+	assertContains(t, out, "slices.IndexFunc(users, func(u User) bool { return u.Age > 25 })")
+}
+
+func TestTranspileFile_Lambda_SortSlice(t *testing.T) {
+	src := `package main
+
+import "sort"
+
+func sortNames(names []string) {
+	sort.Slice(names, (i, j) => names[i] < names[j])
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "sort.Slice(names, func(i int, j int) bool { return names[i] < names[j] })")
+}
+
+func TestTranspileFile_Lambda_AnnotatedResultFromBody(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func Map[T, U any](xs []T, f func(T) U) []U {
+	var out []U
+	for _, x := range xs {
+		out = append(out, f(x))
+	}
+	return out
+}
+
+func upper(names []string) []string {
+	double := (x int) => x * 2
+	_ = double
+	return Map(names, (s string) => strings.ToUpper(s))
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "double := func(x int) int { return x * 2 }")
+	assertContains(t, out, "Map(names, func(s string) string { return strings.ToUpper(s) })")
+}
+
+func TestTranspileFile_Lambda_BlockBodyAndNoResult(t *testing.T) {
+	src := `package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+func run(xs []int) {
+	slices.SortFunc(xs, (a, b) => {
+		if a == b {
+			return 0
+		}
+		return cmp.Compare(b, a)
+	})
+	forEach(xs, x => fmt.Println(x))
+}
+
+func forEach(xs []int, f func(int)) {
+	for _, x := range xs {
+		f(x)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "slices.SortFunc(xs, func(a int, b int) int {")
+	assertContains(t, out, "return cmp.Compare(b, a)")
+	assertContains(t, out, "forEach(xs, func(x int) { fmt.Println(x) })")
+}
+
+func TestTranspileFile_Lambda_BlockBodyResultFromReturns(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func run() {
+	sign := (x int) => {
+		if x > 0 {
+			return "pos"
+		}
+		return "neg"
+	}
+	half := (x int) => {
+		if x == 0 {
+			return 0
+		}
+		return float64(x) / 2
+	}
+	show := (s string) => {
+		fmt.Println(s)
+	}
+	show(sign(1))
+	_ = half
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "sign := func(x int) string {")
+	assertContains(t, out, "half := func(x int) float64 {")
+	assertContains(t, out, "show := func(s string) {")
+}
+
+func TestTranspileFile_Lambda_BlockBodyResultUnknown_ReturnsError(t *testing.T) {
+	src := `package main
+
+func run() {
+	f := (x int) => {
+		if x > 0 {
+			return x
+		}
+		return
+	}
+	_ = f
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "4:7: cannot infer lambda result type") {
+		t.Errorf("expected a positioned lambda result error, got %v", err)
+	}
+}
+
+func TestTranspileFile_Lambda_TypeFromAssignVarAndReturn(t *testing.T) {
+	src := `package main
+
+var g func(int) int = x => x * 3
+
+func inc() func(int) int {
+	return x => x + 1
+}
+
+func f() {
+	var h func(int) int
+	h = x => x + 1
+	var k func(string) int = s => len(s)
+	_, _ = h, k
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var g func(int) int = func(x int) int { return x * 3 }")
+	assertContains(t, out, "return func(x int) int { return x + 1 }")
+	assertContains(t, out, "h = func(x int) int { return x + 1 }")
+	assertContains(t, out, "var k func(string) int = func(s string) int { return len(s) }")
+}
+
+func TestTranspileFile_Lambda_InSwitchAndDefer(t *testing.T) {
+	src := `package main
+
+import "slices"
+
+func apply(f func(int) int, v int) int { return f(v) }
+
+func f(k int, xs []int) {
+	switch k {
+	case 1:
+		apply(x => x * 2, k)
+	}
+	defer apply(x => x, k)
+	slices.SortFunc(xs, (a int, b int) => a - b)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "apply(func(x int) int { return x * 2 }, k)")
+	assertContains(t, out, "defer apply(func(x int) int { return x }, k)")
+	assertContains(t, out, "slices.SortFunc(xs, func(a int, b int) int { return a - b })")
+}
+
+func TestTranspileFile_Lambda_CannotInfer_ReturnsError(t *testing.T) {
+	src := `package main
+
+func f() {
+	g := x => x
+	_ = g
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for an untyped lambda without an expected type")
+	}
+}
+
+func TestTranspileFile_Lambda_WrongArity_ReturnsError(t *testing.T) {
+	src := `package main
+
+import "sort"
+
+func f(xs []int) {
+	sort.Slice(xs, i => xs[i] > 0)
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil {
+		t.Error("expected TranspileFile to return an error for a lambda with the wrong number of parameters")
+	}
+}
+
+// ─── for ... in ───────────────────────────────────────────────────────────────
+
+func TestTranspileFile_ForIn_ExclusiveRange(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(xs []string) {
+	for i in 0..<len(xs) {
+		fmt.Println(xs[i])
+	}
+	for i in 0..<10 {
+		fmt.Println(i)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i, _godslEnd := 0, len(xs); i < _godslEnd; i++ {")
+	assertContains(t, out, "for i := 0; i < 10; i++ {")
+}
+
+func TestTranspileFile_ForIn_InclusiveRangeWithStep(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f() {
+	for i in 10..0 step -2 {
+		fmt.Println(i)
+	}
+	for i in 1..9 step 4 {
+		fmt.Println(i)
+	}
+	for i in 3..1 step -1 {
+		fmt.Println(i)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i := 10; i >= 0; i -= 2 {")
+	assertContains(t, out, "for i := 1; i <= 9; i += 4 {")
+	assertContains(t, out, "for i := 3; i >= 1; i-- {")
+}
+
+func TestTranspileFile_ForIn_VariableStep(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(step int) {
+	for i in 0..<100 step step {
+		fmt.Println(i)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i, _godslStep := 0, step; _godslStep > 0 && i < 100 || _godslStep < 0 && i > 100 || _godslStep == 0; i += _godslStep {")
+	assertContains(t, out, "if _godslStep == 0 {\n\t\t\tpanic(\"range step must not be zero\")\n\t\t}")
+}
+
+func TestTranspileFile_ForIn_InclusiveRangeStopsAtBound(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(lo, hi uint8, step int) {
+	for i in lo..hi {
+		fmt.Println(i)
+	}
+	for i in hi..0 step -1 {
+		fmt.Println(i)
+	}
+	for i in 0..int(hi) step step {
+		fmt.Println(i)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i, _godslEnd, _godslDone := lo, hi, false; !_godslDone && i <= _godslEnd; _godslDone, i = i == _godslEnd, i+1 {")
+	assertContains(t, out, "for i, _godslDone := hi, false; !_godslDone && i >= 0; _godslDone, i = i == 0, i-1 {")
+	assertContains(t, out, "for i, _godslEnd, _godslStep, _godslDone := 0, int(hi), step, false; !_godslDone && (_godslStep > 0 && i <= _godslEnd || _godslStep < 0 && i >= _godslEnd || _godslStep == 0); _godslDone, i = i == _godslEnd, i+_godslStep {")
+}
+
+func TestTranspileFile_ForIn_LowBoundTakesHighType(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(n int64) {
+	for i in 0..<n {
+		fmt.Println(i)
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i, _godslEnd := int64(0), n; i < _godslEnd; i++ {")
+}
+
+func TestTranspileFile_ForIn_Collection(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(items []string, ch chan int) {
+	for i, v in items {
+		fmt.Println(i, v)
+	}
+	for v in items {
+		fmt.Println(v)
+	}
+	for v in ch {
+		fmt.Println(v)
+	}
+	for _ in 0..<3 {
+		fmt.Println()
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "for i, v := range items {")
+	assertContains(t, out, "for _, v := range items {")
+	assertContains(t, out, "for v := range ch {")
+	assertContains(t, out, "for _godslI := 0; _godslI < 3; _godslI++ {")
+}
+
+func TestTranspileFile_ForIn_Errors(t *testing.T) {
+	cases := map[string]string{
+		"zero step":      "for i in 0..10 step 0 {\n\t}",
+		"two variables":  "for i, v in 0..10 {\n\t}",
+		"step on values": "for v in items step 2 {\n\t}",
+	}
+	for name, loop := range cases {
+		src := "package main\n\nfunc f(items []int) {\n\t" + loop + "\n}\n"
+		if _, err := transpiler.TranspileFile(src); err == nil {
+			t.Errorf("%s: expected TranspileFile to return an error", name)
+		}
+	}
+}
+
+// ─── default and named arguments ──────────────────────────────────────────────
+
+func TestTranspileFile_DefaultArgs_FilledAtCallSite(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+const defaultSep = ", "
+
+func join(parts []string, sep = defaultSep, upper bool = false) string {
+	s := strings.Join(parts, sep)
+	if upper {
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+func main() {
+	_ = join([]string{"a"})
+	_ = join([]string{"a"}, upper: true)
+	_ = join(parts: []string{"a"}, sep: "-")
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func join(parts []string, sep string, upper bool) string {")
+	assertContains(t, out, `_ = join([]string{"a"}, _godslDefault_join_sep(), false)`)
+	assertContains(t, out, `_ = join([]string{"a"}, _godslDefault_join_sep(), true)`)
+	assertContains(t, out, `_ = join([]string{"a"}, "-", false)`)
+	assertContains(t, out, "func _godslDefault_join_sep() string {\n\treturn defaultSep\n}")
+}
+
+func TestTranspileFile_DefaultArgs_EvaluatedInPackageScope(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+const limit = 3
+
+func fetch(n int, retries = limit) int { return retries }
+
+func main() {
+	limit := 100
+	fmt.Println(fetch(1), limit)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "fmt.Println(fetch(1, _godslDefault_fetch_retries()), limit)")
+	assertContains(t, out, "func _godslDefault_fetch_retries() int {\n\treturn limit\n}")
+}
+
+func TestTranspileFile_DefaultArgs_ExportedGeneratesOptions(t *testing.T) {
+	src := `package net
+
+import "time"
+
+func Dial(addr string, timeout = 5 * time.Second, retries = 3) error {
+	return nil
+}
+
+func connect() error {
+	return Dial("x", retries: 5)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func Dial(addr string, timeout time.Duration, retries int) error")
+	assertContains(t, out, `return Dial("x", _godslDefault_Dial_timeout(), 5)`)
+	assertContains(t, out, "type DialOptions struct {\n\tTimeout time.Duration\n\tRetries int\n}")
+	assertContains(t, out, "return DialOptions{Timeout: 5 * time.Second, Retries: 3}")
+	assertContains(t, out, "func (o DialOptions) WithRetries(retries int) DialOptions {")
+	assertContains(t, out, "func DialWithOptions(addr string, opts DialOptions) error {\n\treturn Dial(addr, opts.Timeout, opts.Retries)\n}")
+}
+
+func TestTranspileFile_DefaultArgs_OtherFileAndPackage(t *testing.T) {
+	netSigs, err := transpiler.CollectSignatures(`package net
+
+import "time"
+
+func Dial(addr string, timeout = 5 * time.Second, retries = 3) error { return nil }
+`)
+	if err != nil {
+		t.Fatalf("CollectSignatures returned error: %v", err)
+	}
+	ownSigs, err := transpiler.CollectSignatures(`package main
+
+import "strings"
+
+func greet(name = strings.ToUpper("world")) string { return "hi " + name }
+`)
+	if err != nil {
+		t.Fatalf("CollectSignatures returned error: %v", err)
+	}
+
+	src := `package main
+
+import "example.com/app/net"
+
+func main() {
+	_ = net.Dial("x", retries: 5)
+	_ = greet()
+}
+`
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{
+		Signatures: map[string]map[string]*transpiler.FuncSignature{
+			"":                    ownSigs,
+			"example.com/app/net": netSigs,
+		},
+	})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions returned error: %v", err)
+	}
+	assertContains(t, out, `_ = net.DialWithOptions("x", net.DefaultDialOptions().WithRetries(5))`)
+	assertContains(t, out, `_ = greet(_godslDefault_greet_name())`)
+	assertNotContains(t, out, `"strings"`)
+}
+
+func TestTranspileFile_DefaultArgs_NamedArgsKeepEvaluationOrder(t *testing.T) {
+	src := `package main
+
+func f(a = 1, b = 2, c = 3) {}
+
+func next(s string) int { return len(s) }
+
+func g(x int) {
+	f(b: next("b"), c: next("c"), a: x)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, `f(x, next("b"), next("c"))`)
+
+	src = strings.Replace(src, `f(b: next("b"), c: next("c"), a: x)`, `f(c: next("c"), a: next("a"))`, 1)
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "argument a would be evaluated before argument c") {
+		t.Errorf("expected an evaluation order error, got %v", err)
+	}
+}
+
+func TestTranspileFile_DefaultArgs_OptionsCodeIsFormatted(t *testing.T) {
+	src := `package log
+
+func Print(msg string, level = 1, verbose = false) {
+}
+`
+	out := transpileOK(t, src)
+	assertContains(t, out, "func PrintWithOptions(msg string, opts PrintOptions) {\n\tPrint(msg, opts.Level, opts.Verbose)\n}")
+	assertContains(t, out, "type PrintOptions struct {\n\tLevel   int\n\tVerbose bool\n}")
+	formatted, err := format.Source([]byte(out))
+	if err != nil {
+		t.Fatalf("format.Source error: %v", err)
+	}
+	if string(formatted) != out {
+		t.Errorf("generated code is not gofmt-formatted:\n%s", out)
+	}
+}
+
+func TestTranspileFile_DefaultArgs_ImportUsedByDefaultKept(t *testing.T) {
+	src := `package main
+
+import "strings"
+
+func greet(name = strings.ToUpper("world")) string {
+	return "hi " + name
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func greet(name string) string")
+	assertContains(t, out, `return strings.ToUpper("world")`)
+	assertContains(t, out, `"strings"`)
+}
+
+func TestTranspileFile_DefaultArgs_ShadowedFuncNotRewritten(t *testing.T) {
+	src := `package main
+
+func f(x int = 1) int { return x }
+
+func main() {
+	f := func() int { return 0 }
+	_ = f()
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_ = f()")
+}
+
+func TestTranspileFile_DefaultArgs_Errors(t *testing.T) {
+	cases := map[string]string{
+		"unknown parameter":      "func f(a int, b = 1) {}\nfunc g() { f(1, c: 2) }",
+		"missing argument":       "func f(a int, b = 1) {}\nfunc g() { f(b: 2) }",
+		"positional after name":  "func f(a int, b = 1) {}\nfunc g() { f(b: 2, 1) }",
+		"given twice":            "func f(a int, b = 1) {}\nfunc g() { f(1, a: 2) }",
+		"too many arguments":     "func f(a int, b = 1) {}\nfunc g() { f(1, 2, 3) }",
+		"required after default": "func f(a = 1, b int) {}",
+		"named without defaults": "func f(a int) {}\nfunc g() { f(a: 1) }",
+		"default in literal":     "func g() { _ = func(a int = 1) {} }",
+		"default in method":      "type T struct{}\nfunc (T) f(a int = 1) {}",
+		"with type check pass":   "type T struct{}\nfunc (T) f(a int = 1) {}\nfunc g(x *T) { _ = x?.f }",
+		"refers to parameter":    "func scale(a int, b = a * 2) int { return b }",
+		"refers to later param":  "func f(a = len(s), s string = \"x\") {}",
+		"depends on type param":  "func f[T any](a T, b = *new(T)) {}",
+		"calls out of order":     "func f(a = 1, c = 2) {}\nfunc n() int { return 0 }\nfunc g() { f(c: n(), a: n()) }",
+	}
+	for name, code := range cases {
+		src := "package main\n\n" + code + "\n"
+		if _, err := transpiler.TranspileFile(src); err == nil {
+			t.Errorf("%s: expected TranspileFile to return an error", name)
+		}
+	}
+}
+
+// ─── assert ───────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Assert_PanicsWithSourceText(t *testing.T) {
+	src := `package main
+
+func push(buf []int, cap int, v int) []int {
+	assert len(buf) < cap, "buffer overflow"
+	assert v >= 0
+	return append(buf, v)
+}
+`
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{Filename: "buf.godsl"})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, `"github.com/sviridovkonstantin42/godsl/runtime/assert"`)
+	assertContains(t, out, "if assert.Enabled && len(buf) >= cap {")
+	assertContains(t, out, `assert.Fail("buf.godsl:4", "len(buf) < cap", "buffer overflow")`)
+	assertContains(t, out, `assert.Fail("buf.godsl:5", "v >= 0")`)
+}
+
+func TestTranspileFile_Assert_FloatConditionKeepsNaN(t *testing.T) {
+	src := `package main
+
+func scale(x float64, n int) {
+	assert x >= 0
+	assert n < 10
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if assert.Enabled && !(x >= 0) {")
+	assertContains(t, out, "if assert.Enabled && n >= 10 {")
+}
+
+func TestTranspileFile_Assert_InTestCallsFatalf(t *testing.T) {
+	src := `package main
+
+import "testing"
+
+func TestBuf(t *testing.T) {
+	n := 3
+	assert n % 2 == 0, "n must be even"
+	t.Run("sub", func(st *testing.T) {
+		assert n > 0
+	})
+}
+`
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{Filename: "buf_test.godsl"})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, `t.Fatalf("buf_test.godsl:7: assertion failed: n%%2 == 0: %v", "n must be even")`)
+	assertContains(t, out, `st.Fatalf("buf_test.godsl:9: assertion failed: n > 0")`)
+	assertNotContains(t, out, "runtime/assert")
+}
+
+func TestTranspileFile_Assert_FunctionNamedAssertStillWorks(t *testing.T) {
+	src := `package main
+
+func assert(ok bool) {}
+
+func main() {
+	assert(true)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "assert(true)")
+}
+
+func TestTranspileFile_Assert_ConflictingImport(t *testing.T) {
+	src := `package main
+
+import "github.com/stretchr/testify/assert"
+
+func f(n int) {
+	assert n > 0
+	_ = assert.True
+}
+`
+	if _, err := transpiler.TranspileFile(src); err == nil {
+		t.Error("expected TranspileFile to return an error")
+	}
+}
+
+// ─── requires / ensures ───────────────────────────────────────────────────────
+
+func TestTranspileFile_Contracts_RequiresAndEnsures(t *testing.T) {
+	src := `package main
+
+type Acct struct{ Balance int }
+
+func Transfer(a, b *Acct, amt int) error requires amt > 0 ensures a.Balance >= 0 {
+	a.Balance -= amt
+	b.Balance += amt
+	return nil
+}
+`
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{Filename: "bank.godsl"})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, `"github.com/sviridovkonstantin42/godsl/runtime/contract"`)
+	assertContains(t, out, "func Transfer(a, b *Acct, amt int) error {")
+	assertContains(t, out, "if contract.Enabled && amt <= 0 {")
+	assertContains(t, out, `contract.Requires("bank.godsl:5", "Transfer", "amt > 0")`)
+	assertContains(t, out, "defer func() {")
+	assertContains(t, out, `contract.Ensures("bank.godsl:5", "Transfer", "a.Balance >= 0")`)
+}
+
+func TestTranspileFile_Contracts_FloatConditionKeepsNaN(t *testing.T) {
+	src := `package main
+
+func Sqrt(x float64) (r float64) requires x >= 0 ensures r >= 0 {
+	return x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if contract.Enabled && !(x >= 0) {")
+	assertContains(t, out, "if !(r >= 0) {")
+}
+
+func TestTranspileFile_Contracts_OldAndNamedResults(t *testing.T) {
+	src := `package main
+
+func Add(xs []int, x int) (out []int) ensures len(out) == old(len(xs)) + 1 ensures out[len(out)-1] == old(x) {
+	return append(xs, x)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslOld := len(xs)")
+	assertContains(t, out, "_godslOld2 := x")
+	assertContains(t, out, "if len(out) != _godslOld+1 {")
+	assertContains(t, out, "if out[len(out)-1] != _godslOld2 {")
+	assertContains(t, out, `"len(out) == old(len(xs))+1"`)
+}
+
+func TestTranspileFile_Contracts_ResultTypeNamedRequires(t *testing.T) {
+	src := `package main
+
+type requires int
+
+func f() requires { return 0 }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "func f() requires")
+	assertNotContains(t, out, "runtime/contract")
+}
+
+func TestTranspileFile_Contracts_Errors(t *testing.T) {
+	cases := map[string]string{
+		"old in requires": "func f(x int) requires old(x) > 0 {}",
+		"nested old":      "func f(x int) ensures old(old(x)) > 0 {}",
+		"old arguments":   "func f(x int) ensures old(x, 1) > 0 {}",
+		"no body":         "func f(x int) requires x > 0",
+	}
+	for name, code := range cases {
+		src := "package main\n\n" + code + "\n"
+		if _, err := transpiler.TranspileFile(src); err == nil {
+			t.Errorf("%s: expected TranspileFile to return an error", name)
+		}
+	}
+}
+
+// ─── val ──────────────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Val_LowersToShortDecl(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func computeLimit() int { return 3 }
+
+func main() {
+	val limit = computeLimit()
+	val a, b = 1, "x"
+	val n = must strconv.Atoi("42")
+	if val half = limit / 2; half > 0 {
+		_ = half
+	}
+	val := 10
+	val++
+	_, _, _, _ = a, b, n, val
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "limit := computeLimit()")
+	assertContains(t, out, `a, b := 1, "x"`)
+	assertContains(t, out, `n, err := strconv.Atoi("42")`)
+	assertContains(t, out, "if half := limit / 2; half > 0 {")
+	assertContains(t, out, "val := 10")
+}
+
+func TestTranspileFile_Val_ShadowingAndElementsAllowed(t *testing.T) {
+	src := `package main
+
+func main() {
+	val xs = []int{1}
+	xs[0] = 2
+	{
+		xs := []int{3}
+		xs = append(xs, 4)
+		_ = xs
+	}
+	_ = xs
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "xs[0] = 2")
+}
+
+func TestTranspileFile_Val_PointerMethodRejected(t *testing.T) {
+	src := `package main
+
+type Counter struct{ N int }
+
+func (c *Counter) Inc() { c.N++ }
+
+func main() {
+	val c = Counter{}
+	c.Inc()
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "9:2: cannot call pointer method Inc on val c") {
+		t.Errorf("expected error for pointer method call on val, got %v", err)
+	}
+}
+
+func TestTranspileFile_Val_MutationThroughReferencesAllowed(t *testing.T) {
+	src := `package main
+
+type Counter struct{ N int }
+
+func (c *Counter) Inc() { c.N++ }
+
+func (c Counter) Get() int { return c.N }
+
+type S struct {
+	Xs []int
+	M  map[string]int
+	P  *Counter
+	Counter
+}
+
+func main() {
+	val x = S{}
+	x.Xs[0] = 3
+	x.M["a"] = 1
+	x.P.N = 4
+	x.P.Inc()
+	_ = x.Get()
+	val p = &Counter{}
+	p.N = 1
+	p.Inc()
+	val xs = []int{1, 2}
+	val s = "text"
+	val ap = &[2]int{}
+	_, _, _ = xs[1:], s[1:], ap[:]
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_, _, _ = xs[1:], s[1:], ap[:]")
+	assertContains(t, out, "x.P.Inc()")
+	assertContains(t, out, "p.Inc()")
+}
+
+func TestTranspileFile_Val_Errors(t *testing.T) {
+	cases := map[string]struct{ code, want string }{
+		"assign":       {"val x = 1\n\tx = 2", "5:2: cannot assign to val x"},
+		"op-assign":    {"val x = 1\n\tx -= 1", "5:2: cannot assign to val x"},
+		"increment":    {"val x = 1\n\tx++", "5:2: cannot increment val x"},
+		"address":      {"val x = 1\n\tp := &x\n\t_ = p", "5:8: cannot take the address of val x"},
+		"redefine":     {"val x = 1\n\tx, y := 2, 3\n\t_ = y", "5:2: cannot assign to val x"},
+		"range assign": {"val k = 0\n\tfor k = range []int{1} {\n\t}", "5:6: cannot assign to val k"},
+		"closure":      {"val x = 1\n\tf := func() { x = 3 }\n\tf()", "5:16: cannot assign to val x"},
+		"redeclare":    {"x := 1\n\tval x, y = 2, 3\n\t_ = y", "5:6: x redeclared in this block"},
+		"field":        {"type S struct{ F int }\n\tval x = S{}\n\tx.F = 2", "6:2: cannot assign to x.F: val x holds the value"},
+		"array elem":   {"type S struct{ A [2]int }\n\tval x = S{}\n\tx.A[0] = 9", "6:2: cannot assign to x.A[0]: val x holds the value"},
+		"field addr":   {"type S struct{ F int }\n\tval x = S{}\n\tp := &x.F\n\t_ = p", "6:8: cannot take the address of x.F: val x holds the value"},
+		"array slice":  {"val arr = [3]int{}\n\ts := arr[:]\n\ts[0] = 1", "5:7: cannot slice arr: val arr holds the value"},
+		"field slice":  {"type S struct{ A [2]int }\n\tval x = S{}\n\t_ = x.A[1:]", "6:6: cannot slice x.A: val x holds the value"},
+	}
+	for name, c := range cases {
+		src := "package main\n\nfunc main() {\n\t" + c.code + "\n}\n"
+		_, err := transpiler.TranspileFile(src)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, c.want, err)
+		}
+	}
+}
+
+// ─── comprehensions ───────────────────────────────────────────────────────────
+
+func TestTranspileFile_Comprehension_SliceWithFilter(t *testing.T) {
+	src := `package main
+
+type User struct {
+	Name   string
+	Active bool
+}
+
+func main() {
+	users := []User{{"ann", true}}
+	names := [u.Name for u in users if u.Active]
+	_ = names
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "names := make([]string, 0, len(users))")
+	assertContains(t, out, "for _, u := range users {")
+	assertContains(t, out, "if u.Active {")
+	assertContains(t, out, "names = append(names, u.Name)")
+	assertNotContains(t, out, "func() []string")
+}
+
+func TestTranspileFile_Comprehension_Map(t *testing.T) {
+	src := `package main
+
+type User struct {
+	ID   int
+	Name string
+}
+
+func main() {
+	users := []User{{1, "ann"}}
+	byID := {u.ID: u for u in users}
+	index := {name: i for i, name in [u.Name for u in users]}
+	_, _ = byID, index
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "byID := make(map[int]User, len(users))")
+	assertContains(t, out, "byID[u.ID] = u")
+	// Тип ключа выводится из результата вложенного генератора.
+	assertContains(t, out, "index := make(map[string]int)")
+	assertContains(t, out, "for i, name := range func() []string {")
+	assertContains(t, out, "_godslOut := make([]string, 0, len(users))")
+}
+
+func TestTranspileFile_Comprehension_RangeAndDependentTypes(t *testing.T) {
+	src := `package main
+
+func main() {
+	squares := [i * i for i in 1..5]
+	labels := [float64(s) / 2 for s in squares if s > 1]
+	_ = labels
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "squares := make([]int, 0, 5)")
+	assertContains(t, out, "for i := 1; i <= 5; i++ {")
+	assertContains(t, out, "labels := make([]float64, 0, len(squares))")
+}
+
+func TestTranspileFile_Comprehension_RangeWithVariableBounds(t *testing.T) {
+	src := `package main
+
+func f(lo, n int) {
+	xs := [i for i in 0..<n]
+	ys := [i for i in lo..n]
+	zs := [i for i in 0..<n step 2]
+	_, _, _ = xs, ys, zs
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "xs := make([]int, 0, max(n, 0))")
+	assertContains(t, out, "ys := make([]int, 0, max(n-lo+1, 0))")
+	assertContains(t, out, "var zs []int")
+}
+
+func TestTranspileFile_Comprehension_InExpressionUsesIIFE(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func f(xs []int) []int {
+	fmt.Println([x + 1 for x in xs])
+	xs = [x * 2 for x in xs]
+	return xs
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "fmt.Println(func() []int {")
+	// Генератор читает xs, поэтому xs нельзя перезаписать до цикла.
+	assertContains(t, out, "xs = func() []int {")
+	assertContains(t, out, "_godslOut = append(_godslOut, x*2)")
+}
+
+func TestTranspileFile_Comprehension_UnknownElementType_ReturnsError(t *testing.T) {
+	src := `package main
+
+func main() {
+	xs := []int{1}
+	ys := [undefined(x) for x in xs]
+	_ = ys
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "cannot infer element type of comprehension") {
+		t.Fatalf("expected element type error, got %v", err)
+	}
+}
+
+// ─── pipeline |> ──────────────────────────────────────────────────────────────
+
+func TestTranspileFile_Pipe_SequentialTemporaries(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+type Config struct{ Scale int }
+
+func parse(s string) string { return s }
+
+func transform(cfg Config, n int) int { return n * cfg.Scale }
+
+func run(input string, cfg Config) error {
+	result := input |> parse |> strconv.Atoi? |> transform(cfg, _)
+	_ = result
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslPipe := parse(input)")
+	assertContains(t, out, "_godslPipe2, err := strconv.Atoi(_godslPipe)")
+	assertContains(t, out, "if err != nil {\n\t\treturn err\n\t}")
+	assertContains(t, out, "result := transform(cfg, _godslPipe2)")
+}
+
+func TestTranspileFile_Pipe_ErrorOnlyStagePassesValueThrough(t *testing.T) {
+	src := `package main
+
+import "errors"
+
+func load() []byte { return nil }
+
+func check(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("empty")
+	}
+	return nil
+}
+
+func run() error {
+	n := load() |> check? |> len
+	load() |> check?
+	_ = n
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslPipe := load()")
+	assertContains(t, out, "if err := check(_godslPipe); err != nil {")
+	assertContains(t, out, "n := len(_godslPipe)")
+	assertContains(t, out, "if err := check(load()); err != nil {")
+}
+
+func TestTranspileFile_Pipe_LastStageWithQuestion(t *testing.T) {
+	src := `package main
+
+import "strconv"
+
+func run(s string) error {
+	n := s |> strconv.Atoi?
+	s |> strconv.Atoi?
+	_ = n
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "n, err := strconv.Atoi(s)")
+	assertContains(t, out, "if _, err := strconv.Atoi(s); err != nil {")
+}
+
+func TestTranspileFile_Pipe_InExpressionAndReturn(t *testing.T) {
+	src := `package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func label(n int) string {
+	return n |> strconv.Itoa |> strings.Repeat(_, 2)
+}
+
+func main() {
+	fmt.Println(3 |> label, "a" |> strings.ToUpper)
+	ok := false || true |> fmt.Sprint
+	_ = ok
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslPipe := strconv.Itoa(n)")
+	assertContains(t, out, "return strings.Repeat(_godslPipe, 2)")
+	assertContains(t, out, `fmt.Println(label(3), strings.ToUpper("a"))`)
+	assertContains(t, out, "ok := fmt.Sprint(false || true)")
+}
+
+func TestTranspileFile_Pipe_Errors(t *testing.T) {
+	cases := map[string]struct{ code, want string }{
+		"question in expression": {`_ = len("1" |> strconv.Atoi?)`, "? in a pipeline is only allowed"},
+		"two placeholders":       {`_ = "a" |> strings.Repeat(_, _)`, "_ placeholder only once"},
+	}
+	for name, c := range cases {
+		src := "package main\n\nimport (\n\t\"strconv\"\n\t\"strings\"\n)\n\nfunc main() {\n\t" + c.code + "\n}\n"
+		_, err := transpiler.TranspileFile(src)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, c.want, err)
+		}
+	}
+}
+
+// ─── ternary types from go/types ──────────────────────────────────────────────
+
+func TestTranspileFile_Ternary_TypeCheck_VariablesAndMixedConstants(t *testing.T) {
+	src := `package main
+
+type Point struct{ X, Y int }
+
+func main() {
+	c := true
+	a, b := Point{1, 2}, Point{3, 4}
+	p := c ? a : b
+	x := c ? 1 : 2.5
+	r := c ? 'a' : 1
+	_, _, _ = p.X, x/2, r
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var p Point")
+	assertContains(t, out, "var x float64")
+	assertContains(t, out, "var r rune")
+	assertNotContains(t, out, " any")
+}
+
+func TestTranspileFile_Ternary_TypeCheck_ContextTypeForConstants(t *testing.T) {
+	src := `package main
+
+func scale(f float64) float64 { return f * 2 }
+
+func ratio(c bool) (float64, error) {
+	return c ? 1 : 2, nil
+}
+
+func main() {
+	c := true
+	_ = scale(c ? 1 : 3)
+	_, _ = ratio(c)
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var _godslTern float64")
+	assertContains(t, out, "_ = scale(_godslTern)")
+	assertNotContains(t, out, "var _godslTern int")
+}
+
+func TestTranspileFile_Ternary_TypeCheck_CallArgumentIgnoresReturnType(t *testing.T) {
+	src := `package main
+
+type Named interface{ Name() string }
+
+type Dog struct{}
+
+func (Dog) Name() string { return "dog" }
+
+type Cat struct{}
+
+func (Cat) Name() string { return "cat" }
+
+func describe(n Named) string { return n.Name() }
+
+func count(c bool) int {
+	_ = describe(c ? Dog{} : Cat{})
+	return 0
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var _godslTern Named")
+	assertContains(t, out, "_ = describe(_godslTern)")
+}
+
+func TestTranspileFile_Ternary_TypeCheck_Generic(t *testing.T) {
+	src := `package main
+
+func pick[T any](c bool, a, b T) T {
+	v := c ? a : b
+	return v
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var v T")
+}
+
+func TestTranspileFile_Ternary_TypeCheck_PackageFiles(t *testing.T) {
+	src := `package main
+
+func main() {
+	c := true
+	u := c ? defaultUser() : guest
+	_ = u.Name
+}
+`
+	decls, err := transpiler.PackageDecls(`package main
+
+type User struct{ Name string }
+
+var guest = User{Name: "guest"}
+
+func defaultUser(name = "admin") User { return User{Name: name} }
+`)
+	if err != nil {
+		t.Fatalf("PackageDecls returned error: %v", err)
+	}
+	assertNotContains(t, decls, "return User")
+
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{
+		Package: map[string]string{"user.godsl": decls},
+	})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions returned error: %v", err)
+	}
+	assertContains(t, out, "var u User")
+
+	// Без соседних файлов тип неизвестен.
+	out = transpileOK(t, src)
+	assertContains(t, out, "var u any")
+}
+
+func TestTranspileFile_Ternary_TypeFromImportedProjectPackage(t *testing.T) {
+	src := `package main
+
+import "example.com/app/lib"
+
+func main() {
+	c := true
+	it := c ? lib.Get(1) : lib.Get(2)
+	n := lib.Count([]lib.Item{it}, x => x.N > 0)
+	m := lib.Count([]lib.Item{it}, (x lib.Item) => x.N > 0)
+	_, _ = n, m
+}
+`
+	decls, err := transpiler.PackageDecls(`package lib
+
+type Item struct{ N int }
+
+func Get(n int) Item { return Item{N: n} }
+
+func Count(xs []Item, keep func(Item) bool) int { return 0 }
+`)
+	if err != nil {
+		t.Fatalf("PackageDecls returned error: %v", err)
+	}
+
+	out, err := transpiler.TranspileFileWithOptions(src, transpiler.Options{
+		Imports: map[string]map[string]string{"example.com/app/lib": {"lib.godsl": decls}},
+	})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions returned error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, "var it lib.Item")
+	assertContains(t, out, "n := lib.Count([]lib.Item{it}, func(x lib.Item) bool { return x.N > 0 })")
+	assertContains(t, out, "m := lib.Count([]lib.Item{it}, func(x lib.Item) bool { return x.N > 0 })")
+}
+
+func TestTranspileFile_Ternary_UnresolvedPackageIsError(t *testing.T) {
+	src := `package main
+
+import "example.com/app/lib"
+
+func main() {
+	c := true
+	it := c ? lib.Get(1) : lib.Get(2)
+	_ = it.N
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "7:12: cannot infer type: package example.com/app/lib could not be loaded") {
+		t.Errorf("expected unresolved package error at the ternary, got %v", err)
+	}
+}
+
+// ─── ternary lowering to if/else ──────────────────────────────────────────────
+
+func TestTranspileFile_Ternary_Lowering_AssignAndReturn(t *testing.T) {
+	src := `package main
+
+func sign(n int) string {
+	return n > 0 ? "+" : n < 0 ? "-" : "0"
+}
+
+func main() {
+	c := true
+	x := c ? 1 : 2
+	x = c ? x + 1 : 0
+	_ = x
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "if n > 0 {\n\t\treturn \"+\"\n\t} else if n < 0 {\n\t\treturn \"-\"\n\t} else {\n\t\treturn \"0\"\n\t}")
+	assertContains(t, out, "var x int\n\tif c {\n\t\tx = 1\n\t} else {\n\t\tx = 2\n\t}")
+	assertContains(t, out, "_godslTern = x + 1")
+	assertContains(t, out, "x = _godslTern")
+	assertNotContains(t, out, "func()")
+}
+
+func TestTranspileFile_Ternary_Lowering_CallArguments(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func main() {
+	ok := true
+	fmt.Println("flag is", ok ? "on" : "off", len(ok ? "a" : "bb"))
+	ok ? fmt.Println("yes") : fmt.Println("no")
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var _godslTern string")
+	assertContains(t, out, "var _godslTern2 string")
+	assertContains(t, out, `fmt.Println("flag is", _godslTern, len(_godslTern2))`)
+	assertContains(t, out, "if ok {\n\t\tfmt.Println(\"yes\")\n\t} else {\n\t\tfmt.Println(\"no\")\n\t}")
+	assertNotContains(t, out, "func()")
+}
+
+func TestTranspileFile_Ternary_Lowering_KeepsEvaluationOrder(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func next() int { return 1 }
+
+func main() {
+	ok := true
+	fmt.Println(next(), ok ? "late" : "never")
+	m := map[string]int{}
+	m[fmt.Sprint(1)] = ok ? 1 : 2
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	// next() и ключ карты вычисляются до тернарного оператора.
+	assertContains(t, out, "fmt.Println(next(), func() string {")
+	assertContains(t, out, "m[fmt.Sprint(1)] = func() int {")
+	assertNotContains(t, out, "_godslTern")
+}
+
+func TestTranspileFile_Ternary_Lowering_ShadowedNameUsesTemporary(t *testing.T) {
+	src := `package main
+
+func main() {
+	c := true
+	y := 5
+	{
+		y := c ? y * 2 : 0
+		_ = y
+	}
+	_ = y
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "_godslTern = y * 2")
+	assertContains(t, out, "y := _godslTern")
+}
+
+func TestTranspileFile_Ternary_Lowering_FallbackIIFE(t *testing.T) {
+	src := `package main
+
+var limit = len("ab") > 1 ? 10 : 20
+
+func main() {
+	c := true
+	for i := 0; i < limit; i += c ? 1 : 2 {
+	}
+	if c {
+	} else {
+		_ = c ? "a" : "b"
+	}
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "var limit = func() int {")
+	assertContains(t, out, "i += func() int {")
+	assertContains(t, out, "var _godslTern string")
+	assertNotContains(t, out, "?")
+}
+
+// ─── catch type switch ───────────────────────────────────────────────────────
+
+func TestTranspileFile_CatchSwitch_WholeChain(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type ErrTimeout struct{}
+type ErrNetwork struct{}
+type ErrDisk struct{ Path string }
+
+func (ErrTimeout) Error() string { return "timeout" }
+func (ErrNetwork) Error() string { return "network" }
+func (ErrDisk) Error() string    { return "disk" }
+
+func foo() {
+	try {
+		@errcheck
+		_, err := bar()
+	} catch(ErrTimeout | ErrNetwork) {
+		fmt.Println("transient:", err)
+	} catch(e ErrDisk) {
+		fmt.Println("disk:", e.Path)
+	} catch {
+		fmt.Println("unexpected:", err)
+	}
+}
+
+func bar() (int, error) { return 0, nil }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "switch e := err.(type) {")
+	assertContains(t, out, "case ErrTimeout, ErrNetwork:")
+	assertContains(t, out, "case ErrDisk:\n\t\t\tfmt.Println(\"disk:\", e.Path)")
+	assertContains(t, out, "default:\n\t\t\tfmt.Println(\"unexpected:\", err)")
+	assertNotContains(t, out, "func() bool")
+	assertNotContains(t, out, "return err")
+}
+
+func TestTranspileFile_CatchSwitch_DifferentVarNames(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type ErrA struct{ N int }
+type ErrB struct{ S string }
+
+func (ErrA) Error() string { return "a" }
+func (ErrB) Error() string { return "b" }
+
+func foo() {
+	try {
+		@errcheck
+		_, err := bar()
+	} catch(a ErrA) {
+		fmt.Println(a.N)
+	} catch(b ErrB) {
+		fmt.Println(b.S)
+	}
+}
+
+func bar() (int, error) { return 0, nil }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "switch _godslCatch := err.(type) {")
+	assertContains(t, out, "a := _godslCatch")
+	assertContains(t, out, "b := _godslCatch")
+}
+
+func TestTranspileFile_CatchSwitch_KeepsOuterVariable(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type ErrA struct{}
+
+func (ErrA) Error() string { return "a" }
+
+func foo(e string) {
+	try {
+		@errcheck
+		_, err := bar()
+	} catch(e ErrA) {
+		fmt.Println("a:", e)
+	} catch {
+		fmt.Println(e, err)
+	}
+}
+
+func bar() (int, error) { return 0, nil }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	// The catch-all uses the parameter e, so the switch must not shadow it.
+	assertContains(t, out, "switch _godslCatch := err.(type) {")
+	assertContains(t, out, "e := _godslCatch")
+	assertContains(t, out, "fmt.Println(e, err)")
+}
+
+func TestTranspileFile_CatchSwitch_BreakFallsBackToIf(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type ErrA struct{}
+type ErrB struct{}
+
+func (ErrA) Error() string { return "a" }
+func (ErrB) Error() string { return "b" }
+
+func foo() {
+	for i := 0; i < 3; i++ {
+		try {
+			@errcheck
+			_, err := bar()
+		} catch(e ErrA | ErrB) {
+			fmt.Println(e)
+			break
+		}
+	}
+}
+
+func bar() (int, error) { return 0, nil }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	// break inside a switch would leave the switch, not the loop.
+	assertNotContains(t, out, "switch")
+	assertContains(t, out, "if _, ok := err.(ErrA); ok {")
+	assertContains(t, out, "} else if _, ok := err.(ErrB); ok {")
+	assertContains(t, out, "e := err")
+}
+
+func TestTranspileFile_CatchSwitch_Finally(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+type ErrA struct{}
+type ErrB struct{}
+
+func (ErrA) Error() string { return "a" }
+func (ErrB) Error() string { return "b" }
+
+func foo() {
+	try {
+		@errcheck
+		_, err := bar()
+	} catch(ErrA) {
+		fmt.Println("a")
+		return
+	} catch(ErrB) {
+		fmt.Println("b")
+	} finally {
+		fmt.Println("cleanup")
+	}
+}
+
+func bar() (int, error) { return 0, nil }
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "switch err.(type) {")
+	assertContains(t, out, "case ErrA:\n\t\t\t\tfmt.Println(\"a\")\n\t\t\t\treturn true\n\t\t\tcase ErrB:")
+	assertContains(t, out, `fmt.Println("b")`)
+}
+
+// ─── ? on result.Result / result.Option ──────────────────────────────────────
+
+const resultSrc = `package main
+
+import (
+	"strconv"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/result"
+)
+
+func parse(s string) result.Result[int] { return result.Of(strconv.Atoi(s)) }
+
+func lookup(m map[string]int, k string) result.Option[int] {
+	v, ok := m[k]
+	return result.OptionOf(v, ok)
+}
+
+func run(s string, m map[string]int, ch <-chan result.Result[int], rs []result.Result[string]) error {
+	n := parse(s)?
+	parse("2")?
+	x := lookup(m, "x")?
+	lookup(m, "y")?
+	v := <-ch?
+	first := rs[0]?
+	k := strconv.Atoi(s)?
+	_, _, _, _, _ = n, x, v, first, k
+	return nil
+}
+`
+
+func TestTranspileFile_Result_Question(t *testing.T) {
+	out := transpileOK(t, resultSrc)
+	assertValidGo(t, out)
+	assertContains(t, out, "n, err := parse(s).Get()\n\tif err != nil {\n\t\treturn err\n\t}")
+	assertContains(t, out, "if err := parse(\"2\").Err(); err != nil {")
+	assertContains(t, out, "x, _godslOk := lookup(m, \"x\").Get()\n\tif !_godslOk {\n\t\treturn result.ErrNone\n\t}")
+	assertContains(t, out, "if !lookup(m, \"y\").IsSome() {")
+	assertContains(t, out, "v, err := (<-ch).Get()")
+	assertContains(t, out, "first, err := rs[0].Get()")
+	// Вызов, возвращающий (T, error), разворачивается как раньше.
+	assertContains(t, out, "k, err := strconv.Atoi(s)")
+	assertNotContains(t, out, "_godslProbe")
+}
+
+func TestTranspileFile_Result_TraceErrors(t *testing.T) {
+	out, err := transpiler.TranspileFileWithOptions(resultSrc, transpiler.Options{
+		Filename:    "main.godsl",
+		TraceErrors: true,
+	})
+	if err != nil {
+		t.Fatalf("TranspileFileWithOptions error: %v", err)
+	}
+	assertValidGo(t, out)
+	assertContains(t, out, `return errtrace.Wrap(result.ErrNone, "main.godsl:20", "run")`)
+	assertContains(t, out, `return errtrace.Wrap(err, "main.godsl:18", "run")`)
+}
+
+func TestTranspileFile_Result_InsideRetry(t *testing.T) {
+	src := `package main
+
+import "github.com/sviridovkonstantin42/godsl/runtime/result"
+
+func load() result.Option[string] { return result.None[string]() }
+
+func run() error {
+	retry 3 {
+		s := load()?
+		_ = s
+	}
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "s, _godslOk := load().Get()")
+	assertContains(t, out, "_godslErr = result.ErrNone")
+}
+
+func TestTranspileFile_Result_OptionKeepsUserOk(t *testing.T) {
+	src := `package main
+
+import "github.com/sviridovkonstantin42/godsl/runtime/result"
+
+func load() result.Option[int] { return result.Some(1) }
+
+func run(m map[string]int) error {
+	n, ok := m["a"]
+	x := load()?
+	n = load()?
+	_, _, _ = n, ok, x
+	return nil
+}
+`
+	out := transpileOK(t, src)
+	assertValidGo(t, out)
+	assertContains(t, out, "x, _godslOk := load().Get()")
+	assertContains(t, out, "if _godslVal, _godslOk := load().Get(); !_godslOk {")
+	assertContains(t, out, "} else {\n\t\tn = _godslVal\n\t}")
+	assertNotContains(t, out, "n, ok = load().Get()")
+}
+
+func TestTranspileFile_Result_AssignsOneValue(t *testing.T) {
+	src := `package main
+
+import "github.com/sviridovkonstantin42/godsl/runtime/result"
+
+func load() result.Result[int] { return result.Ok(1) }
+
+func run() error {
+	a, b := load()?
+	_, _ = a, b
+	return nil
+}
+`
+	_, err := transpiler.TranspileFile(src)
+	if err == nil || !strings.Contains(err.Error(), "? on result.Result[int] assigns exactly one value, got 2") {
+		t.Errorf("expected error about assigning one value, got %v", err)
+	}
 }
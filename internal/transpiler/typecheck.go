@@ -0,0 +1,673 @@
+package transpiler
+
+import (
+	"bytes"
+	"fmt"
+	goast "go/ast"
+	goimporter "go/importer"
+	goparser "go/parser"
+	gotoken "go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/format"
+	"github.com/sviridovkonstantin42/godsl/internal/parser"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+	godslruntime "github.com/sviridovkonstantin42/godsl/runtime"
+)
+
+// probeFuncName — функция-маркер пробного прохода. Выражения, типы которых
+// нужны транспилятору, оборачиваются в _godslProbe(id, expr); после
+// проверки go/types тип аргумента выражения становится известен.
+const probeFuncName = "_godslProbe"
+
+// probeTernaryName — функция, которой пробный проход заменяет тернарный
+// оператор: тип её результата — общий тип веток.
+const probeTernaryName = "_godslTernary"
+
+// probeValueName — функция пробного прохода, выделяющая значение из
+// результата (T, error): _godslValue(f()) имеет тип T.
+const probeValueName = "_godslValue"
+
+// probeAsyncName — функция пробного прохода, превращающая результат
+// (T, error) вызова в func() (T, error) для async.Call0.
+const probeAsyncName = "_godslAsync"
+
+// Импортёр go/types кэширует загруженные пакеты между файлами; generate
+// транспилирует файлы параллельно, поэтому проверка типов сериализуется.
+var (
+	typecheckMu       sync.Mutex
+	typecheckImporter types.Importer
+)
+
+// runtimePathPrefix — префикс путей импорта рантайм-пакетов godsl.
+const runtimePathPrefix = "github.com/sviridovkonstantin42/godsl/runtime/"
+
+// runtimeImporter загружает рантайм-пакеты godsl из встроенных исходников
+// (importer "gc" их не находит), остальные пакеты — через base.
+type runtimeImporter struct {
+	base types.Importer
+	pkgs map[string]*types.Package
+}
+
+// Import реализует types.Importer.
+func (imp *runtimeImporter) Import(importPath string) (*types.Package, error) {
+	dir, ok := strings.CutPrefix(importPath, runtimePathPrefix)
+	if !ok {
+		return imp.base.Import(importPath)
+	}
+	if pkg, ok := imp.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	names, err := fs.Glob(godslruntime.Sources, dir+"/*.go")
+	if err != nil || len(names) == 0 {
+		return imp.base.Import(importPath)
+	}
+	fset := gotoken.NewFileSet()
+	var files []*goast.File
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := godslruntime.Sources.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		f, err := goparser.ParseFile(fset, path.Base(name), src, goparser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: imp}
+	pkg, err := conf.Check(importPath, fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+	imp.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+// sourceImporters — импортёры из исходников по директориям файлов: они
+// загружают зависимости модуля и кэшируют их между файлами.
+var sourceImporters = map[string]types.ImporterFrom{}
+
+// projectImporter загружает пакеты для пробного прохода: пакеты проекта —
+// из объявлений Options.Imports, стандартную библиотеку и рантайм godsl —
+// через base, остальные зависимости модуля — из исходников относительно
+// dir. Пакеты, которые загрузить не удалось, запоминаются в failed.
+type projectImporter struct {
+	base   types.Importer
+	decls  map[string]map[string]string
+	dir    string
+	pkgs   map[string]*types.Package
+	failed map[string]error
+}
+
+func newProjectImporter(base types.Importer, decls map[string]map[string]string, dir string) *projectImporter {
+	return &projectImporter{
+		base:   base,
+		decls:  decls,
+		dir:    dir,
+		pkgs:   map[string]*types.Package{},
+		failed: map[string]error{},
+	}
+}
+
+// Import реализует types.Importer.
+func (imp *projectImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+	if err, ok := imp.failed[importPath]; ok {
+		return nil, err
+	}
+	// Пока пакет загружается, повторный импорт — это цикл.
+	imp.failed[importPath] = fmt.Errorf("import cycle through %s", importPath)
+	pkg, err := imp.load(importPath)
+	delete(imp.failed, importPath)
+	if err != nil {
+		imp.failed[importPath] = err
+		return nil, err
+	}
+	imp.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+func (imp *projectImporter) load(importPath string) (*types.Package, error) {
+	if files, ok := imp.decls[importPath]; ok {
+		return imp.check(importPath, files)
+	}
+	pkg, err := imp.base.Import(importPath)
+	if err == nil || imp.dir == "" {
+		return pkg, err
+	}
+	src, ok := sourceImporters[imp.dir]
+	if !ok {
+		src = goimporter.ForCompiler(gotoken.NewFileSet(), "source", nil).(types.ImporterFrom)
+		sourceImporters[imp.dir] = src
+	}
+	return src.ImportFrom(importPath, imp.dir, 0)
+}
+
+// check проверяет пакет проекта по объявлениям его файлов. Ошибки в
+// объявлениях не мешают: go/types возвращает пакет с тем, что удалось
+// проверить.
+func (imp *projectImporter) check(importPath string, decls map[string]string) (*types.Package, error) {
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fset := gotoken.NewFileSet()
+	var files []*goast.File
+	for _, name := range names {
+		f, err := goparser.ParseFile(fset, name, decls[name], goparser.SkipObjectResolution)
+		if err != nil || len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go declarations in package %s", importPath)
+	}
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	pkg, _ := conf.Check(importPath, fset, files, nil)
+	return pkg, nil
+}
+
+// requireResolved сообщает об ошибке, если node ссылается на пакет,
+// который пробный проход не смог загрузить: без него тип node неизвестен,
+// а запасной any сломал бы сгенерированный код.
+func (t *Transpiler) requireResolved(node ast.Node) {
+	if t.importer == nil || len(t.importer.failed) == 0 {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok {
+			for p, err := range t.importer.failed {
+				if t.importName(p) == x.Name {
+					t.errorf(sel.Pos(), "cannot infer type: package %s could not be loaded: %v", p, err)
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
+
+// needsTypeCheck сообщает, нужен ли файлу пробный проход go/types:
+// он нужен опциональным цепочкам, guard с присваиванием, match,
+// if/switch-выражениям, record (сравнимость полей для Equal), лямбдам
+// циклам for ... in и генераторам (типы границ диапазона, коллекций и
+// элементов), стадиям конвейера с ?, тернарным операторам и ? (Result и
+// Option отличаются от вызовов, возвращающих (T, error), только типом).
+func needsTypeCheck(file *ast.File) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.OptSelectorExpr, *ast.MatchExpr, *ast.StmtExpr, *ast.RecordDecl, *ast.LambdaExpr, *ast.InClause, *ast.TernaryExpr, *ast.CoalesceExpr, *ast.FallbackExpr,
+			*ast.AsyncExpr, *ast.AwaitExpr:
+			found = true
+		case *ast.GuardStmt:
+			// тип проверяемой переменной, операндов сравнения или функции в else
+			found = true
+		case *ast.AssertStmt:
+			found = isOrderedCompare(n.Cond)
+		case *ast.ContractClause:
+			found = isOrderedCompare(n.Cond)
+		case *ast.QuestionStmt:
+			// ? для result.Result и result.Option
+			found = true
+		case *ast.PipeExpr:
+			// число результатов стадии с ?
+			if n.Question.IsValid() {
+				found = true
+			}
+		case *ast.Field:
+			// тип параметра выводится из значения по умолчанию
+			if n.Default != nil && n.Type == nil {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// probe регистрирует выражение, тип которого нужен транспилятору.
+// В пробном проходе возвращает _godslProbe(id, expr), иначе — expr.
+func (t *Transpiler) probe(node ast.Node, expr ast.Expr) ast.Expr {
+	if !t.probing {
+		return expr
+	}
+	id := len(t.probes)
+	t.probes = append(t.probes, node)
+	return &ast.CallExpr{
+		Fun: &ast.Ident{NamePos: token.NoPos, Name: probeFuncName},
+		Args: []ast.Expr{
+			&ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: strconv.Itoa(id)},
+			expr,
+		},
+	}
+}
+
+// typeOf возвращает тип узла, выведенный пробным проходом, или nil.
+func (t *Transpiler) typeOf(node ast.Node) types.Type {
+	return t.exprTypes[node]
+}
+
+// maxProbePasses ограничивает число повторных пробных проходов для
+// генераторов, зависящих друг от друга.
+const maxProbePasses = 4
+
+// checkTypes выполняет пробный проход и, если в файле есть генераторы,
+// повторяет его. В первом проходе результат генератора имеет тип []any или
+// map[any]any, поэтому генератор по результату другого генератора видит
+// any; следующие проходы подставляют типы предыдущего, пока они не
+// перестанут меняться.
+func (t *Transpiler) checkTypes(file *ast.File) {
+	t.probeTypes(file)
+	if !hasComprehension(file) {
+		return
+	}
+	for pass := 1; pass < maxProbePasses; pass++ {
+		prev := t.exprTypes
+		t.prevTypes = prev
+		t.probeTypes(file)
+		if sameTypes(prev, t.exprTypes) {
+			break
+		}
+	}
+	t.prevTypes = nil
+}
+
+// hasComprehension сообщает, есть ли в файле генераторы среза или мапы.
+func hasComprehension(file *ast.File) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if _, ok := n.(*ast.ComprehensionExpr); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// sameTypes сообщает, совпадают ли типы двух пробных проходов. Типы
+// разных проверок go/types сравниваются по записи с полными путями пакетов.
+func sameTypes(a, b map[ast.Node]types.Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for node, typ := range a {
+		other, ok := b[node]
+		if !ok || types.TypeString(typ, nil) != types.TypeString(other, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// probeTypes выполняет пробный проход: транспилирует файл, оборачивая
+// интересующие выражения маркером, и проверяет результат через go/types.
+// Другие файлы пакета из Options.Package проверяются вместе с ним, а
+// импортируемые пакеты загружает projectImporter. Ошибки проверки
+// (например, ссылки на файлы пакета, которые не переданы) игнорируются:
+// для таких выражений тип остаётся неизвестным и транспилятор использует
+// запасной вариант без типов или, если причина — незагруженный пакет,
+// сообщает ошибку (requireResolved).
+func (t *Transpiler) probeTypes(file *ast.File) {
+	// Ошибки проверок, выполненных до пробного прохода, сохраняются.
+	errs := t.errs
+	t.probing = true
+	probeFile := t.transpileFile(file)
+	// addImports дописывает импорты в GenDecl, общий с исходным файлом,
+	// поэтому пробному файлу нужна своя копия.
+	probeFile.Imports = append([]*ast.ImportSpec(nil), probeFile.Imports...)
+	for i, decl := range probeFile.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			cp := *gen
+			cp.Specs = append([]ast.Spec(nil), gen.Specs...)
+			probeFile.Decls[i] = &cp
+		}
+	}
+	t.addImports(probeFile)
+
+	var buf bytes.Buffer
+	err := format.Node(&buf, t.fset, probeFile)
+	probes := t.probes
+
+	// Сбрасываем состояние перед основным проходом.
+	t.probing, t.probes, t.errs = false, nil, errs
+	t.imports = make(map[string]string)
+	if err != nil {
+		return
+	}
+	buf.WriteString(strings.Join(t.declCode, ""))
+	buf.WriteString("\nfunc " + probeFuncName + "[T any](id int, v T) T { return v }\n")
+	buf.WriteString("\nfunc " + probeTernaryName + "[T any](cond bool, a, b T) T { return a }\n")
+	buf.WriteString("\nfunc " + probeValueName + "[T any](v T, err error) T { return v }\n")
+	buf.WriteString("\nfunc " + probeAsyncName + "[T any](v T, err error) func() (T, error) { return nil }\n")
+
+	fset := gotoken.NewFileSet()
+	f, _ := goparser.ParseFile(fset, "probe.go", buf.Bytes(), 0)
+	if f == nil {
+		return
+	}
+	files := append([]*goast.File{f}, t.packageFiles(fset, f.Name.Name)...)
+
+	info := &types.Info{
+		Types: make(map[goast.Expr]types.TypeAndValue),
+		Uses:  make(map[*goast.Ident]types.Object),
+	}
+	typecheckMu.Lock()
+	if typecheckImporter == nil {
+		typecheckImporter = &runtimeImporter{
+			base: goimporter.ForCompiler(gotoken.NewFileSet(), "gc", nil),
+			pkgs: map[string]*types.Package{},
+		}
+	}
+	if t.importer == nil {
+		t.importer = newProjectImporter(typecheckImporter, t.opts.Imports, t.opts.Dir)
+	}
+	conf := types.Config{Importer: t.importer, Error: func(error) {}}
+	t.typesPkg, _ = conf.Check(f.Name.Name, fset, files, info)
+	typecheckMu.Unlock()
+
+	t.exprTypes = make(map[ast.Node]types.Type)
+	t.callees = make(map[ast.Node]types.Object)
+	t.untypedExprs = make(map[ast.Node]bool)
+	goast.Inspect(f, func(n goast.Node) bool {
+		call, ok := n.(*goast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		if fun, ok := call.Fun.(*goast.Ident); !ok || fun.Name != probeFuncName {
+			return true
+		}
+		lit, ok := call.Args[0].(*goast.BasicLit)
+		if !ok {
+			return true
+		}
+		id, err := strconv.Atoi(lit.Value)
+		if err != nil || id >= len(probes) {
+			return true
+		}
+		arg := call.Args[1]
+		if _, ok := probes[id].(terminatorProbe); ok {
+			switch fun := arg.(type) {
+			case *goast.Ident:
+				t.callees[probes[id]] = info.Uses[fun]
+			case *goast.SelectorExpr:
+				t.callees[probes[id]] = info.Uses[fun.Sel]
+			}
+			return true
+		}
+		// Для вызова с лямбдой нужна сигнатура вызываемой функции.
+		if _, ok := probes[id].(calleeProbe); ok {
+			inner, ok := arg.(*goast.CallExpr)
+			if !ok {
+				return true
+			}
+			arg = inner.Fun
+		}
+		if tv, ok := info.Types[arg]; ok && tv.Type != nil && tv.Type != types.Typ[types.Invalid] {
+			t.exprTypes[probes[id]] = tv.Type
+		}
+		if _, ok := probes[id].(*ast.TernaryExpr); ok {
+			t.untypedExprs[probes[id]] = untypedBranches(info, arg)
+		} else if isUntypedConst(info, arg) {
+			t.untypedExprs[probes[id]] = true
+		}
+		return true
+	})
+}
+
+// untypedBranches сообщает, что обе ветки тернарного оператора в пробном
+// файле — константы: их тип выведен по умолчанию и может быть уточнён
+// контекстом.
+func untypedBranches(info *types.Info, call goast.Expr) bool {
+	ternary, ok := call.(*goast.CallExpr)
+	if !ok || len(ternary.Args) != 3 {
+		return false
+	}
+	for _, branch := range ternary.Args[1:] {
+		if tv, ok := info.Types[branch]; !ok || tv.Value == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// isUntypedConst сообщает, что выражение пробного прохода — константа
+// типа по умолчанию своего вида (int, rune, float64, complex128, string,
+// bool). go/types записывает нетипизированной константе-аргументу
+// выведенный тип, поэтому такая константа считается нетипизированной.
+func isUntypedConst(info *types.Info, expr goast.Expr) bool {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil {
+		return false
+	}
+	basic, ok := tv.Type.(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch basic.Kind() {
+	case types.Int, types.Int32, types.Float64, types.Complex128, types.String, types.Bool:
+		return true
+	}
+	return false
+}
+
+// PackageDecls возвращает объявления файла .godsl на Go для
+// Options.Package других файлов того же пакета. Тела функций заменяются
+// пустыми: соседним файлам нужны только типы, сигнатуры и переменные.
+func PackageDecls(source string) (string, error) {
+	t := NewTranspiler()
+	file, err := parser.ParseFile(t.fset, "", source, 0)
+	if err != nil {
+		return "", fmt.Errorf("parse error: %v", err)
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			fn.Body = &ast.BlockStmt{Lbrace: fn.Body.Lbrace, Rbrace: fn.Body.Rbrace}
+			fn.Contracts = nil
+		}
+	}
+	return t.transpileParsed(file)
+}
+
+// packageFiles разбирает другие файлы пакета из Options.Package для
+// проверки типов вместе с пробным файлом. Тела функций отбрасываются:
+// нужны только объявления. Файлы другого пакета (например, внешние
+// тесты pkg_test) и файлы с ошибками разбора пропускаются.
+func (t *Transpiler) packageFiles(fset *gotoken.FileSet, pkg string) []*goast.File {
+	names := make([]string, 0, len(t.opts.Package))
+	for name := range t.opts.Package {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []*goast.File
+	for _, name := range names {
+		f, err := goparser.ParseFile(fset, name, t.opts.Package[name], goparser.SkipObjectResolution)
+		if err != nil || f.Name.Name != pkg {
+			continue
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*goast.FuncDecl); ok {
+				fn.Body = nil
+			}
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// typeExpr возвращает выражение типа для сгенерированного кода или nil,
+// если тип нельзя записать в этом файле (пакет типа не импортирован).
+func (t *Transpiler) typeExpr(typ types.Type) ast.Expr {
+	if typ == nil {
+		return nil
+	}
+	ok := true
+	qualifier := func(pkg *types.Package) string {
+		if pkg == t.typesPkg {
+			return ""
+		}
+		name, imported := t.importNames[pkg.Path()]
+		if !imported || name == "_" {
+			ok = false
+			return pkg.Name()
+		}
+		if name == "" {
+			return pkg.Name()
+		}
+		if name == "." {
+			return ""
+		}
+		return name
+	}
+	s := types.TypeString(typ, qualifier)
+	if !ok || strings.HasPrefix(s, "untyped ") {
+		return nil
+	}
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil
+	}
+	clearPositions(expr)
+	return expr
+}
+
+// zeroValue возвращает нулевое значение типа: "", 0, false, nil или T{}.
+// Возвращает nil, если тип неизвестен.
+func (t *Transpiler) zeroValue(typ types.Type) ast.Expr {
+	if typ == nil {
+		return nil
+	}
+	if _, ok := typ.(*types.TypeParam); ok {
+		if texpr := t.typeExpr(typ); texpr != nil {
+			return zeroOfTypeExpr(texpr)
+		}
+		return nil
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return &ast.BasicLit{ValuePos: token.NoPos, Kind: token.STRING, Value: `""`}
+		case u.Info()&types.IsBoolean != 0:
+			return &ast.Ident{NamePos: token.NoPos, Name: "false"}
+		case u.Info()&types.IsNumeric != 0:
+			return &ast.BasicLit{ValuePos: token.NoPos, Kind: token.INT, Value: "0"}
+		case u.Kind() == types.UnsafePointer:
+			return &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return &ast.Ident{NamePos: token.NoPos, Name: "nil"}
+	case *types.Struct, *types.Array:
+		if texpr := t.typeExpr(typ); texpr != nil {
+			return &ast.CompositeLit{Type: texpr}
+		}
+	}
+	return nil
+}
+
+// zeroOfTypeExpr возвращает *new(T) — нулевое значение произвольного типа T.
+func zeroOfTypeExpr(typ ast.Expr) ast.Expr {
+	return &ast.StarExpr{Star: token.NoPos, X: &ast.CallExpr{
+		Fun:  &ast.Ident{NamePos: token.NoPos, Name: "new"},
+		Args: []ast.Expr{typ},
+	}}
+}
+
+// isNilable сообщает, можно ли сравнить значение типа с nil.
+func isNilable(typ types.Type) bool {
+	if _, ok := typ.(*types.TypeParam); ok {
+		return false
+	}
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// collectImportNames возвращает имена, под которыми в файле импортированы
+// пакеты: путь → явное имя ("" для импорта без имени).
+func collectImportNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[path] = name
+	}
+	return names
+}
+
+// clearPositions обнуляет позиции узлов выражения, разобранного отдельно
+// от исходного файла, чтобы printer не переносил строки по чужим позициям.
+func clearPositions(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			n.NamePos = token.NoPos
+		case *ast.BasicLit:
+			n.ValuePos = token.NoPos
+		case *ast.StarExpr:
+			n.Star = token.NoPos
+		case *ast.ParenExpr:
+			n.Lparen, n.Rparen = token.NoPos, token.NoPos
+		case *ast.IndexExpr:
+			n.Lbrack, n.Rbrack = token.NoPos, token.NoPos
+		case *ast.IndexListExpr:
+			n.Lbrack, n.Rbrack = token.NoPos, token.NoPos
+		case *ast.ArrayType:
+			n.Lbrack = token.NoPos
+		case *ast.MapType:
+			n.Map = token.NoPos
+		case *ast.ChanType:
+			n.Begin, n.Arrow = token.NoPos, token.NoPos
+		case *ast.FuncType:
+			n.Func = token.NoPos
+		case *ast.StructType:
+			n.Struct = token.NoPos
+		case *ast.InterfaceType:
+			n.Interface = token.NoPos
+		case *ast.FieldList:
+			n.Opening, n.Closing = token.NoPos, token.NoPos
+		case *ast.Ellipsis:
+			n.Ellipsis = token.NoPos
+		case *ast.UnaryExpr:
+			n.OpPos = token.NoPos
+		case *ast.BinaryExpr:
+			n.OpPos = token.NoPos
+		case *ast.CallExpr:
+			n.Lparen, n.Ellipsis, n.Rparen = token.NoPos, token.NoPos, token.NoPos
+		case *ast.CompositeLit:
+			n.Lbrace, n.Rbrace = token.NoPos, token.NoPos
+		case *ast.KeyValueExpr:
+			n.Colon = token.NoPos
+		}
+		return true
+	})
+}
@@ -0,0 +1,248 @@
+package transpiler
+
+import (
+	"go/types"
+
+	"github.com/sviridovkonstantin42/godsl/internal/ast"
+	"github.com/sviridovkonstantin42/godsl/internal/token"
+)
+
+// checkVals проверяет, что переменные, объявленные через val, не
+// изменяются: присваивание (в том числе повторное объявление через := и
+// for ... = range), ++/--, взятие адреса и срез массива запрещены. Проверка опирается на
+// разрешение имён парсера: Obj.Decl идентификатора val — его AssignStmt с
+// Tok == VAL. После проверки val становится обычным :=:
+//
+//	val limit = computeLimit()  →  limit := computeLimit()
+//
+// val защищает саму переменную, а не значение, на которое она ссылается:
+// элементы среза и поля по указателю менять можно. Изменения полей и
+// элементов массива, хранящихся в самой переменной, и вызовы методов с
+// получателем-указателем проверяет checkValMutations по типам go/types.
+func (t *Transpiler) checkVals(file *ast.File) {
+	t.valMutations = nil
+	var vals []*ast.AssignStmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.VAL {
+				vals = append(vals, n)
+			}
+			for _, lhs := range n.Lhs {
+				t.addValMutation(lhs, "assign to")
+				id, ok := lhs.(*ast.Ident)
+				if !ok || id.Obj == nil || id.Obj.Decl == n {
+					continue
+				}
+				switch {
+				case isValIdent(id):
+					t.errorf(id.Pos(), "cannot assign to val %s", id.Name)
+				case n.Tok == token.VAL:
+					// val x, y = ... не может переиспользовать существующую x.
+					t.errorf(id.Pos(), "%s redeclared in this block", id.Name)
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				for _, x := range []ast.Expr{n.Key, n.Value} {
+					if id, ok := valIdent(x); ok {
+						t.errorf(id.Pos(), "cannot assign to val %s", id.Name)
+					}
+				}
+			}
+		case *ast.IncDecStmt:
+			op := "increment"
+			if n.Tok == token.DEC {
+				op = "decrement"
+			}
+			if id, ok := valIdent(n.X); ok {
+				t.errorf(id.Pos(), "cannot %s val %s", op, id.Name)
+			}
+			t.addValMutation(n.X, op)
+		case *ast.UnaryExpr:
+			if n.Op != token.AND {
+				break
+			}
+			if id, ok := valIdent(n.X); ok {
+				t.errorf(id.Pos(), "cannot take the address of val %s", id.Name)
+			}
+			t.addValMutation(n.X, "take the address of")
+		case *ast.SliceExpr:
+			// arr[:] у массива в переменной берёт его адрес
+			if root := valPathRoot(n.X); root != nil {
+				t.valMutations = append(t.valMutations, valMutation{root: root, expr: n.X, op: "slice"})
+			}
+		case *ast.SelectorExpr:
+			// x.Inc() с получателем-указателем берёт адрес x
+			t.addValMutation(n, "")
+		}
+		return true
+	})
+	for _, s := range vals {
+		s.Tok = token.DEFINE
+	}
+}
+
+// valIdent возвращает идентификатор, если выражение — имя переменной,
+// объявленной через val.
+func valIdent(x ast.Expr) (*ast.Ident, bool) {
+	id, ok := ast.Unparen(x).(*ast.Ident)
+	return id, ok && isValIdent(id)
+}
+
+// isValIdent сообщает, объявлен ли идентификатор через val.
+func isValIdent(id *ast.Ident) bool {
+	if id.Obj == nil {
+		return false
+	}
+	decl, ok := id.Obj.Decl.(*ast.AssignStmt)
+	return ok && decl.Tok == token.VAL
+}
+
+// valMutation — изменение части val-переменной: поля, элемента массива или
+// вызов метода (op == ""). Допустимо ли оно, зависит от типов, поэтому
+// проверка откладывается до пробного прохода.
+type valMutation struct {
+	root *ast.Ident // имя val-переменной
+	expr ast.Expr   // изменяемое выражение или селектор метода
+	op   string     // assign to, increment, decrement, take the address of, slice
+}
+
+// addValMutation запоминает изменение, если выражение — поле или элемент
+// val-переменной (x.F, x.A[0], x.In.N), а не сама переменная.
+func (t *Transpiler) addValMutation(x ast.Expr, op string) {
+	if _, ok := ast.Unparen(x).(*ast.Ident); ok {
+		return
+	}
+	if root := valPathRoot(x); root != nil {
+		t.valMutations = append(t.valMutations, valMutation{root: root, expr: x, op: op})
+	}
+}
+
+// valPathRoot возвращает val-переменную, с которой начинается цепочка
+// селекторов и индексов выражения, или nil.
+func valPathRoot(x ast.Expr) *ast.Ident {
+	switch x := ast.Unparen(x).(type) {
+	case *ast.Ident:
+		if isValIdent(x) {
+			return x
+		}
+	case *ast.SelectorExpr:
+		return valPathRoot(x.X)
+	case *ast.IndexExpr:
+		return valPathRoot(x.X)
+	}
+	return nil
+}
+
+// probeValRoots строит для пробного прохода операторы _godslProbe(id, x)
+// для val-переменных, изменения частей которых содержит stmt: так
+// checkValMutations узнаёт их типы. Вложенные блоки обрабатываются при
+// транспиляции своих операторов.
+func (t *Transpiler) probeValRoots(stmt ast.Stmt) []ast.Stmt {
+	if len(t.valMutations) == 0 {
+		return nil
+	}
+	roots := make(map[*ast.Ident]bool, len(t.valMutations))
+	for _, m := range t.valMutations {
+		roots[m.root] = true
+	}
+	var probes []ast.Stmt
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt, *ast.FuncLit, *ast.CaseClause, *ast.CommClause:
+			return false
+		case *ast.Ident:
+			if roots[n] {
+				probes = append(probes, &ast.ExprStmt{X: t.probe(n, &ast.Ident{NamePos: token.NoPos, Name: n.Name})})
+			}
+		}
+		return true
+	})
+	return probes
+}
+
+// checkValMutations сообщает об изменениях val-переменных через их
+// значение: присваивание полю структуры или элементу массива, хранящимся
+// в переменной, срез такого массива и вызов метода с получателем-указателем. Через указатель,
+// срез или карту менять можно. Если тип переменной неизвестен, изменение
+// пропускается.
+func (t *Transpiler) checkValMutations() {
+	for _, m := range t.valMutations {
+		if t.typeOf(m.root) == nil {
+			continue
+		}
+		if m.op == "" {
+			sel := m.expr.(*ast.SelectorExpr)
+			recv, ok := t.valPathType(sel.X)
+			if !ok {
+				continue
+			}
+			if obj, _, indirect := types.LookupFieldOrMethod(recv, false, typePkg(recv), sel.Sel.Name); obj == nil && indirect {
+				t.errorf(sel.Pos(), "cannot call pointer method %s on val %s", sel.Sel.Name, m.root.Name)
+			}
+			continue
+		}
+		typ, ok := t.valPathType(m.expr)
+		if !ok {
+			continue
+		}
+		// Срез строки копирует байты и не ссылается на переменную.
+		if _, isArray := typ.Underlying().(*types.Array); m.op == "slice" && !isArray {
+			continue
+		}
+		t.errorf(m.expr.Pos(), "cannot %s %s: val %s holds the value", m.op, t.sourceText(m.expr), m.root.Name)
+	}
+}
+
+// valPathType возвращает тип выражения-цепочки от val-переменной и
+// сообщает, хранится ли оно в самой переменной: путь не проходит через
+// указатель, срез или карту.
+func (t *Transpiler) valPathType(x ast.Expr) (types.Type, bool) {
+	switch x := ast.Unparen(x).(type) {
+	case *ast.Ident:
+		typ := t.typeOf(x)
+		return typ, typ != nil && !isReference(typ)
+	case *ast.SelectorExpr:
+		typ, ok := t.valPathType(x.X)
+		if !ok {
+			return nil, false
+		}
+		field, _, indirect := types.LookupFieldOrMethod(typ, false, typePkg(typ), x.Sel.Name)
+		v, isField := field.(*types.Var)
+		if !isField || indirect || isReference(v.Type()) {
+			return nil, false
+		}
+		return v.Type(), true
+	case *ast.IndexExpr:
+		typ, ok := t.valPathType(x.X)
+		if !ok {
+			return nil, false
+		}
+		arr, isArray := typ.Underlying().(*types.Array)
+		if !isArray {
+			return nil, false
+		}
+		return arr.Elem(), true
+	}
+	return nil, false
+}
+
+// isReference сообщает, ссылается ли значение типа на данные вне самой
+// переменной, так что изменения через него не меняют переменную.
+func isReference(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Interface, *types.Signature:
+		return true
+	}
+	return false
+}
+
+// typePkg возвращает пакет именованного типа (нужен для поиска
+// неэкспортированных полей и методов) или nil.
+func typePkg(typ types.Type) *types.Package {
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Pkg()
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package assert — рантайм-пакет godsl для оператора assert.
+//
+// Транспилятор разворачивает assert cond, msg в проверку
+//
+//	if assert.Enabled && !cond { assert.Fail("main.godsl:12", "cond", msg) }
+//
+// При сборке с тегом godsl_noassert (godsl build --release) Enabled — ложная
+// константа, и компилятор выбрасывает проверку вместе с вычислением cond.
+package assert
+
+import "fmt"
+
+// Error — значение паники при нарушенном assert.
+type Error struct {
+	Pos     string // позиция в .godsl файле: "main.godsl:12"
+	Expr    string // исходный текст условия
+	Message string // сообщение assert; пустое, если не задано
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: assertion failed: %s", e.Pos, e.Expr)
+	}
+	return fmt.Sprintf("%s: assertion failed: %s: %s", e.Pos, e.Expr, e.Message)
+}
+
+// Fail паникует с *Error. msg — необязательное сообщение assert.
+func Fail(pos, expr string, msg ...any) {
+	e := &Error{Pos: pos, Expr: expr}
+	if len(msg) > 0 {
+		e.Message = fmt.Sprint(msg...)
+	}
+	panic(e)
+}
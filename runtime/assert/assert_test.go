@@ -0,0 +1,32 @@
+package assert_test
+
+import (
+	"testing"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/assert"
+)
+
+func TestFail_PanicsWithError(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  []any
+		want string
+	}{
+		{"no message", nil, "main.godsl:3: assertion failed: n > 0"},
+		{"message", []any{"n must be positive"}, "main.godsl:3: assertion failed: n > 0: n must be positive"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				e, ok := recover().(*assert.Error)
+				if !ok {
+					t.Fatalf("panic value is not *assert.Error")
+				}
+				if e.Error() != c.want {
+					t.Errorf("Error() = %q, want %q", e.Error(), c.want)
+				}
+			}()
+			assert.Fail("main.godsl:3", "n > 0", c.msg...)
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build godsl_noassert
+
+package assert
+
+// Enabled сообщает, проверяются ли assert в этой сборке.
+const Enabled = false
@@ -0,0 +1,89 @@
+// Package async — рантайм-пакет godsl для выражений async/await.
+//
+// async f(x) транспилируется в async.Call1(f, x): вызов запускается в
+// отдельной горутине и сразу возвращает Future. await f транспилируется
+// в f.Await(), а await для среза фьючерсов — в async.All(fs).
+package async
+
+// Future — результат вызова, выполняющегося в отдельной горутине.
+type Future[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// Go запускает fn в отдельной горутине.
+func Go[T any](fn func() (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.val, f.err = fn()
+	}()
+	return f
+}
+
+// Await блокируется до завершения вызова и возвращает его результат.
+// Повторные вызовы возвращают тот же результат.
+func (f *Future[T]) Await() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// Done возвращает канал, закрывающийся по завершении вызова, — для select.
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// All дожидается всех фьючерсов и возвращает их результаты в исходном
+// порядке. При ошибке возвращает первую по порядку ошибку, не дожидаясь
+// оставшихся.
+func All[T any](fs []*Future[T]) ([]T, error) {
+	vals := make([]T, len(fs))
+	for i, f := range fs {
+		v, err := f.Await()
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	return vals, nil
+}
+
+// Адаптеры CallN и DoN фиксируют аргументы в момент async, как оператор go,
+// и позволяют компилятору вывести тип результата из типа функции.
+
+// Call0 запускает fn() в отдельной горутине.
+func Call0[T any](fn func() (T, error)) *Future[T] { return Go(fn) }
+
+// Call1 запускает fn(a) в отдельной горутине.
+func Call1[A, T any](fn func(A) (T, error), a A) *Future[T] {
+	return Go(func() (T, error) { return fn(a) })
+}
+
+// Call2 запускает fn(a, b) в отдельной горутине.
+func Call2[A, B, T any](fn func(A, B) (T, error), a A, b B) *Future[T] {
+	return Go(func() (T, error) { return fn(a, b) })
+}
+
+// Call3 запускает fn(a, b, c) в отдельной горутине.
+func Call3[A, B, C, T any](fn func(A, B, C) (T, error), a A, b B, c C) *Future[T] {
+	return Go(func() (T, error) { return fn(a, b, c) })
+}
+
+// Do0 запускает fn(), возвращающую только ошибку, в отдельной горутине.
+func Do0(fn func() error) *Future[struct{}] {
+	return Go(func() (struct{}, error) { return struct{}{}, fn() })
+}
+
+// Do1 запускает fn(a), возвращающую только ошибку, в отдельной горутине.
+func Do1[A any](fn func(A) error, a A) *Future[struct{}] {
+	return Do0(func() error { return fn(a) })
+}
+
+// Do2 запускает fn(a, b), возвращающую только ошибку, в отдельной горутине.
+func Do2[A, B any](fn func(A, B) error, a A, b B) *Future[struct{}] {
+	return Do0(func() error { return fn(a, b) })
+}
+
+// Do3 запускает fn(a, b, c), возвращающую только ошибку, в отдельной горутине.
+func Do3[A, B, C any](fn func(A, B, C) error, a A, b B, c C) *Future[struct{}] {
+	return Do0(func() error { return fn(a, b, c) })
+}
@@ -0,0 +1,70 @@
+package async_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/async"
+)
+
+func TestCall1_Await(t *testing.T) {
+	f := async.Call1(strconv.Atoi, "42")
+	v, err := f.Await()
+	if err != nil || v != 42 {
+		t.Fatalf("Await() = %v, %v; want 42, nil", v, err)
+	}
+	// повторный Await возвращает тот же результат
+	if v, _ := f.Await(); v != 42 {
+		t.Errorf("second Await() = %v, want 42", v)
+	}
+}
+
+func TestCall_ArgsCapturedAtStart(t *testing.T) {
+	s := "1"
+	f := async.Call1(strconv.Atoi, s)
+	s = "2"
+	if v, _ := f.Await(); v != 1 {
+		t.Errorf("Await() = %v, want 1 (argument captured at async)", v)
+	}
+}
+
+func TestDo_Error(t *testing.T) {
+	boom := errors.New("boom")
+	f := async.Do1(func(string) error { return boom }, "x")
+	if _, err := f.Await(); !errors.Is(err, boom) {
+		t.Errorf("Await() error = %v, want %v", err, boom)
+	}
+}
+
+func TestAll_PreservesOrder(t *testing.T) {
+	delays := []time.Duration{30 * time.Millisecond, 0, 10 * time.Millisecond}
+	var fs []*async.Future[int]
+	for i, d := range delays {
+		fs = append(fs, async.Call2(func(i int, d time.Duration) (int, error) {
+			time.Sleep(d)
+			return i, nil
+		}, i, d))
+	}
+	vals, err := async.All(fs)
+	if err != nil {
+		t.Fatalf("All() error = %v", err)
+	}
+	for i, v := range vals {
+		if v != i {
+			t.Errorf("vals[%d] = %d, want %d", i, v, i)
+		}
+	}
+}
+
+func TestAll_FirstError(t *testing.T) {
+	fs := []*async.Future[int]{
+		async.Call1(strconv.Atoi, "1"),
+		async.Call1(strconv.Atoi, "x"),
+	}
+	vals, err := async.All(fs)
+	if err == nil || vals != nil {
+		t.Errorf("All() = %v, %v; want nil, error", vals, err)
+	}
+}
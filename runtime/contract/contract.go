@@ -0,0 +1,38 @@
+// Package contract — рантайм-пакет godsl для контрактов функций
+// (клаузы requires и ensures).
+//
+// Транспилятор проверяет предусловия при входе в функцию, а постусловия —
+// в отложенном блоке:
+//
+//	if contract.Enabled && amt <= 0 {
+//	    contract.Requires("bank.godsl:12", "Transfer", "amt > 0")
+//	}
+//
+// При сборке с тегом godsl_nocontracts (godsl build --release) Enabled —
+// ложная константа, и компилятор выбрасывает проверки вместе с сохранением
+// значений old(expr).
+package contract
+
+import "fmt"
+
+// Error — значение паники при нарушенном контракте.
+type Error struct {
+	Kind string // "precondition" или "postcondition"
+	Pos  string // позиция клаузы в .godsl файле: "bank.godsl:12"
+	Func string // функция, контракт которой нарушен
+	Expr string // исходный текст условия
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s of %s failed: %s", e.Pos, e.Kind, e.Func, e.Expr)
+}
+
+// Requires паникует с *Error о нарушенном предусловии.
+func Requires(pos, fn, expr string) {
+	panic(&Error{Kind: "precondition", Pos: pos, Func: fn, Expr: expr})
+}
+
+// Ensures паникует с *Error о нарушенном постусловии.
+func Ensures(pos, fn, expr string) {
+	panic(&Error{Kind: "postcondition", Pos: pos, Func: fn, Expr: expr})
+}
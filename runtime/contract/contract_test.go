@@ -0,0 +1,33 @@
+package contract_test
+
+import (
+	"testing"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/contract"
+)
+
+func TestRequiresEnsures_PanicWithError(t *testing.T) {
+	cases := []struct {
+		name string
+		fail func(pos, fn, expr string)
+		expr string
+		want string
+	}{
+		{"requires", contract.Requires, "amt > 0", "bank.godsl:5: precondition of Transfer failed: amt > 0"},
+		{"ensures", contract.Ensures, "a.Balance >= 0", "bank.godsl:5: postcondition of Transfer failed: a.Balance >= 0"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			defer func() {
+				e, ok := recover().(*contract.Error)
+				if !ok {
+					t.Fatalf("panic value is not *contract.Error")
+				}
+				if e.Error() != c.want {
+					t.Errorf("Error() = %q, want %q", e.Error(), c.want)
+				}
+			}()
+			c.fail("bank.godsl:5", "Transfer", c.expr)
+		})
+	}
+}
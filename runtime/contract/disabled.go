@@ -0,0 +1,6 @@
+//go:build godsl_nocontracts
+
+package contract
+
+// Enabled сообщает, проверяются ли контракты в этой сборке.
+const Enabled = false
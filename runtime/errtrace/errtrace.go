@@ -0,0 +1,98 @@
+// Package errtrace — рантайм-пакет godsl для режима --trace-errors.
+//
+// В этом режиме транспилятор оборачивает каждую ошибку, проходящую через
+// ?, throw и must, позицией в исходном .godsl файле и именем функции.
+// Format выводит накопленный путь распространения ошибки.
+package errtrace
+
+import (
+	"errors"
+	"strings"
+)
+
+// Frame — одна точка распространения ошибки.
+type Frame struct {
+	Pos  string // позиция в .godsl файле: "file.godsl:12"
+	Func string // имя функции, через которую прошла ошибка
+}
+
+// Error — ошибка с накопленным путём распространения.
+// Сообщение и цепочка Unwrap исходной ошибки не меняются.
+type Error struct {
+	err    error
+	frames []Frame
+}
+
+func (e *Error) Error() string { return e.err.Error() }
+
+// Unwrap возвращает исходную ошибку, чтобы errors.Is/errors.As продолжали работать.
+func (e *Error) Unwrap() error { return e.err }
+
+// Frames возвращает путь распространения: первой идёт точка, где ошибка
+// впервые попала в ?/throw/must, последней — самая внешняя.
+func (e *Error) Frames() []Frame { return e.frames }
+
+// Wrap добавляет к ошибке точку распространения. Для nil возвращает nil.
+// Если ошибка уже содержит трассу (в том числе обёрнутую через %w),
+// новая точка дописывается к ней.
+func Wrap(err error, pos, fn string) error {
+	if err == nil {
+		return nil
+	}
+	frame := Frame{Pos: pos, Func: fn}
+
+	var prev *Error
+	if !errors.As(err, &prev) {
+		return &Error{err: err, frames: []Frame{frame}}
+	}
+
+	frames := make([]Frame, len(prev.frames), len(prev.frames)+1)
+	copy(frames, prev.frames)
+	frames = append(frames, frame)
+
+	if err == error(prev) {
+		// Ошибка уже наша — не вкладываем обёртку в обёртку.
+		return &Error{err: prev.err, frames: frames}
+	}
+	return &Error{err: err, frames: frames}
+}
+
+// Cause снимает обёртку трассировки и возвращает исходную ошибку.
+// Используется в catch, чтобы проверка типа err.(T) видела исходный тип.
+func Cause(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.err
+	}
+	return err
+}
+
+// Frames возвращает путь распространения ошибки или nil, если трассы нет.
+func Frames(err error) []Frame {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.frames
+	}
+	return nil
+}
+
+// Format возвращает сообщение ошибки и путь её распространения:
+//
+//	connection refused
+//	    at dial (net.godsl:14)
+//	    at fetch (client.godsl:31)
+//	    at main (main.godsl:8)
+func Format(err error) string {
+	if err == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(err.Error())
+	for _, f := range Frames(err) {
+		b.WriteString("\n    at ")
+		b.WriteString(f.Func)
+		b.WriteString(" (")
+		b.WriteString(f.Pos)
+		b.WriteString(")")
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package errtrace_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/errtrace"
+)
+
+type notFound struct{}
+
+func (notFound) Error() string { return "not found" }
+
+func TestWrap_Nil(t *testing.T) {
+	if err := errtrace.Wrap(nil, "main.godsl:1", "main"); err != nil {
+		t.Errorf("Wrap(nil) = %v, want nil", err)
+	}
+}
+
+func TestWrap_KeepsMessageAndChain(t *testing.T) {
+	base := errors.New("boom")
+	err := errtrace.Wrap(base, "main.godsl:3", "foo")
+	if err.Error() != "boom" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "boom")
+	}
+	if !errors.Is(err, base) {
+		t.Error("errors.Is must see the original error")
+	}
+}
+
+func TestWrap_AccumulatesFrames(t *testing.T) {
+	err := errtrace.Wrap(notFound{}, "db.godsl:10", "load")
+	err = errtrace.Wrap(err, "svc.godsl:20", "get")
+	err = errtrace.Wrap(err, "main.godsl:30", "main")
+
+	frames := errtrace.Frames(err)
+	if len(frames) != 3 {
+		t.Fatalf("expected 3 frames, got %d: %v", len(frames), frames)
+	}
+	if frames[0].Func != "load" || frames[2].Pos != "main.godsl:30" {
+		t.Errorf("unexpected frames order: %v", frames)
+	}
+	if _, ok := errtrace.Cause(err).(notFound); !ok {
+		t.Errorf("Cause must return the original error, got %T", errtrace.Cause(err))
+	}
+}
+
+func TestWrap_ThroughFmtErrorf(t *testing.T) {
+	err := errtrace.Wrap(errors.New("eof"), "io.godsl:5", "read")
+	err = fmt.Errorf("parse config: %w", err)
+	err = errtrace.Wrap(err, "main.godsl:9", "main")
+
+	if got := err.Error(); got != "parse config: eof" {
+		t.Errorf("Error() = %q", got)
+	}
+	if n := len(errtrace.Frames(err)); n != 2 {
+		t.Errorf("expected 2 frames, got %d", n)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	err := errtrace.Wrap(errors.New("boom"), "a.godsl:1", "inner")
+	err = errtrace.Wrap(err, "b.godsl:2", "outer")
+
+	got := errtrace.Format(err)
+	want := "boom\n    at inner (a.godsl:1)\n    at outer (b.godsl:2)"
+	if got != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+	if !strings.HasPrefix(errtrace.Format(errors.New("plain")), "plain") {
+		t.Error("Format must work for errors without trace")
+	}
+}
@@ -0,0 +1,65 @@
+package result
+
+import "errors"
+
+// ErrNone — ошибка, которую передаёт ? для пустого Option.
+var ErrNone = errors.New("option has no value")
+
+// Option — значение типа T или его отсутствие.
+type Option[T any] struct {
+	val T
+	ok  bool
+}
+
+// Some возвращает Option со значением v.
+func Some[T any](v T) Option[T] { return Option[T]{val: v, ok: true} }
+
+// None возвращает пустой Option.
+func None[T any]() Option[T] { return Option[T]{} }
+
+// OptionOf собирает Option из пары (T, bool), например из v, ok := m[k].
+func OptionOf[T any](v T, ok bool) Option[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Some(v)
+}
+
+// Get возвращает значение и признак его наличия, как v, ok := m[k].
+func (o Option[T]) Get() (T, bool) { return o.val, o.ok }
+
+// IsSome сообщает, что значение есть.
+func (o Option[T]) IsSome() bool { return o.ok }
+
+// Or возвращает значение или def, если его нет.
+func (o Option[T]) Or(def T) T {
+	if !o.ok {
+		return def
+	}
+	return o.val
+}
+
+// OkOr превращает Option в Result: пустой Option становится ошибкой err.
+func (o Option[T]) OkOr(err error) Result[T] {
+	if !o.ok {
+		return Err[T](err)
+	}
+	return Ok(o.val)
+}
+
+// MapOption применяет f к значению, если оно есть.
+func MapOption[T, U any](o Option[T], f func(T) U) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return Some(f(o.val))
+}
+
+// AndThenOption продолжает вычисление f, которое само может не дать
+// значения.
+func AndThenOption[T, U any](o Option[T], f func(T) Option[U]) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return f(o.val)
+}
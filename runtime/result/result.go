@@ -0,0 +1,100 @@
+// Package result — рантайм-пакет godsl с типами Result[T] и Option[T].
+//
+// Кортеж (T, error) нельзя передать через канал или сохранить в срезе,
+// а Result[T] — обычное значение. Оператор ? разворачивает оба типа:
+//
+//	v := r?   →   v, err := r.Get(); if err != nil { return err }
+//	v := o?   →   v, ok := o.Get(); if !ok { return result.ErrNone }
+package result
+
+// Result — значение типа T или ошибка.
+type Result[T any] struct {
+	val T
+	err error
+}
+
+// Ok возвращает успешный результат со значением v.
+func Ok[T any](v T) Result[T] { return Result[T]{val: v} }
+
+// Err возвращает результат с ошибкой err.
+func Err[T any](err error) Result[T] { return Result[T]{err: err} }
+
+// Of собирает результат из пары (T, error): result.Of(strconv.Atoi(s)).
+func Of[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(v)
+}
+
+// Get возвращает значение и ошибку, как обычная функция Go.
+func (r Result[T]) Get() (T, error) { return r.val, r.err }
+
+// IsOk сообщает, что результат успешный.
+func (r Result[T]) IsOk() bool { return r.err == nil }
+
+// Err возвращает ошибку или nil.
+func (r Result[T]) Err() error { return r.err }
+
+// Or возвращает значение или def при ошибке.
+func (r Result[T]) Or(def T) T {
+	if r.err != nil {
+		return def
+	}
+	return r.val
+}
+
+// OrElse возвращает значение или результат f от ошибки.
+func (r Result[T]) OrElse(f func(error) T) T {
+	if r.err != nil {
+		return f(r.err)
+	}
+	return r.val
+}
+
+// MapErr заменяет ошибку результатом f, например оборачивает её.
+func (r Result[T]) MapErr(f func(error) error) Result[T] {
+	if r.err != nil {
+		return Err[T](f(r.err))
+	}
+	return r
+}
+
+// Option возвращает значение успешного результата; ошибка теряется.
+func (r Result[T]) Option() Option[T] {
+	if r.err != nil {
+		return None[T]()
+	}
+	return Some(r.val)
+}
+
+// Map применяет f к значению успешного результата. Ошибка передаётся без
+// изменений. Методы Go не могут вводить параметры типа, поэтому Map —
+// функция.
+func Map[T, U any](r Result[T], f func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(f(r.val))
+}
+
+// AndThen продолжает вычисление f, которое само может завершиться ошибкой.
+func AndThen[T, U any](r Result[T], f func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return f(r.val)
+}
+
+// Collect собирает значения результатов в срез в исходном порядке или
+// возвращает первую по порядку ошибку.
+func Collect[T any](rs []Result[T]) Result[[]T] {
+	vals := make([]T, len(rs))
+	for i, r := range rs {
+		if r.err != nil {
+			return Err[[]T](r.err)
+		}
+		vals[i] = r.val
+	}
+	return Ok(vals)
+}
@@ -0,0 +1,92 @@
+package result_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/result"
+)
+
+func TestOf(t *testing.T) {
+	if v, err := result.Of(strconv.Atoi("42")).Get(); err != nil || v != 42 {
+		t.Errorf("Of(Atoi(42)).Get() = %v, %v; want 42, nil", v, err)
+	}
+	r := result.Of(strconv.Atoi("x"))
+	if r.IsOk() || r.Err() == nil {
+		t.Errorf("Of(Atoi(x)) = ok, want error")
+	}
+	if got := r.Or(-1); got != -1 {
+		t.Errorf("Or(-1) = %v, want -1", got)
+	}
+}
+
+func TestMap_AndThen(t *testing.T) {
+	double := func(n int) int { return n * 2 }
+	parse := func(s string) result.Result[int] { return result.Of(strconv.Atoi(s)) }
+
+	if v, err := result.Map(parse("21"), double).Get(); err != nil || v != 42 {
+		t.Errorf("Map = %v, %v; want 42, nil", v, err)
+	}
+	boom := errors.New("boom")
+	if _, err := result.Map(result.Err[int](boom), double).Get(); !errors.Is(err, boom) {
+		t.Errorf("Map on error = %v, want %v", err, boom)
+	}
+	r := result.AndThen(result.Ok("7"), parse)
+	if v, err := r.Get(); err != nil || v != 7 {
+		t.Errorf("AndThen = %v, %v; want 7, nil", v, err)
+	}
+	if r := result.AndThen(result.Ok("x"), parse); r.IsOk() {
+		t.Errorf("AndThen with failing step = ok, want error")
+	}
+}
+
+func TestMapErr_OrElse(t *testing.T) {
+	boom := errors.New("boom")
+	r := result.Err[int](boom).MapErr(func(err error) error { return fmt.Errorf("load: %w", err) })
+	if err := r.Err(); !errors.Is(err, boom) || err.Error() != "load: boom" {
+		t.Errorf("MapErr error = %v, want load: boom", err)
+	}
+	if got := r.OrElse(func(error) int { return 5 }); got != 5 {
+		t.Errorf("OrElse = %v, want 5", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ok := []result.Result[int]{result.Ok(1), result.Ok(2)}
+	if v, err := result.Collect(ok).Get(); err != nil || len(v) != 2 || v[1] != 2 {
+		t.Errorf("Collect = %v, %v; want [1 2], nil", v, err)
+	}
+	first, second := errors.New("first"), errors.New("second")
+	bad := []result.Result[int]{result.Ok(1), result.Err[int](first), result.Err[int](second)}
+	if _, err := result.Collect(bad).Get(); err != first {
+		t.Errorf("Collect error = %v, want %v", err, first)
+	}
+}
+
+func TestOption(t *testing.T) {
+	m := map[string]int{"a": 1}
+	v, ok := m["a"]
+	o := result.OptionOf(v, ok)
+	if got, ok := o.Get(); !ok || got != 1 {
+		t.Errorf("Get() = %v, %v; want 1, true", got, ok)
+	}
+	none := result.None[int]()
+	if none.IsSome() || none.Or(9) != 9 {
+		t.Errorf("None: IsSome = %v, Or(9) = %v", none.IsSome(), none.Or(9))
+	}
+	if _, err := none.OkOr(result.ErrNone).Get(); !errors.Is(err, result.ErrNone) {
+		t.Errorf("OkOr error = %v, want ErrNone", err)
+	}
+	s := result.MapOption(result.Some(2), strconv.Itoa)
+	if got, _ := s.Get(); got != "2" {
+		t.Errorf("MapOption = %q, want \"2\"", got)
+	}
+	if result.AndThenOption(none, func(int) result.Option[int] { return result.Some(1) }).IsSome() {
+		t.Errorf("AndThenOption on None = some, want none")
+	}
+	if result.Err[int](errors.New("x")).Option().IsSome() {
+		t.Errorf("Err.Option() = some, want none")
+	}
+}
@@ -0,0 +1,14 @@
+// Package runtime встраивает исходный код рантайм-пакетов godsl.
+//
+// Пробный проход транспилятора проверяет типы через go/types, а importer
+// "gc" находит только стандартную библиотеку. Типы рантайм-пакетов,
+// которые нужны транспилятору (например, result.Result для ?), он
+// проверяет из этих исходников.
+package runtime
+
+import "embed"
+
+// Sources — исходники рантайм-пакетов по каталогам: result/result.go, …
+//
+//go:embed result/*.go
+var Sources embed.FS
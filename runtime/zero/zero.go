@@ -0,0 +1,45 @@
+// Package zero — рантайм-пакет godsl для операторов ?? и ?. (опциональные цепочки).
+//
+// Транспилятор использует его, когда по исходному коду нельзя понять,
+// с каким нулевым значением сравнивать левый операнд ?? (nil, "" или 0).
+package zero
+
+import "reflect"
+
+// Is сообщает, равно ли v нулевому значению своего типа:
+// nil для указателей, интерфейсов, срезов и карт, "" для строк, 0 для чисел.
+func Is[T any](v T) bool {
+	return reflect.ValueOf(&v).Elem().IsZero()
+}
+
+// Or возвращает v, если оно не нулевое, и def иначе.
+func Or[T any](v, def T) T {
+	if Is(v) {
+		return def
+	}
+	return v
+}
+
+// Deref возвращает *p или нулевое значение, если p == nil.
+func Deref[T any](p *T) T {
+	if p == nil {
+		var z T
+		return z
+	}
+	return *p
+}
+
+// DerefOr возвращает *p или def, если p == nil.
+func DerefOr[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// Like возвращает нулевое значение типа v. Используется для сброса
+// переменной перед опциональной цепочкой a?.B, тип которой неизвестен.
+func Like[T any](v T) T {
+	var z T
+	return z
+}
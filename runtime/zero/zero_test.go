@@ -0,0 +1,68 @@
+package zero_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sviridovkonstantin42/godsl/runtime/zero"
+)
+
+func TestIs(t *testing.T) {
+	var p *int
+	var err error
+	var m map[string]int
+	var s []int
+	cases := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"nil pointer", zero.Is(p), true},
+		{"nil interface", zero.Is(err), true},
+		{"nil map", zero.Is(m), true},
+		{"nil slice", zero.Is(s), true},
+		{"empty string", zero.Is(""), true},
+		{"zero int", zero.Is(0), true},
+		{"string", zero.Is("x"), false},
+		{"error", zero.Is(errors.New("x")), false},
+		{"empty non-nil slice", zero.Is([]int{}), false},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: Is = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestOr(t *testing.T) {
+	if got := zero.Or("", "login"); got != "login" {
+		t.Errorf(`Or("", "login") = %q`, got)
+	}
+	if got := zero.Or("nick", "login"); got != "nick" {
+		t.Errorf(`Or("nick", "login") = %q`, got)
+	}
+}
+
+func TestDerefOr(t *testing.T) {
+	var p *int
+	if got := zero.DerefOr(p, 7); got != 7 {
+		t.Errorf("DerefOr(nil, 7) = %d", got)
+	}
+	n := 0
+	if got := zero.DerefOr(&n, 7); got != 0 {
+		t.Errorf("DerefOr(&0, 7) = %d, want 0 (only nil falls back)", got)
+	}
+	if got := zero.Deref(p); got != 0 {
+		t.Errorf("Deref(nil) = %d", got)
+	}
+}
+
+func TestLike(t *testing.T) {
+	if got := zero.Like("city"); got != "" {
+		t.Errorf(`Like("city") = %q`, got)
+	}
+	n := 5
+	if got := zero.Like(&n); got != nil {
+		t.Errorf("Like(&n) = %v, want nil", got)
+	}
+}